@@ -3,57 +3,87 @@ package main
 import (
 	"context"
 	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
 	"syscall"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
-
 	"ingester/internal/blockchain"
+	"ingester/internal/cache"
+	"ingester/internal/chain"
 	"ingester/internal/config"
 	apperr "ingester/internal/errors"
 	"ingester/internal/events"
 	"ingester/internal/finality"
+	"ingester/internal/mev"
+	"ingester/internal/oracle"
+	"ingester/internal/outbox"
 	"ingester/internal/publisher"
+	"ingester/internal/token"
 )
 
 const (
-	eventChannelBuffer  = 100
+	eventChannelBuffer   = 100
+	publishRetryInterval = 5 * time.Second
+	// The seen-set keeps exact IDs for twice the startup backfill and two filters of
+	// dedupFilterCapacity older IDs.
+	dedupWindowBlocks   = 1000
+	dedupFilterCapacity = 100_000
 	healthServerTimeout = 5 * time.Second
 	shutdownGracePeriod = 5 * time.Second
+	// reloadTimeout bounds one config reload: pair metadata and feed descriptions are RPC calls.
+	reloadTimeout = time.Minute
 )
 
 var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
 func main() {
-	if err := run(); err != nil {
+	if err := dispatch(os.Args[1:]); err != nil {
 		logger.Error("Ingester stopped", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func dispatch(args []string) error {
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		return printConfig(args[2:])
+	}
 
-	rpcURL := config.GetPolygonRPCURL()
-	if err := validateRPCURL(rpcURL); err != nil {
+	cfg, err := config.Load(args, os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stdout)
+		return nil
+	}
+	if err != nil {
 		return err
 	}
+	return run(cfg, args)
+}
 
-	pairAddress, err := parsePairAddress(config.GetPairAddress())
-	if err != nil {
+// printConfig writes the effective configuration, then reports validation errors (if any)
+// so operators can see both what was resolved and why it would be rejected.
+func printConfig(args []string) error {
+	cfg, loadErr := config.Load(args, os.LookupEnv)
+	if err := cfg.WriteYAML(os.Stdout); err != nil {
 		return err
 	}
+	return loadErr
+}
+
+func run(cfg config.Config, args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	logger.Info("Configuration loaded", "pair", pairAddress.Hex())
+	pairAddresses := cfg.PairAddresses()
+	logger.Info("Configuration loaded", "pairs", len(pairAddresses), "configFile", cfg.File)
 
-	healthServer, err := startHealthServer(config.GetAppPort())
+	healthServer, err := startHealthServer(cfg.AppPort)
 	if err != nil {
 		return err
 	}
@@ -68,23 +98,125 @@ func run() error {
 		return err
 	}
 
-	topicMapper := publisher.TopicMapperFromEnv()
-	urlBuilder := publisher.DaprPublishURL()
+	topicMapper := publisher.TopicMapperFromConfig(cfg.Topics)
+	urlBuilder := publisher.DaprPublishURL(cfg.Dapr)
 
 	httpClient := &http.Client{Timeout: 10 * time.Second}
 	httpDoer := func(req *http.Request) (*http.Response, error) {
 		return httpClient.Do(req)
 	}
 
-	listener, err := blockchain.NewListener(ctx, rpcURL, pairAddress)
+	client, err := blockchain.Dial(cfg.RPCURL)
+	if err != nil {
+		return err
+	}
+	chainID, err := blockchain.DetectChainID(ctx, client, cfg.ChainID)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	profile := chain.ProfileFor(chainID)
+	if _, known := chain.Lookup(chainID); !known {
+		logger.Warn("No built-in profile for chain, using generic defaults", "chainId", chainID)
+	}
+
+	registry, err := oracle.LoadRegistry(chainID, cfg.FeedRegistry)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	var twapWindowBlocks uint64
+	if cfg.Oracle.TWAPWindow > 0 {
+		twapWindowBlocks = profile.BlocksPer(cfg.Oracle.TWAPWindow)
+	}
+	priceOracle, err := oracle.New(client, registry, oracle.Settings{
+		Mode:             oracle.Mode(cfg.Oracle.Mode),
+		MaxSpread:        cfg.Oracle.MaxSpread,
+		PriceFile:        cfg.Oracle.PriceFile,
+		TWAPWindowBlocks: twapWindowBlocks,
+	})
+	if err != nil {
+		client.Close()
+		return err
+	}
+	tokenStorage, err := openCacheStorage(cfg.CacheDir, "tokens.cache")
+	if err != nil {
+		client.Close()
+		return err
+	}
+	defer closeCacheStorage(tokenStorage)
+	priceStorage, err := openCacheStorage(cfg.CacheDir, "prices.cache")
+	if err != nil {
+		client.Close()
+		return err
+	}
+	defer closeCacheStorage(priceStorage)
+	tokens, err := token.New(client, chainID, cfg.TokenMetadata.File, tokenStorage)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	priceCache, err := blockchain.NewPriceCache(chainID, priceStorage)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	listener, err := blockchain.NewListener(ctx, client, chainID, pairAddresses, tokens, registry, priceOracle, priceCache, twapWindowBlocks)
 	if err != nil {
 		return err
 	}
 	defer listener.Close()
+	listener.SetPricePrecision(cfg.PricePrecision)
+
+	if err := checkFeeds(ctx, listener, registry, cfg.StrictFeedValidation); err != nil {
+		return err
+	}
+
+	reloadChannel, err := reloadTriggers(ctx, cfg.File, cfg.FeedRegistry)
+	if err != nil {
+		return err
+	}
+	configReloader := &reloader{args: args, started: cfg, chainID: chainID, listener: listener, registry: registry}
+
+	outboxStorage, err := openOutboxStorage(cfg.OutboxFile)
+	if err != nil {
+		return err
+	}
+	defer closeCacheStorage(outboxStorage)
+	eventOutbox, err := outbox.Open(outboxStorage)
+	if err != nil {
+		return err
+	}
+	seenStorage, err := openOutboxStorage(seenFile(cfg.OutboxFile))
+	if err != nil {
+		return err
+	}
+	defer closeCacheStorage(seenStorage)
+	seen, err := outbox.OpenSeenSet(seenStorage, dedupWindowBlocks, dedupFilterCapacity)
+	if err != nil {
+		return err
+	}
+	if checkpoint := eventOutbox.Checkpoint(); checkpoint > 0 {
+		listener.ResumeFrom(checkpoint)
+	}
 
-	confirmations := config.GetFinalityConfirmations()
+	confirmations := cfg.Confirmations(profile.FinalityConfirmations)
 	finalityBuffer := finality.NewBuffer(confirmations)
-	logger.Info("Listener ready", "finalityConfirmations", confirmations)
+	var deriveMev outbox.DeriveFunc
+	if cfg.Topics.MevEvents != "" {
+		deriveMev = mev.NewDetector().Detect
+		if confirmations == 0 {
+			logger.Warn("MEV detection needs whole blocks and sees one event at a time without finality buffering", "topic", cfg.Topics.MevEvents)
+		}
+	}
+	relay := outbox.NewRelay(eventOutbox, seen, finalityBuffer, deriveMev, func(ctx context.Context, event events.Event) error {
+		return publisher.Publish(ctx, event, codecs, topicMapper, urlBuilder, httpDoer)
+	})
+	if err := relay.Replay(ctx); err != nil {
+		return err
+	}
+	logger.Info("Listener ready", "chain", profile.Name, "chainId", chainID, "finalityConfirmations", confirmations,
+		"resumeFrom", eventOutbox.Checkpoint())
 
 	eventChannel := make(chan events.Event, eventChannelBuffer)
 	errorChannel := make(chan error, 1)
@@ -96,45 +228,131 @@ func run() error {
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 
-	return consumeEvents(ctx, cancel, codecs, topicMapper, urlBuilder, httpDoer, finalityBuffer, eventChannel, errorChannel, signalChannel)
+	reloadDone := make(chan struct{})
+	go func() {
+		defer close(reloadDone)
+		configReloader.run(ctx, reloadChannel)
+	}()
+	// Wait for a running reload before the listener is closed.
+	defer func() {
+		cancel()
+		<-reloadDone
+	}()
+
+	retryTicker := time.NewTicker(publishRetryInterval)
+	defer retryTicker.Stop()
+
+	stop := func() error {
+		return shutdown(ctx, listener.Stop, relay, eventChannel, errorChannel, cfg.ShutdownTimeout)
+	}
+	return consumeEvents(ctx, cancel, relay, stop, eventChannel, errorChannel, signalChannel, retryTicker.C)
 }
 
 func consumeEvents(
 	ctx context.Context,
 	cancel context.CancelFunc,
-	codecs publisher.CodecMap,
-	topicMapper publisher.TopicMapper,
-	urlBuilder publisher.URLBuilder,
-	httpDoer publisher.HTTPDoer,
-	finalityBuffer *finality.Buffer,
+	relay *outbox.Relay,
+	stop func() error,
 	eventChannel <-chan events.Event,
 	errorChannel <-chan error,
 	signalChannel <-chan os.Signal,
+	retryChannel <-chan time.Time,
 ) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-signalChannel:
-			logger.Info("Shutdown signal received, flushing finality buffer")
-			for _, evt := range finalityBuffer.Flush() {
-				publisher.Publish(ctx, evt, codecs, topicMapper, urlBuilder, httpDoer)
-			}
+			logger.Info("Shutdown signal received, stopping listener")
+			err := stop()
 			cancel()
-			return nil
+			return err
 		case err := <-errorChannel:
 			if err == nil || errors.Is(err, context.Canceled) {
 				return nil
 			}
 			cancel()
 			return err
+		case <-retryChannel:
+			if err := relay.Retry(ctx); err != nil {
+				cancel()
+				return err
+			}
+		case event := <-eventChannel:
+			if err := relay.Add(ctx, event); err != nil {
+				cancel()
+				return err
+			}
+		}
+	}
+}
+
+// shutdown stops the listener and relays the events it still delivers, then publishes what the
+// finality buffer already released, all within timeout. Unconfirmed events are never published;
+// they are kept for the next run when the outbox is durable (see outbox.Relay.Drain). What was
+// left undone is logged.
+func shutdown(
+	ctx context.Context,
+	stopListener func(),
+	relay *outbox.Relay,
+	eventChannel <-chan events.Event,
+	errorChannel <-chan error,
+	timeout time.Duration,
+) error {
+	deadline, cancelDeadline := context.WithTimeout(ctx, timeout)
+	defer cancelDeadline()
+
+	stopListener()
+	listenerDrained := false
+receive:
+	for {
+		select {
+		case <-deadline.Done():
+			break receive
+		case err := <-errorChannel:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				logger.Warn("Listener failed while stopping", "error", err)
+			}
+			listenerDrained = err == nil
+			break receive
+		case event := <-eventChannel:
+			if err := relay.Add(deadline, event); err != nil {
+				return err
+			}
+		}
+	}
+	// Events the listener sent just before returning.
+	for drained := false; !drained; {
+		select {
 		case event := <-eventChannel:
-			confirmed := finalityBuffer.Add(event)
-			for _, evt := range confirmed {
-				publisher.Publish(ctx, evt, codecs, topicMapper, urlBuilder, httpDoer)
+			if err := relay.Add(deadline, event); err != nil {
+				return err
 			}
+		default:
+			drained = true
 		}
 	}
+
+	report, err := relay.Drain(deadline, publishRetryInterval)
+	if err != nil {
+		return err
+	}
+	attributes := []any{
+		"listenerDrained", listenerDrained,
+		"unconfirmed", report.Unconfirmed,
+		"unpublished", report.Unpublished,
+		"keptInOutbox", report.Durable,
+		"lost", report.Lost,
+	}
+	switch {
+	case report.Lost > 0:
+		logger.Error("Shutdown lost unconfirmed or unpublished events; set OUTBOX_FILE to keep them", attributes...)
+	case !listenerDrained || report.Unpublished > 0:
+		logger.Warn("Shutdown deadline reached before all work finished", attributes...)
+	default:
+		logger.Info("Shutdown complete", attributes...)
+	}
+	return nil
 }
 
 func startHealthServer(appPort string) (*http.Server, error) {
@@ -170,6 +388,48 @@ func startHealthServer(appPort string) (*http.Server, error) {
 	return healthServer, nil
 }
 
+// openCacheStorage opens name under dir, or returns a nil Storage (memory only) when dir is empty.
+func openCacheStorage(dir, name string) (cache.Storage, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	storage, err := cache.OpenFileStorage(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
+
+// openOutboxStorage opens the outbox log at path, or returns a nil Storage (memory only) when
+// path is empty. Every record is synced: the outbox acknowledges events on the strength of it.
+func openOutboxStorage(path string) (cache.Storage, error) {
+	if path == "" {
+		return nil, nil
+	}
+	storage, err := cache.OpenFileStorageWith(path, cache.FileOptions{Sync: true})
+	if err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
+
+// seenFile places the seen-set next to the outbox log; "" when the outbox is memory only.
+func seenFile(outboxFile string) string {
+	if outboxFile == "" {
+		return ""
+	}
+	return outboxFile + ".seen"
+}
+
+func closeCacheStorage(storage cache.Storage) {
+	if storage == nil {
+		return
+	}
+	if err := storage.Close(); err != nil {
+		logger.Error("Failed to close cache storage", "error", err)
+	}
+}
+
 func stopHealthServer(healthServer *http.Server) {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
 	defer cancel()
@@ -184,25 +444,6 @@ func healthHandler() http.Handler {
 	mux.HandleFunc("/health", func(writer http.ResponseWriter, _ *http.Request) {
 		writer.WriteHeader(http.StatusOK)
 	})
+	mux.Handle("/debug/vars", expvar.Handler())
 	return mux
 }
-
-func parsePairAddress(text string) (common.Address, error) {
-	if !common.IsHexAddress(text) {
-		return common.Address{}, &apperr.ConfigError{Message: "PAIR_ADDRESS must be valid hex address"}
-	}
-	return common.HexToAddress(text), nil
-}
-
-func validateRPCURL(url string) error {
-	if url == "" {
-		return &apperr.ConfigError{Message: "POLYGON_RPC_URL required (must be WebSocket: wss:// or ws://)"}
-	}
-	if len(url) < 5 {
-		return &apperr.ConfigError{Message: "POLYGON_RPC_URL too short"}
-	}
-	if strings.HasPrefix(url, "wss://") || strings.HasPrefix(url, "ws://") {
-		return nil
-	}
-	return &apperr.ConfigError{Message: "POLYGON_RPC_URL must be WebSocket URL (wss:// or ws://)"}
-}
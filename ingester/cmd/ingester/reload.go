@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ingester/internal/blockchain"
+	"ingester/internal/config"
+	"ingester/internal/oracle"
+)
+
+// reloader re-reads configuration on SIGHUP or config/feed registry file change and applies the
+// parts that can change live: tracked pairs, price feeds and stablecoins. The finality buffer is
+// untouched.
+type reloader struct {
+	args     []string
+	started  config.Config
+	chainID  uint64
+	listener *blockchain.Listener
+	registry *oracle.Registry
+}
+
+// checkFeeds validates registry against the chain. Mismatches are logged, or returned when strict.
+func checkFeeds(ctx context.Context, listener *blockchain.Listener, registry *oracle.Registry, strict bool) error {
+	err := listener.ValidateFeeds(ctx, registry)
+	if err == nil {
+		return nil
+	}
+	if strict {
+		return err
+	}
+	logger.Warn("Chainlink feed validation failed, continuing because strict validation is off", "error", err)
+	return nil
+}
+
+// reloadTriggers merges SIGHUP and changes to any of the given files into one channel.
+// Empty paths are skipped.
+func reloadTriggers(ctx context.Context, files ...string) (<-chan struct{}, error) {
+	fileChanges := make(chan struct{}, 1)
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		changes, err := config.Watch(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			for range changes {
+				select {
+				case fileChanges <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	triggers := make(chan struct{}, 1)
+	go func() {
+		defer signal.Stop(hangups)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+			case <-fileChanges:
+			}
+			select {
+			case triggers <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return triggers, nil
+}
+
+// run applies a reload for each trigger, one at a time, until ctx ends. It has its own
+// goroutine so a slow reload never holds up event delivery; each reload is bounded by
+// reloadTimeout, and the listener and registry it updates are safe for concurrent use.
+func (r *reloader) run(ctx context.Context, triggers <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-triggers:
+			reloadCtx, cancel := context.WithTimeout(ctx, reloadTimeout)
+			r.reload(reloadCtx)
+			cancel()
+		}
+	}
+}
+
+func (r *reloader) reload(ctx context.Context) {
+	next, err := config.Load(r.args, os.LookupEnv)
+	if err != nil {
+		logger.Error("Config reload rejected, keeping current configuration", "error", err)
+		return
+	}
+	if !next.ReloadableEqual(r.started) {
+		logger.Warn("Config reload ignores changes to fields that require a restart")
+	}
+
+	nextRegistry, err := oracle.LoadRegistry(r.chainID, next.FeedRegistry)
+	if err != nil {
+		logger.Error("Feed registry reload rejected, keeping current configuration", "error", err)
+		return
+	}
+	if err := checkFeeds(ctx, r.listener, nextRegistry, r.started.StrictFeedValidation); err != nil {
+		logger.Error("Feed registry reload rejected, keeping current configuration", "error", err)
+		return
+	}
+
+	pairDiff, err := r.listener.UpdatePairs(ctx, next.PairAddresses())
+	if err != nil {
+		logger.Error("Config reload failed, keeping current configuration", "error", err)
+		return
+	}
+
+	registryDiff := r.registry.Replace(nextRegistry)
+	r.listener.InvalidatePrices(registryDiff.AffectedTokens())
+
+	logger.Info("Configuration reloaded",
+		"pairsAdded", len(pairDiff.Added),
+		"pairsRemoved", len(pairDiff.Removed),
+		"feedsAdded", len(registryDiff.AddedFeeds),
+		"feedsRemoved", len(registryDiff.RemovedFeeds),
+		"feedsChanged", len(registryDiff.ChangedFeeds),
+		"stablecoinsAdded", len(registryDiff.AddedStablecoins),
+		"stablecoinsRemoved", len(registryDiff.RemovedStablecoins),
+	)
+}
@@ -21,11 +21,15 @@ var burnEventSchemaText string
 //go:embed TransferEvent.avsc
 var transferEventSchemaText string
 
+//go:embed MevCandidateEvent.avsc
+var mevCandidateEventSchemaText string
+
 var schemaRegistry = map[events.EventType]string{
-	events.EventTypeSwap:     swapEventSchemaText,
-	events.EventTypeMint:     mintEventSchemaText,
-	events.EventTypeBurn:     burnEventSchemaText,
-	events.EventTypeTransfer: transferEventSchemaText,
+	events.EventTypeSwap:         swapEventSchemaText,
+	events.EventTypeMint:         mintEventSchemaText,
+	events.EventTypeBurn:         burnEventSchemaText,
+	events.EventTypeTransfer:     transferEventSchemaText,
+	events.EventTypeMevCandidate: mevCandidateEventSchemaText,
 }
 
 func NewCodec(eventType events.EventType) (*goavro.Codec, error) {
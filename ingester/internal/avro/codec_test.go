@@ -1,6 +1,7 @@
 package avro
 
 import (
+	"math/big"
 	"testing"
 
 	"ingester/internal/events"
@@ -35,6 +36,9 @@ func TestEncodeDecode_SwapEvent(t *testing.T) {
 		t.Fatalf("NewCodec failed: %v", err)
 	}
 
+	roundID := "18446744073709551617" // phase 1, round 1: larger than a long
+	amount0In := "0.000000000000001000"
+	priceExact := "0.000000000000000000000001"
 	// Use nil volumeUSD to avoid Avro union encoding complexity in tests
 	original := events.SwapEvent{
 		BaseEvent: events.BaseEvent{
@@ -59,6 +63,15 @@ func TestEncodeDecode_SwapEvent(t *testing.T) {
 		VolumeUSD:  nil, // Nil to simplify test
 		GasUsed:    21000,
 		GasPrice:   "50000000000",
+
+		PriceSource:  "CHAINLINK",
+		PriceRoundID: &roundID,
+		Direction:    events.SwapDirectionToken0ToToken1,
+		HopIndex:     1,
+		HopCount:     2,
+
+		Amount0InAdjusted: &amount0In,
+		PriceExact:        &priceExact,
 	}
 
 	// Encode
@@ -90,6 +103,29 @@ func TestEncodeDecode_SwapEvent(t *testing.T) {
 	if decodedMap["sender"] != "0xsender" {
 		t.Errorf("Expected sender '0xsender', got %v", decodedMap["sender"])
 	}
+
+	if decodedMap["priceSource"] != "CHAINLINK" {
+		t.Errorf("Expected priceSource CHAINLINK, got %v", decodedMap["priceSource"])
+	}
+	if roundUnion, ok := decodedMap["priceRoundId"].(map[string]interface{}); !ok || roundUnion["string"] != roundID {
+		t.Errorf("Expected priceRoundId %s, got %v", roundID, decodedMap["priceRoundId"])
+	}
+	if decodedMap["direction"] != "TOKEN0_TO_TOKEN1" || decodedMap["hopIndex"] != int32(1) || decodedMap["hopCount"] != int32(2) {
+		t.Errorf("Expected hop 1 of 2 selling token0, got %v %v of %v", decodedMap["direction"], decodedMap["hopIndex"], decodedMap["hopCount"])
+	}
+	if decodedMap["router"] != nil || decodedMap["viaAggregator"] != false {
+		t.Errorf("Expected no router, got %v (aggregator %v)", decodedMap["router"], decodedMap["viaAggregator"])
+	}
+	if adjusted, _ := decodedMap["amount0InAdjusted"].(map[string]interface{}); adjusted == nil ||
+		adjusted["bytes.decimal"].(*big.Rat).Cmp(big.NewRat(1, 1e15)) != 0 {
+		t.Errorf("Expected amount0InAdjusted 1e-15, got %v", decodedMap["amount0InAdjusted"])
+	}
+	if decodedMap["amount1OutAdjusted"] != nil {
+		t.Errorf("Expected amount1OutAdjusted null, got %v", decodedMap["amount1OutAdjusted"])
+	}
+	if exact, _ := decodedMap["priceExact"].(map[string]interface{}); exact["string"] != priceExact || decodedMap["priceDecimal"] != nil {
+		t.Errorf("Expected priceExact %s without priceDecimal, got %v and %v", priceExact, decodedMap["priceExact"], decodedMap["priceDecimal"])
+	}
 }
 
 func TestEncodeDecode_MintEvent(t *testing.T) {
@@ -98,6 +134,8 @@ func TestEncodeDecode_MintEvent(t *testing.T) {
 		t.Fatalf("NewCodec failed: %v", err)
 	}
 
+	token0Name := "Maker"
+	token0Decimals := int32(18)
 	original := events.MintEvent{
 		BaseEvent: events.BaseEvent{
 			EventType:       events.EventTypeMint,
@@ -110,6 +148,8 @@ func TestEncodeDecode_MintEvent(t *testing.T) {
 			Token0:          "0xtoken0",
 			Token1:          "0xtoken1",
 			EventTimestamp:  1640001010,
+			Token0Name:      &token0Name,
+			Token0Decimals:  &token0Decimals,
 		},
 		Sender:  "0xminter",
 		Amount0: "5000",
@@ -140,6 +180,18 @@ func TestEncodeDecode_MintEvent(t *testing.T) {
 	if decodedMap["amount0"] != "5000" {
 		t.Errorf("Expected amount0 '5000', got %v", decodedMap["amount0"])
 	}
+
+	if name, _ := decodedMap["token0Name"].(map[string]interface{}); name["string"] != "Maker" {
+		t.Errorf("Expected token0Name 'Maker', got %v", decodedMap["token0Name"])
+	}
+
+	if decimals, _ := decodedMap["token0Decimals"].(map[string]interface{}); decimals["int"] != int32(18) {
+		t.Errorf("Expected token0Decimals 18, got %v", decodedMap["token0Decimals"])
+	}
+
+	if decodedMap["token1Decimals"] != nil {
+		t.Errorf("Expected token1Decimals null, got %v", decodedMap["token1Decimals"])
+	}
 }
 
 func TestEncodeDecode_BurnEvent(t *testing.T) {
@@ -148,6 +200,8 @@ func TestEncodeDecode_BurnEvent(t *testing.T) {
 		t.Fatalf("NewCodec failed: %v", err)
 	}
 
+	lpTokens := "1000"
+	totalUSD := 8.5
 	original := events.BurnEvent{
 		BaseEvent: events.BaseEvent{
 			EventType:       events.EventTypeBurn,
@@ -165,6 +219,8 @@ func TestEncodeDecode_BurnEvent(t *testing.T) {
 		Recipient: "0xreceiver",
 		Amount0:   "2500",
 		Amount1:   "2125",
+		LPTokens:  &lpTokens,
+		TotalUSD:  &totalUSD,
 	}
 
 	// Encode
@@ -191,6 +247,18 @@ func TestEncodeDecode_BurnEvent(t *testing.T) {
 	if decodedMap["recipient"] != "0xreceiver" {
 		t.Errorf("Expected recipient '0xreceiver', got %v", decodedMap["recipient"])
 	}
+
+	if lp, _ := decodedMap["lpTokens"].(map[string]interface{}); lp["string"] != "1000" {
+		t.Errorf("Expected lpTokens '1000', got %v", decodedMap["lpTokens"])
+	}
+
+	if total, _ := decodedMap["totalUSD"].(map[string]interface{}); total["double"] != 8.5 {
+		t.Errorf("Expected totalUSD 8.5, got %v", decodedMap["totalUSD"])
+	}
+
+	if decodedMap["amount0USD"] != nil || decodedMap["shareOfSupply"] != nil {
+		t.Errorf("Expected amount0USD and shareOfSupply null, got %v and %v", decodedMap["amount0USD"], decodedMap["shareOfSupply"])
+	}
 }
 
 func TestEncodeDecode_TransferEvent(t *testing.T) {
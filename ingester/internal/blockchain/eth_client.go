@@ -2,23 +2,55 @@ package blockchain
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	apperr "ingester/internal/errors"
 )
 
 // EthClient abstracts the Ethereum JSON-RPC operations used by Listener.
 // *ethclient.Client satisfies this without adaptation.
 type EthClient interface {
+	ChainID(ctx context.Context) (*big.Int, error)
 	BlockNumber(ctx context.Context) (uint64, error)
 	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error)
+	TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error)
 	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	Close()
 }
 
 var _ EthClient = (*ethclient.Client)(nil)
+
+// Dial connects to the WebSocket RPC endpoint.
+func Dial(rpcURL string) (*ethclient.Client, error) {
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, &apperr.ConnectionError{Message: "rpc dial failed", Cause: err}
+	}
+	return client, nil
+}
+
+// DetectChainID asks the node for its chain ID via eth_chainId. When expected is non-zero the
+// node must report that chain, so a mispointed RPC URL fails at startup rather than producing
+// events attributed to the wrong chain.
+func DetectChainID(ctx context.Context, client EthClient, expected uint64) (uint64, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return 0, &apperr.ConnectionError{Message: "rpc chain id fetch failed", Cause: err}
+	}
+	if !chainID.IsUint64() {
+		return 0, &apperr.DataError{Message: fmt.Sprintf("chain id %s out of range", chainID)}
+	}
+	if expected != 0 && chainID.Uint64() != expected {
+		return 0, &apperr.ConfigError{Message: fmt.Sprintf("CHAIN_ID is %d but the RPC endpoint serves chain %d", expected, chainID.Uint64())}
+	}
+	return chainID.Uint64(), nil
+}
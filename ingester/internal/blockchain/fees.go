@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/chain"
+	"ingester/internal/oracle"
+)
+
+// nativeDecimals is the precision of every supported chain's native token.
+const nativeDecimals = 18
+
+// txFees is what a swap's transaction paid for gas. baseFee and priorityFee are per gas; fee is
+// the total in wei of the native token.
+type txFees struct {
+	txType      *int32
+	baseFee     *big.Int
+	priorityFee *big.Int
+	fee         *big.Int
+}
+
+// feesOf splits the effective gas price into the block's base fee and the priority fee the
+// sender paid on top. Both are nil before London or on chains whose headers carry no base
+// fee, and priorityFee also when the receipt has no effective gas price. Without the receipt
+// or the transaction only the base fee is known.
+func feesOf(receipt *types.Receipt, tx *types.Transaction, header *types.Header) txFees {
+	var fees txFees
+	if receipt == nil || tx == nil {
+		fees.baseFee = header.BaseFee
+		return fees
+	}
+	txType := int32(tx.Type())
+	fees.txType = &txType
+	if receipt.EffectiveGasPrice != nil {
+		fees.fee = new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	if header.BaseFee != nil {
+		fees.baseFee = header.BaseFee
+		if receipt.EffectiveGasPrice != nil && receipt.EffectiveGasPrice.Cmp(header.BaseFee) >= 0 {
+			fees.priorityFee = new(big.Int).Sub(receipt.EffectiveGasPrice, header.BaseFee)
+		}
+	}
+	return fees
+}
+
+// feeUSD values the fee at the USD price of the chain's wrapped native token at block; nil
+// when the chain has no wrapped native token or it has no price.
+func (l *Listener) feeUSD(ctx context.Context, fee *big.Int, block chain.Block) *float64 {
+	wrappedNative := chain.ProfileFor(l.chainID).WrappedNative
+	if fee == nil || wrappedNative == (chain.Profile{}).WrappedNative {
+		return nil
+	}
+	quote, found := oracle.GetTokenUSDPrice(ctx, wrappedNative, block, l.registry, l.priceCache, l.priceOracle)
+	if !found || quote.Price <= 0 {
+		return nil
+	}
+	return toUSD(fee, nativeDecimals, quote.Price)
+}
@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/blockchain/mocks"
+	"ingester/internal/chain"
+	"ingester/internal/oracle"
+	"ingester/internal/token"
+)
+
+func TestFeesOf_SplitsBaseAndPriorityFee(t *testing.T) {
+	tx := types.NewTx(&types.DynamicFeeTx{})
+	receipt := &types.Receipt{GasUsed: 100_000, EffectiveGasPrice: big.NewInt(32e9)}
+
+	fees := feesOf(receipt, tx, &types.Header{BaseFee: big.NewInt(30e9)})
+
+	if fees.txType == nil || *fees.txType != types.DynamicFeeTxType {
+		t.Errorf("Expected type 2, got %v", fees.txType)
+	}
+	if fees.baseFee.Cmp(big.NewInt(30e9)) != 0 || fees.priorityFee.Cmp(big.NewInt(2e9)) != 0 {
+		t.Errorf("Expected 30 gwei base and 2 gwei priority fee, got %v and %v", fees.baseFee, fees.priorityFee)
+	}
+	if fees.fee.Cmp(big.NewInt(32e14)) != 0 {
+		t.Errorf("Expected a 0.0032 native fee, got %v wei", fees.fee)
+	}
+}
+
+func TestFeesOf_WithoutBaseFee(t *testing.T) {
+	receipt := &types.Receipt{GasUsed: 21_000, EffectiveGasPrice: big.NewInt(1e9)}
+
+	fees := feesOf(receipt, types.NewTx(&types.LegacyTx{}), &types.Header{})
+
+	if fees.txType == nil || *fees.txType != types.LegacyTxType || fees.baseFee != nil || fees.priorityFee != nil {
+		t.Errorf("Expected a legacy transaction without fee split, got %+v", fees)
+	}
+	if fees.fee.Cmp(big.NewInt(21e12)) != 0 {
+		t.Errorf("Expected 21000 gwei fee, got %v wei", fees.fee)
+	}
+}
+
+func TestListener_FeeUSDUsesWrappedNativePrice(t *testing.T) {
+	wmatic := chain.ProfileFor(137).WrappedNative
+	client := mocks.NewMockEthClient(t)
+	quotes := stubOracle{wmatic: {Price: 0.5, Source: oracle.PriceSourceChainlink}}
+	listener := NewListenerWith(client, 137, nil, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), quotes, oracle.NewPriceCache(time.Minute, 1), 0)
+	defer listener.stopCaches()
+
+	feeUSD := listener.feeUSD(context.Background(), big.NewInt(2e18), chain.Latest)
+
+	if feeUSD == nil || *feeUSD != 1 {
+		t.Errorf("Expected $1 for 2 MATIC, got %v", feeUSD)
+	}
+	if unknown := listener.feeUSD(context.Background(), nil, chain.Latest); unknown != nil {
+		t.Errorf("Expected no USD fee without a fee, got %v", *unknown)
+	}
+}
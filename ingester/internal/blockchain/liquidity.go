@@ -0,0 +1,159 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+	"ingester/internal/oracle"
+)
+
+// lpDecimals is the precision of every Uniswap V2 LP token.
+const lpDecimals = 18
+
+// lpMovement is the LP token side of a Mint or Burn, read from the pair's Transfer logs in the
+// same transaction.
+type lpMovement struct {
+	amount   *big.Int
+	provider common.Address
+}
+
+// mintedLP finds the LP tokens a Mint issued: the pair's last Transfer from the zero address
+// before the Mint log. Earlier mints in the same call go to the protocol fee recipient or, for
+// a pair's first deposit, lock the minimum liquidity at the zero address.
+func mintedLP(receipt *types.Receipt, mintLog types.Log) (lpMovement, bool) {
+	var movement lpMovement
+	found := false
+	for _, transfer := range pairTransfers(receipt, mintLog) {
+		from, to, value, err := parseTransferLog(*transfer)
+		if err != nil || from != (common.Address{}) || to == (common.Address{}) {
+			continue
+		}
+		movement, found = lpMovement{amount: value, provider: to}, true
+	}
+	return movement, found
+}
+
+// burnedLP finds the LP tokens a Burn redeemed: the pair's last Transfer from itself to the
+// zero address before the Burn log. The provider is whoever last sent LP tokens to the pair
+// before that, usually through a router's transferFrom.
+func burnedLP(receipt *types.Receipt, burnLog types.Log) (lpMovement, bool) {
+	var movement lpMovement
+	found := false
+	var provider common.Address
+	for _, transfer := range pairTransfers(receipt, burnLog) {
+		from, to, value, err := parseTransferLog(*transfer)
+		if err != nil {
+			continue
+		}
+		switch {
+		case to == burnLog.Address && from != (common.Address{}):
+			provider = from
+		case from == burnLog.Address && to == (common.Address{}):
+			movement, found = lpMovement{amount: value, provider: provider}, true
+		}
+	}
+	return movement, found
+}
+
+// pairTransfers returns the LP Transfer logs the pair emitted before eventLog in its
+// transaction, in log order.
+func pairTransfers(receipt *types.Receipt, eventLog types.Log) []*types.Log {
+	var transfers []*types.Log
+	for _, logEntry := range receipt.Logs {
+		if logEntry.Index >= eventLog.Index {
+			break
+		}
+		if logEntry.Address == eventLog.Address && len(logEntry.Topics) > 0 && logEntry.Topics[0] == TransferEventTopic {
+			transfers = append(transfers, logEntry)
+		}
+	}
+	return transfers
+}
+
+// lpPosition correlates a Mint or Burn log with the pair's LP Transfers in its transaction.
+// All three are nil when no matching Transfer precedes the log; provider is also nil for a
+// burn whose LP tokens were not sent to the pair in the same transaction.
+func (l *Listener) lpPosition(ctx context.Context, receipt *types.Receipt, logEntry types.Log, burned bool) (lpTokens, provider *string, share *float64) {
+	movement, found := mintedLP(receipt, logEntry)
+	if burned {
+		movement, found = burnedLP(receipt, logEntry)
+	}
+	if !found {
+		logger.Debug("No LP transfer found for liquidity event", "pair", logEntry.Address.Hex(), "tx", logEntry.TxHash.Hex(), "logIndex", logEntry.Index)
+		return nil, nil, nil
+	}
+	lpTokens = bigString(movement.amount)
+	if movement.provider != (common.Address{}) {
+		hex := movement.provider.Hex()
+		provider = &hex
+	}
+	return lpTokens, provider, l.lpShare(ctx, logEntry.Address, logEntry.BlockNumber, movement.amount, burned)
+}
+
+// lpShare is amount as a fraction of the pair's LP supply at the end of blockNumber; for a
+// burn, of that supply plus the burned amount. It approximates the supply around the event
+// when other mints or burns on the pair share the block, and is nil when totalSupply cannot
+// be read at the block (no archive state).
+func (l *Listener) lpShare(ctx context.Context, pair common.Address, blockNumber uint64, amount *big.Int, burned bool) *float64 {
+	var supply *big.Int
+	if err := contract.CallContractAt(ctx, l.client, pair, UniswapV2PairABI, "totalSupply", new(big.Int).SetUint64(blockNumber), &supply); err != nil {
+		logger.Debug("LP total supply unavailable", "pair", pair.Hex(), "block", blockNumber, "error", err)
+		return nil
+	}
+	if burned {
+		supply = new(big.Int).Add(supply, amount)
+	}
+	if supply.Sign() == 0 {
+		return nil
+	}
+	share, _ := new(big.Rat).SetFrac(amount, supply).Float64()
+	return &share
+}
+
+// liquidityValuation is the USD side of a Mint or Burn: each token amount and their sum.
+type liquidityValuation struct {
+	amount0USD *float64
+	amount1USD *float64
+	totalUSD   *float64
+}
+
+// valueLiquidity prices each side through the same stablecoin, feed, reference pool and
+// override path as valueSwap. When only one side has a price, totalUSD is twice that side:
+// liquidity enters and leaves a V2 pair at the pool's price, so both sides are worth the same.
+// The unpriced side's own amount stays nil rather than be inferred.
+func valueLiquidity(
+	ctx context.Context,
+	registry *oracle.Registry,
+	priceCache *oracle.PriceCache,
+	priceOracle oracle.PriceOracle,
+	block chain.Block,
+	amount0, amount1 *big.Int,
+	pairMetadata PairMetadata,
+) liquidityValuation {
+	var valuation liquidityValuation
+	if quote, found := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token0Address, block, registry, priceCache, priceOracle); found && quote.Price > 0 {
+		valuation.amount0USD = toUSD(amount0, pairMetadata.Token0Decimals, quote.Price)
+	}
+	if quote, found := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token1Address, block, registry, priceCache, priceOracle); found && quote.Price > 0 {
+		valuation.amount1USD = toUSD(amount1, pairMetadata.Token1Decimals, quote.Price)
+	}
+
+	var total float64
+	switch {
+	case valuation.amount0USD != nil && valuation.amount1USD != nil:
+		total = *valuation.amount0USD + *valuation.amount1USD
+	case valuation.amount0USD != nil:
+		total = 2 * *valuation.amount0USD
+	case valuation.amount1USD != nil:
+		total = 2 * *valuation.amount1USD
+	default:
+		return valuation
+	}
+	valuation.totalUSD = &total
+	return valuation
+}
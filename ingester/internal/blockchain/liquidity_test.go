@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/mock"
+
+	"ingester/internal/blockchain/mocks"
+	"ingester/internal/chain"
+	"ingester/internal/oracle"
+	"ingester/internal/token"
+)
+
+var (
+	testProvider  = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	testFeeTo     = common.HexToAddress("0x00000000000000000000000000000000000000fe")
+	testLPRouter  = common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	testOtherPair = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+)
+
+func transferLog(pair, from, to common.Address, value int64, index uint) *types.Log {
+	return &types.Log{
+		Address: pair,
+		Index:   index,
+		Topics:  []common.Hash{TransferEventTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+	}
+}
+
+func TestMintedLP_SkipsFeeAndMinimumLiquidityMints(t *testing.T) {
+	mintLog := types.Log{Address: testPairA, Index: 5}
+	receipt := &types.Receipt{Logs: []*types.Log{
+		transferLog(testToken0, testProvider, testPairA, 500, 0), // token deposit, not an LP token
+		transferLog(testPairA, common.Address{}, testFeeTo, 3, 1),
+		transferLog(testPairA, common.Address{}, common.Address{}, 1000, 2),
+		transferLog(testPairA, common.Address{}, testProvider, 9000, 3),
+		&mintLog,
+		transferLog(testPairA, common.Address{}, testFeeTo, 7, 6), // after the Mint
+	}}
+
+	movement, found := mintedLP(receipt, mintLog)
+
+	if !found || movement.amount.Int64() != 9000 || movement.provider != testProvider {
+		t.Errorf("Expected 9000 LP minted to the provider, got %v %+v", found, movement)
+	}
+}
+
+func TestBurnedLP_ProviderSentTokensToThePair(t *testing.T) {
+	burnLog := types.Log{Address: testPairA, Index: 4}
+	receipt := &types.Receipt{Logs: []*types.Log{
+		transferLog(testOtherPair, testProvider, testOtherPair, 1, 0),
+		transferLog(testPairA, testProvider, testPairA, 2500, 1),
+		transferLog(testPairA, testPairA, common.Address{}, 2500, 2),
+		transferLog(testToken0, testPairA, testLPRouter, 100, 3),
+		&burnLog,
+	}}
+
+	movement, found := burnedLP(receipt, burnLog)
+
+	if !found || movement.amount.Int64() != 2500 || movement.provider != testProvider {
+		t.Errorf("Expected 2500 LP burned by the provider, got %v %+v", found, movement)
+	}
+	if _, found := burnedLP(&types.Receipt{Logs: []*types.Log{&burnLog}}, burnLog); found {
+		t.Error("Expected no burn without an LP transfer to the zero address")
+	}
+}
+
+func TestListener_LPShare(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).Return(common.LeftPadBytes(big.NewInt(7500).Bytes(), 32), nil)
+	listener := NewListenerWith(client, 137, nil, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 0)
+	defer listener.stopCaches()
+
+	if share := listener.lpShare(context.Background(), testPairA, 10, big.NewInt(1500), false); share == nil || *share != 0.2 {
+		t.Errorf("Expected a mint of 1500 out of 7500 to be 0.2, got %v", share)
+	}
+	if share := listener.lpShare(context.Background(), testPairA, 10, big.NewInt(2500), true); share == nil || *share != 0.25 {
+		t.Errorf("Expected a burn of 2500 out of 7500+2500 to be 0.25, got %v", share)
+	}
+}
+
+func TestValueLiquidity(t *testing.T) {
+	pair := PairMetadata{Token0Address: testToken0, Token1Address: testToken1, Token0Decimals: 18, Token1Decimals: 6}
+	feed := oracle.Quote{Price: 0.5, Source: oracle.PriceSourceChainlink}
+
+	tests := []struct {
+		name        string
+		stablecoins []common.Address
+		quotes      stubOracle
+		amount0USD  *float64
+		amount1USD  *float64
+		totalUSD    *float64
+	}{
+		{"both priced", []common.Address{testToken1}, stubOracle{testToken0: feed}, ptr(2.0), ptr(2.5), ptr(4.5)},
+		{"token0 only", nil, stubOracle{testToken0: feed}, ptr(2.0), nil, ptr(4.0)},
+		{"token1 only", []common.Address{testToken1}, stubOracle{}, nil, ptr(2.5), ptr(5.0)},
+		{"no price", nil, stubOracle{}, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 4 token0 and 2.5 token1 (6 decimals)
+			valuation := valueLiquidity(context.Background(), oracle.NewRegistry(tt.stablecoins, nil, nil), oracle.NewPriceCache(time.Minute, 1),
+				tt.quotes, chain.Latest, big.NewInt(4e18), big.NewInt(2_500_000), pair)
+
+			for name, got := range map[string][2]*float64{
+				"amount0USD": {valuation.amount0USD, tt.amount0USD},
+				"amount1USD": {valuation.amount1USD, tt.amount1USD},
+				"totalUSD":   {valuation.totalUSD, tt.totalUSD},
+			} {
+				if (got[0] == nil) != (got[1] == nil) || (got[0] != nil && *got[0] != *got[1]) {
+					t.Errorf("Expected %s %v, got %v", name, got[1], got[0])
+				}
+			}
+		})
+	}
+}
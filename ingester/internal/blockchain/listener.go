@@ -7,64 +7,249 @@ import (
 	"log/slog"
 	"math/big"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 
 	"ingester/internal/cache"
+	"ingester/internal/chain"
 	"ingester/internal/contract"
 	apperr "ingester/internal/errors"
 	"ingester/internal/events"
 	"ingester/internal/oracle"
+	"ingester/internal/token"
 )
 
 type Listener struct {
-	pairAddress  common.Address
-	client       EthClient
-	pairMetadata PairMetadata
-	symbolCache  *cache.Cache[common.Address, string]
-	priceCache   *cache.Cache[common.Address, float64]
-	priceOracle  oracle.PriceOracle
+	client      EthClient
+	chainID     uint64
+	pairsMu     sync.RWMutex
+	pairs       map[common.Address]PairMetadata
+	resubscribe chan struct{}
+	lastBlock   atomic.Uint64
+	resumeFrom  uint64
+	stop        chan struct{}
+	stopOnce    sync.Once
+	registry    *oracle.Registry
+	tokens      *token.Service
+	priceCache  *oracle.PriceCache
+	priceOracle oracle.PriceOracle
+	twapBlocks  uint64
+	twapCache   *cache.Cache[pairBlock, float64]
+	// twapBackoff holds pairs whose TWAP read failed recently; twapWarned whether one has.
+	twapBackoff *cache.Cache[common.Address, bool]
+	twapWarned  atomic.Bool
+	stopCaches  context.CancelFunc
+	// pricePrecision is the significant digits of a swap's priceExact.
+	pricePrecision int
+}
+
+// pairBlock keys a pair's TWAP by the block it ends at.
+type pairBlock struct {
+	pair  common.Address
+	block uint64
+}
+
+// PairDiff lists tracked pairs added and removed by UpdatePairs.
+type PairDiff struct {
+	Added   []common.Address
+	Removed []common.Address
 }
 
 var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-func NewListener(ctx context.Context, rpcURL string, pairAddress common.Address) (*Listener, error) {
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		return nil, &apperr.ConnectionError{Message: "rpc dial failed", Cause: err}
-	}
+// priceBucket is how much chain time one cached USD price covers.
+const priceBucket = time.Minute
 
-	pairMetadata, err := fetchPairMetadata(ctx, client, pairAddress, UniswapV2PairABI)
-	if err != nil {
-		client.Close()
-		return nil, err
+// Cached prices and pair TWAPs expire after cacheTTL; a janitor drops them every
+// cacheJanitorInterval, and each cache keeps at most cacheMaxEntries.
+const (
+	cacheTTL             = 5 * time.Minute
+	cacheJanitorInterval = time.Minute
+	cacheMaxEntries      = 10_000
+)
+
+// defaultPricePrecision is the significant digits of a swap's priceExact until
+// SetPricePrecision is called.
+const defaultPricePrecision = 30
+
+// twapFailureBackoff is how long a pair's swaps skip the TWAP comparison after a read of its
+// TWAP failed, so a node without archive state is not asked again for every swap.
+const twapFailureBackoff = 10 * time.Minute
+
+// priceSoftTTL is how old a cached chain.Latest price gets before it is refreshed in the
+// background; until cacheTTL it is still served. Swaps at the tip avoid waiting on the oracle
+// through the price cache's next-bucket fallback instead (see oracle.PriceCache).
+const priceSoftTTL = time.Minute
+
+// errResubscribe ends one subscription so Listen can open another with the new address set.
+var errResubscribe = errors.New("resubscribe requested")
+
+// errStopped ends the subscription for good once Stop was called and received logs are handled.
+var errStopped = errors.New("listener stopped")
+
+// NewListener fetches metadata for every pair and takes ownership of client: it is closed
+// on failure or by Close. Swaps are compared with their pair's TWAP over twapBlocks blocks;
+// zero disables the comparison.
+func NewListener(
+	ctx context.Context,
+	client EthClient,
+	chainID uint64,
+	pairAddresses []common.Address,
+	tokens *token.Service,
+	registry *oracle.Registry,
+	priceOracle oracle.PriceOracle,
+	priceCache *oracle.PriceCache,
+	twapBlocks uint64,
+) (*Listener, error) {
+	pairs := make([]PairMetadata, 0, len(pairAddresses))
+	for _, pairAddress := range pairAddresses {
+		pairMetadata, err := fetchPairMetadata(ctx, client, tokens, pairAddress)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		pairs = append(pairs, pairMetadata)
 	}
 
-	return NewListenerWith(client, pairAddress, pairMetadata, oracle.NewChainlinkOracle(client)), nil
+	return NewListenerWith(client, chainID, pairs, tokens, registry, priceOracle, priceCache, twapBlocks), nil
+}
+
+// NewPriceCache builds the USD price cache for chainID, persisted to storage when it is not nil.
+func NewPriceCache(chainID uint64, storage cache.Storage) (*oracle.PriceCache, error) {
+	return oracle.NewPriceCacheWith(cacheTTL, chain.ProfileFor(chainID).BlocksPer(priceBucket), cache.Options{
+		MaxEntries: cacheMaxEntries,
+		SoftTTL:    priceSoftTTL,
+		Storage:    storage,
+		Name:       "prices",
+	})
 }
 
 // NewListenerWith accepts pre-built dependencies for testability.
-func NewListenerWith(client EthClient, pairAddress common.Address, pairMetadata PairMetadata, priceOracle oracle.PriceOracle) *Listener {
+func NewListenerWith(client EthClient, chainID uint64, pairs []PairMetadata, tokens *token.Service, registry *oracle.Registry, priceOracle oracle.PriceOracle, priceCache *oracle.PriceCache, twapBlocks uint64) *Listener {
+	pairMap := make(map[common.Address]PairMetadata, len(pairs))
+	for _, pairMetadata := range pairs {
+		pairMap[pairMetadata.PairAddress] = pairMetadata
+	}
+	// Without Storage the cache cannot fail to build.
+	twapCache, _ := cache.NewCacheWith[pairBlock, float64](cacheTTL, cache.Options{MaxEntries: cacheMaxEntries, Name: "pairTwaps"})
+	twapBackoff, _ := cache.NewCacheWith[common.Address, bool](twapFailureBackoff, cache.Options{MaxEntries: cacheMaxEntries, Name: "pairTwapFailures"})
+	janitorCtx, stopCaches := context.WithCancel(context.Background())
+	priceCache.StartJanitor(janitorCtx, cacheJanitorInterval)
+	twapCache.StartJanitor(janitorCtx, cacheJanitorInterval)
+	twapBackoff.StartJanitor(janitorCtx, cacheJanitorInterval)
 	return &Listener{
-		pairAddress:  pairAddress,
-		client:       client,
-		pairMetadata: pairMetadata,
-		symbolCache:  cache.NewCache[common.Address, string](0),
-		priceCache:   cache.NewCache[common.Address, float64](5 * time.Minute),
-		priceOracle:  priceOracle,
+		client:      client,
+		chainID:     chainID,
+		pairs:       pairMap,
+		resubscribe: make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		registry:    registry,
+		tokens:      tokens,
+		priceCache:  priceCache,
+		priceOracle: priceOracle,
+		twapBlocks:  twapBlocks,
+		twapCache:   twapCache,
+		twapBackoff: twapBackoff,
+		stopCaches:  stopCaches,
+
+		pricePrecision: defaultPricePrecision,
+	}
+}
+
+// ValidateFeeds checks registry's Chainlink feeds against the chain this listener is connected to.
+func (l *Listener) ValidateFeeds(ctx context.Context, registry *oracle.Registry) error {
+	return oracle.ValidateFeeds(ctx, l.client, registry)
+}
+
+// Pairs returns the metadata of every tracked pair, ordered by address.
+func (l *Listener) Pairs() []PairMetadata {
+	l.pairsMu.RLock()
+	defer l.pairsMu.RUnlock()
+
+	pairs := make([]PairMetadata, 0, len(l.pairs))
+	for _, pairMetadata := range l.pairs {
+		pairs = append(pairs, pairMetadata)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].PairAddress.Cmp(pairs[j].PairAddress) < 0 })
+	return pairs
+}
+
+// UpdatePairs replaces the tracked pair set. Metadata is fetched for new pairs before anything
+// changes, so a failed lookup leaves the current set untouched. The log subscription is only
+// reopened when the address set actually differs.
+func (l *Listener) UpdatePairs(ctx context.Context, pairAddresses []common.Address) (PairDiff, error) {
+	l.pairsMu.RLock()
+	current := make(map[common.Address]PairMetadata, len(l.pairs))
+	for address, pairMetadata := range l.pairs {
+		current[address] = pairMetadata
+	}
+	l.pairsMu.RUnlock()
+
+	next := make(map[common.Address]PairMetadata, len(pairAddresses))
+	var diff PairDiff
+	for _, pairAddress := range pairAddresses {
+		if pairMetadata, exists := current[pairAddress]; exists {
+			next[pairAddress] = pairMetadata
+			continue
+		}
+		pairMetadata, err := fetchPairMetadata(ctx, l.client, l.tokens, pairAddress)
+		if err != nil {
+			return PairDiff{}, err
+		}
+		next[pairAddress] = pairMetadata
+		diff.Added = append(diff.Added, pairAddress)
+	}
+	for pairAddress := range current {
+		if _, exists := next[pairAddress]; !exists {
+			diff.Removed = append(diff.Removed, pairAddress)
+		}
+	}
+
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		return diff, nil
+	}
+
+	l.pairsMu.Lock()
+	l.pairs = next
+	l.pairsMu.Unlock()
+
+	select {
+	case l.resubscribe <- struct{}{}:
+	default:
 	}
+	return diff, nil
+}
+
+// InvalidatePrices drops cached USD prices so the next lookup goes back to the oracle.
+func (l *Listener) InvalidatePrices(tokenAddresses []common.Address) {
+	l.priceCache.Invalidate(tokenAddresses)
 }
 
-func (l *Listener) PairMetadata() PairMetadata {
-	return l.pairMetadata
+// ResumeFrom makes the next Listen start at block (inclusive) instead of a fixed backfill
+// behind the chain head, so blocks missed while the ingester was down are read. Call it
+// before Listen.
+func (l *Listener) ResumeFrom(block uint64) {
+	l.resumeFrom = block
+}
+
+// SetPricePrecision sets how many significant digits a swap's priceExact keeps. Call it
+// before Listen.
+func (l *Listener) SetPricePrecision(digits int) {
+	l.pricePrecision = digits
+}
+
+// Stop ends the subscription. Logs already received are still enriched and sent, then Listen
+// returns nil. Safe to call more than once and before Listen.
+func (l *Listener) Stop() {
+	l.stopOnce.Do(func() { close(l.stop) })
 }
 
 func (l *Listener) Listen(ctx context.Context, outputChannel chan<- events.Event) error {
@@ -77,9 +262,36 @@ func (l *Listener) Listen(ctx context.Context, outputChannel chan<- events.Event
 	if latestBlock > backfillBlocks {
 		fromBlock = latestBlock - backfillBlocks
 	}
+	if l.resumeFrom > 0 && l.resumeFrom <= latestBlock {
+		fromBlock = l.resumeFrom
+	}
+
+	for {
+		err := l.listenOnce(ctx, fromBlock, outputChannel)
+		if errors.Is(err, errStopped) {
+			logger.Info("Listener stopped", "lastBlock", l.lastBlock.Load())
+			return nil
+		}
+		if !errors.Is(err, errResubscribe) {
+			return err
+		}
+		// Resume from the last block seen (inclusive) so logs in a partially delivered block are not lost.
+		if lastBlock := l.lastBlock.Load(); lastBlock > fromBlock {
+			fromBlock = lastBlock
+		}
+		logger.Info("Resubscribing to pair logs", "pairs", len(l.Pairs()), "fromBlock", fromBlock)
+	}
+}
+
+func (l *Listener) listenOnce(ctx context.Context, fromBlock uint64, outputChannel chan<- events.Event) error {
+	pairs := l.Pairs()
+	addresses := make([]common.Address, 0, len(pairs))
+	for _, pairMetadata := range pairs {
+		addresses = append(addresses, pairMetadata.PairAddress)
+	}
 
 	filterQuery := ethereum.FilterQuery{
-		Addresses: []common.Address{l.pairAddress},
+		Addresses: addresses,
 		Topics:    [][]common.Hash{{SwapEventTopic, MintEventTopic, BurnEventTopic, TransferEventTopic}},
 		FromBlock: new(big.Int).SetUint64(fromBlock),
 	}
@@ -89,33 +301,65 @@ func (l *Listener) Listen(ctx context.Context, outputChannel chan<- events.Event
 	if err != nil {
 		return &apperr.ConnectionError{Message: "event subscription failed", Cause: err}
 	}
+	defer subscription.Unsubscribe()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-l.resubscribe:
+			return errResubscribe
+		case <-l.stop:
+			subscription.Unsubscribe()
+			return l.drainLogs(ctx, logChannel, outputChannel)
 		case subscriptionErr := <-subscription.Err():
 			return &apperr.ConnectionError{Message: "event stream failed", Cause: subscriptionErr}
 		case logEntry := <-logChannel:
-			event, err := l.eventFromLog(ctx, logEntry)
-			if err != nil {
-				var dataErr *apperr.DataError
-				if errors.As(err, &dataErr) {
-					logger.Warn("Skipping bad event data", "error", err)
-					continue
-				}
+			if err := l.handleLog(ctx, logEntry, outputChannel); err != nil {
 				return err
 			}
-			select {
-			case outputChannel <- event:
-			case <-ctx.Done():
-				return ctx.Err()
+		}
+	}
+}
+
+// drainLogs handles the logs left in logChannel after unsubscribing, then returns errStopped.
+func (l *Listener) drainLogs(ctx context.Context, logChannel <-chan types.Log, outputChannel chan<- events.Event) error {
+	for {
+		select {
+		case logEntry := <-logChannel:
+			if err := l.handleLog(ctx, logEntry, outputChannel); err != nil {
+				return err
 			}
+		default:
+			return errStopped
 		}
 	}
 }
 
+// handleLog enriches one log and sends the event. Bad log data is skipped.
+func (l *Listener) handleLog(ctx context.Context, logEntry types.Log, outputChannel chan<- events.Event) error {
+	if logEntry.BlockNumber > l.lastBlock.Load() {
+		l.lastBlock.Store(logEntry.BlockNumber)
+	}
+	event, err := l.eventFromLog(ctx, logEntry)
+	if err != nil {
+		var dataErr *apperr.DataError
+		if errors.As(err, &dataErr) {
+			logger.Warn("Skipping bad event data", "error", err)
+			return nil
+		}
+		return err
+	}
+	select {
+	case outputChannel <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (l *Listener) Close() {
+	l.stopCaches()
 	l.client.Close()
 }
 
@@ -126,15 +370,24 @@ func (l *Listener) eventFromLog(ctx context.Context, logEntry types.Log) (events
 		}
 	}
 
+	l.pairsMu.RLock()
+	pairMetadata, tracked := l.pairs[logEntry.Address]
+	l.pairsMu.RUnlock()
+	if !tracked {
+		return nil, &apperr.DataError{
+			Message: fmt.Sprintf("log from untracked pair %s at block=%d tx=%s", logEntry.Address.Hex(), logEntry.BlockNumber, logEntry.TxHash.Hex()),
+		}
+	}
+
 	switch logEntry.Topics[0] {
 	case SwapEventTopic:
-		return l.parseSwapEvent(ctx, logEntry)
+		return l.parseSwapEvent(ctx, logEntry, pairMetadata)
 	case MintEventTopic:
-		return l.parseMintEvent(ctx, logEntry)
+		return l.parseMintEvent(ctx, logEntry, pairMetadata)
 	case BurnEventTopic:
-		return l.parseBurnEvent(ctx, logEntry)
+		return l.parseBurnEvent(ctx, logEntry, pairMetadata)
 	case TransferEventTopic:
-		return l.parseTransferEvent(ctx, logEntry)
+		return l.parseTransferEvent(ctx, logEntry, pairMetadata)
 	default:
 		return nil, &apperr.DataError{
 			Message: fmt.Sprintf("unknown topic at block=%d tx=%s: %s", logEntry.BlockNumber, logEntry.TxHash.Hex(), logEntry.Topics[0].Hex()),
@@ -142,7 +395,7 @@ func (l *Listener) eventFromLog(ctx context.Context, logEntry types.Log) (events
 	}
 }
 
-func (l *Listener) parseSwapEvent(ctx context.Context, logEntry types.Log) (events.SwapEvent, error) {
+func (l *Listener) parseSwapEvent(ctx context.Context, logEntry types.Log, pairMetadata PairMetadata) (events.SwapEvent, error) {
 	sender, recipient, amount0In, amount1In, amount0Out, amount1Out, err := parseSwapLog(logEntry)
 	if err != nil {
 		return events.SwapEvent{}, &apperr.DataError{
@@ -151,33 +404,115 @@ func (l *Listener) parseSwapEvent(ctx context.Context, logEntry types.Log) (even
 		}
 	}
 
-	gasUsed, gasPrice, err := fetchGasDetails(ctx, l.client, logEntry.TxHash)
-	if err != nil {
+	// A node that no longer serves the transaction or its receipt (pruned history) does not
+	// hold the swap back: the fields derived from them are left nil.
+	receipt, err := fetchReceipt(ctx, l.client, logEntry.TxHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
 		return events.SwapEvent{}, err
 	}
+	tx, err := fetchTransaction(ctx, l.client, logEntry.TxHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return events.SwapEvent{}, err
+	}
+	if receipt == nil || tx == nil {
+		logger.Warn("Transaction or receipt not found, publishing swap without transaction details",
+			"tx", logEntry.TxHash.Hex(), "receiptFound", receipt != nil, "transactionFound", tx != nil)
+	}
+	gasUsed, gasPrice := gasDetails(receipt)
+	route := routeOf(receipt, tx, logEntry.Index, chain.ProfileFor(l.chainID))
+	origin := originOf(ctx, l.client, tx, receipt, l.chainID)
 
-	base, err := l.buildBase(ctx, logEntry, events.EventTypeSwap)
+	base, header, err := l.buildBase(ctx, logEntry, pairMetadata, events.EventTypeSwap)
 	if err != nil {
 		return events.SwapEvent{}, err
 	}
-	price := priceFromSwapAmounts(amount0In, amount1In, amount0Out, amount1Out, l.pairMetadata)
+	fees := feesOf(receipt, tx, header)
+	exactPrice := swapPrice(amount0In, amount1In, amount0Out, amount1Out, pairMetadata)
+	price := ratFloat64(exactPrice)
+	valuation := valueSwap(ctx, l.registry, l.priceCache, l.priceOracle, priceBlock(logEntry, base), amount0In, amount1In, amount0Out, amount1Out, pairMetadata)
+	twapPrice, twapDeviation := l.compareWithTWAP(ctx, logEntry.BlockNumber, price, pairMetadata)
+	direction, net0, net1 := swapDirection(amount0In, amount1In, amount0Out, amount1Out)
 
 	return events.SwapEvent{
-		BaseEvent:  base,
-		Sender:     sender.Hex(),
-		Recipient:  recipient.Hex(),
-		Amount0In:  amount0In.String(),
-		Amount1In:  amount1In.String(),
-		Amount0Out: amount0Out.String(),
-		Amount1Out: amount1Out.String(),
-		Price:      price,
-		VolumeUSD:  volumeUSDFromSwap(ctx, l.priceCache, l.priceOracle, amount0In, amount1In, amount0Out, amount1Out, l.pairMetadata, price),
-		GasUsed:    gasUsed,
-		GasPrice:   gasPrice,
+		BaseEvent:      base,
+		Sender:         sender.Hex(),
+		Recipient:      recipient.Hex(),
+		Amount0In:      amount0In.String(),
+		Amount1In:      amount1In.String(),
+		Amount0Out:     amount0Out.String(),
+		Amount1Out:     amount1Out.String(),
+		Price:          price,
+		PriceExact:     significantDecimal(exactPrice, l.pricePrecision),
+		PriceDecimal:   scaledDecimal(exactPrice),
+		VolumeUSD:      valuation.volumeUSD,
+		GasUsed:        gasUsed,
+		GasPrice:       gasPrice,
+		PriceSource:    string(valuation.quote.Source),
+		PriceRoundID:   bigString(valuation.quote.RoundID),
+		PriceUpdatedAt: unixOrNil(valuation.quote.UpdatedAt),
+		Token0PriceUSD: valuation.token0PriceUSD,
+		Token1PriceUSD: valuation.token1PriceUSD,
+		TWAPPrice:      twapPrice,
+		TWAPDeviation:  twapDeviation,
+		Direction:      direction,
+		ExecutionPrice: executionPrice(direction, net0, net1, pairMetadata),
+		HopIndex:       route.hopIndex,
+		HopCount:       route.hopCount,
+		Router:         route.router,
+		ViaAggregator:  route.viaAggregator,
+		TxFrom:         origin.from,
+		TxTo:           origin.to,
+		Nonce:          origin.nonce,
+		MethodSelector: origin.methodSelector,
+		TxIndex:        txIndexOf(receipt),
+		TxType:         fees.txType,
+		BaseFee:        bigString(fees.baseFee),
+		PriorityFee:    bigString(fees.priorityFee),
+		TxFee:          bigString(fees.fee),
+		TxFeeUSD:       l.feeUSD(ctx, fees.fee, priceBlock(logEntry, base)),
+
+		Amount0InAdjusted:  adjustedAmount(amount0In, pairMetadata.Token0Decimals),
+		Amount1InAdjusted:  adjustedAmount(amount1In, pairMetadata.Token1Decimals),
+		Amount0OutAdjusted: adjustedAmount(amount0Out, pairMetadata.Token0Decimals),
+		Amount1OutAdjusted: adjustedAmount(amount1Out, pairMetadata.Token1Decimals),
 	}, nil
 }
 
-func (l *Listener) parseMintEvent(ctx context.Context, logEntry types.Log) (events.MintEvent, error) {
+// compareWithTWAP returns the pair's TWAP over the window ending the block before the swap,
+// so the swap's own block cannot move it, and how far price sits from it. Both are nil when
+// the comparison is disabled or the TWAP cannot be read (no archive state, a pair younger
+// than the window). After a failed read the pair is skipped for twapFailureBackoff; the first
+// failure is logged at Warn, later ones at Debug.
+func (l *Listener) compareWithTWAP(ctx context.Context, blockNumber uint64, price float64, pairMetadata PairMetadata) (*float64, *float64) {
+	if l.twapBlocks == 0 || blockNumber <= 1 {
+		return nil, nil
+	}
+	if _, _, backingOff := l.twapBackoff.Get(pairMetadata.PairAddress); backingOff {
+		return nil, nil
+	}
+	key := pairBlock{pair: pairMetadata.PairAddress, block: blockNumber - 1}
+	twapPrice, found := l.twapCache.GetOrFetch(ctx, key, func(ctx context.Context, key pairBlock) (float64, bool) {
+		twap, err := oracle.PairTWAP(ctx, l.client, l.client, key.pair, chain.Block{Number: key.block}, l.twapBlocks)
+		if err != nil {
+			l.twapBackoff.Set(key.pair, true, true)
+			attributes := []any{"pair", key.pair.Hex(), "block", key.block, "backoff", twapFailureBackoff, "error", err}
+			if l.twapWarned.CompareAndSwap(false, true) {
+				logger.Warn("Pair TWAP unavailable, skipping the comparison for a while; it needs archive state (TWAP_WINDOW=0 disables it)", attributes...)
+			} else {
+				logger.Debug("Pair TWAP unavailable", attributes...)
+			}
+			return 0, false
+		}
+		return twap.DecimalAdjusted(pairMetadata.Token0Decimals, pairMetadata.Token1Decimals), true
+	})
+	if !found || twapPrice <= 0 {
+		return nil, nil
+	}
+	deviation := (price - twapPrice) / twapPrice
+	return &twapPrice, &deviation
+}
+
+func (l *Listener) parseMintEvent(ctx context.Context, logEntry types.Log, pairMetadata PairMetadata) (events.MintEvent, error) {
 	sender, amount0, amount1, err := parseMintLog(logEntry)
 	if err != nil {
 		return events.MintEvent{}, &apperr.DataError{
@@ -186,20 +521,36 @@ func (l *Listener) parseMintEvent(ctx context.Context, logEntry types.Log) (even
 		}
 	}
 
-	base, err := l.buildBase(ctx, logEntry, events.EventTypeMint)
+	receipt, err := fetchReceipt(ctx, l.client, logEntry.TxHash)
 	if err != nil {
 		return events.MintEvent{}, err
 	}
+	lpTokens, provider, share := l.lpPosition(ctx, receipt, logEntry, false)
+
+	base, _, err := l.buildBase(ctx, logEntry, pairMetadata, events.EventTypeMint)
+	if err != nil {
+		return events.MintEvent{}, err
+	}
+	valuation := valueLiquidity(ctx, l.registry, l.priceCache, l.priceOracle, priceBlock(logEntry, base), amount0, amount1, pairMetadata)
 
 	return events.MintEvent{
-		BaseEvent: base,
-		Sender:    sender.Hex(),
-		Amount0:   amount0.String(),
-		Amount1:   amount1.String(),
+		BaseEvent:     base,
+		Sender:        sender.Hex(),
+		Amount0:       amount0.String(),
+		Amount1:       amount1.String(),
+		LPTokens:      lpTokens,
+		Provider:      provider,
+		ShareOfSupply: share,
+		Amount0USD:    valuation.amount0USD,
+		Amount1USD:    valuation.amount1USD,
+		TotalUSD:      valuation.totalUSD,
+
+		Amount0Adjusted: adjustedAmount(amount0, pairMetadata.Token0Decimals),
+		Amount1Adjusted: adjustedAmount(amount1, pairMetadata.Token1Decimals),
 	}, nil
 }
 
-func (l *Listener) parseBurnEvent(ctx context.Context, logEntry types.Log) (events.BurnEvent, error) {
+func (l *Listener) parseBurnEvent(ctx context.Context, logEntry types.Log, pairMetadata PairMetadata) (events.BurnEvent, error) {
 	sender, recipient, amount0, amount1, err := parseBurnLog(logEntry)
 	if err != nil {
 		return events.BurnEvent{}, &apperr.DataError{
@@ -208,21 +559,37 @@ func (l *Listener) parseBurnEvent(ctx context.Context, logEntry types.Log) (even
 		}
 	}
 
-	base, err := l.buildBase(ctx, logEntry, events.EventTypeBurn)
+	receipt, err := fetchReceipt(ctx, l.client, logEntry.TxHash)
+	if err != nil {
+		return events.BurnEvent{}, err
+	}
+	lpTokens, provider, share := l.lpPosition(ctx, receipt, logEntry, true)
+
+	base, _, err := l.buildBase(ctx, logEntry, pairMetadata, events.EventTypeBurn)
 	if err != nil {
 		return events.BurnEvent{}, err
 	}
+	valuation := valueLiquidity(ctx, l.registry, l.priceCache, l.priceOracle, priceBlock(logEntry, base), amount0, amount1, pairMetadata)
 
 	return events.BurnEvent{
-		BaseEvent: base,
-		Sender:    sender.Hex(),
-		Recipient: recipient.Hex(),
-		Amount0:   amount0.String(),
-		Amount1:   amount1.String(),
+		BaseEvent:     base,
+		Sender:        sender.Hex(),
+		Recipient:     recipient.Hex(),
+		Amount0:       amount0.String(),
+		Amount1:       amount1.String(),
+		LPTokens:      lpTokens,
+		Provider:      provider,
+		ShareOfSupply: share,
+		Amount0USD:    valuation.amount0USD,
+		Amount1USD:    valuation.amount1USD,
+		TotalUSD:      valuation.totalUSD,
+
+		Amount0Adjusted: adjustedAmount(amount0, pairMetadata.Token0Decimals),
+		Amount1Adjusted: adjustedAmount(amount1, pairMetadata.Token1Decimals),
 	}, nil
 }
 
-func (l *Listener) parseTransferEvent(ctx context.Context, logEntry types.Log) (events.TransferEvent, error) {
+func (l *Listener) parseTransferEvent(ctx context.Context, logEntry types.Log, pairMetadata PairMetadata) (events.TransferEvent, error) {
 	from, to, value, err := parseTransferLog(logEntry)
 	if err != nil {
 		return events.TransferEvent{}, &apperr.DataError{
@@ -231,7 +598,7 @@ func (l *Listener) parseTransferEvent(ctx context.Context, logEntry types.Log) (
 		}
 	}
 
-	base, err := l.buildBase(ctx, logEntry, events.EventTypeTransfer)
+	base, _, err := l.buildBase(ctx, logEntry, pairMetadata, events.EventTypeTransfer)
 	if err != nil {
 		return events.TransferEvent{}, err
 	}
@@ -241,25 +608,21 @@ func (l *Listener) parseTransferEvent(ctx context.Context, logEntry types.Log) (
 		From:      from.Hex(),
 		To:        to.Hex(),
 		Value:     value.String(),
+
+		ValueAdjusted: adjustedAmount(value, lpDecimals),
 	}, nil
 }
 
-func (l *Listener) buildBase(ctx context.Context, logEntry types.Log, eventType events.EventType) (events.BaseEvent, error) {
-	blockTimestamp, err := fetchBlockTimestamp(ctx, l.client, logEntry.BlockNumber)
+// buildBase also returns the log's block header, for swaps to read the base fee from.
+func (l *Listener) buildBase(ctx context.Context, logEntry types.Log, pairMetadata PairMetadata, eventType events.EventType) (events.BaseEvent, *types.Header, error) {
+	header, err := fetchBlockHeader(ctx, l.client, logEntry.BlockNumber)
 	if err != nil {
-		return events.BaseEvent{}, err
+		return events.BaseEvent{}, nil, err
 	}
 
-	token0 := l.fetchTokenSymbol(ctx, l.pairMetadata.Token0Address)
-	token1 := l.fetchTokenSymbol(ctx, l.pairMetadata.Token1Address)
-
-	var token0Ptr, token1Ptr *string
-	if token0 != "" {
-		token0Ptr = &token0
-	}
-	if token1 != "" {
-		token1Ptr = &token1
-	}
+	token0 := l.tokenMetadata(ctx, pairMetadata.Token0Address)
+	token1 := l.tokenMetadata(ctx, pairMetadata.Token1Address)
+	token0Decimals, token1Decimals := int32(pairMetadata.Token0Decimals), int32(pairMetadata.Token1Decimals)
 
 	var eventIDBuilder strings.Builder
 	eventIDBuilder.Grow(66 + 1 + 10)
@@ -268,60 +631,96 @@ func (l *Listener) buildBase(ctx context.Context, logEntry types.Log, eventType
 	eventIDBuilder.WriteString(strconv.FormatUint(uint64(logEntry.Index), 10))
 
 	return events.BaseEvent{
+		ChainID:         int64(l.chainID),
 		EventType:       eventType,
 		EventID:         eventIDBuilder.String(),
 		BlockNumber:     int64(logEntry.BlockNumber),
-		BlockTimestamp:  blockTimestamp,
+		BlockTimestamp:  int64(header.Time),
 		TransactionHash: logEntry.TxHash.Hex(),
 		LogIndex:        int32(logEntry.Index),
-		PairAddress:     l.pairMetadata.PairAddress.Hex(),
-		Token0:          l.pairMetadata.Token0Address.Hex(),
-		Token1:          l.pairMetadata.Token1Address.Hex(),
-		Token0Symbol:    token0Ptr,
-		Token1Symbol:    token1Ptr,
+		PairAddress:     pairMetadata.PairAddress.Hex(),
+		Token0:          pairMetadata.Token0Address.Hex(),
+		Token1:          pairMetadata.Token1Address.Hex(),
+		Token0Symbol:    nonEmpty(token0.Symbol),
+		Token1Symbol:    nonEmpty(token1.Symbol),
 		EventTimestamp:  time.Now().Unix(),
-	}, nil
+		Token0Name:      nonEmpty(token0.Name),
+		Token1Name:      nonEmpty(token1.Name),
+		Token0Decimals:  &token0Decimals,
+		Token1Decimals:  &token1Decimals,
+	}, header, nil
 }
 
-func priceFromSwapAmounts(amount0In, amount1In, amount0Out, amount1Out *big.Int, pairMetadata PairMetadata) float64 {
-	if amount0In.Sign() > 0 && amount1Out.Sign() > 0 {
-		return ratioToFloat64(amount1Out, amount0In, pairMetadata.Token1Decimals, pairMetadata.Token0Decimals)
-	}
-	if amount1In.Sign() > 0 && amount0Out.Sign() > 0 {
-		return ratioToFloat64(amount1In, amount0Out, pairMetadata.Token1Decimals, pairMetadata.Token0Decimals)
-	}
-	return 0
+// swapValuation is the USD side of a swap: the volume, the quote it was computed from and every
+// per-token price that was available. quote.Source is NONE when the volume is unknown.
+type swapValuation struct {
+	volumeUSD      *float64
+	quote          oracle.Quote
+	token0PriceUSD *float64
+	token1PriceUSD *float64
 }
 
-// volumeUSDFromSwap resolves via: stablecoin token1 -> stablecoin token0 -> oracle token0 -> oracle token1 -> swap price.
-func volumeUSDFromSwap(
+// valueSwap resolves via: stablecoin token1 -> stablecoin token0 -> oracle token0 -> oracle token1.
+// It leaves the volume nil rather than guess when neither token has a USD price: the raw swap price
+// is only denominated in USD when one side is a stablecoin, which the first two cases already cover.
+func valueSwap(
 	ctx context.Context,
-	priceCache *cache.Cache[common.Address, float64],
+	registry *oracle.Registry,
+	priceCache *oracle.PriceCache,
 	priceOracle oracle.PriceOracle,
+	block chain.Block,
 	amount0In, amount1In, amount0Out, amount1Out *big.Int,
 	pairMetadata PairMetadata,
-	price float64,
-) *float64 {
-	token1Price, foundToken1 := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token1Address, priceCache, priceOracle)
-	token0Price, foundToken0 := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token0Address, priceCache, priceOracle)
+) swapValuation {
+	token1Quote, foundToken1 := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token1Address, block, registry, priceCache, priceOracle)
+	token0Quote, foundToken0 := oracle.GetTokenUSDPrice(ctx, pairMetadata.Token0Address, block, registry, priceCache, priceOracle)
+
+	valuation := swapValuation{quote: oracle.Quote{Source: oracle.PriceSourceNone}}
+	if foundToken0 && token0Quote.Price > 0 {
+		valuation.token0PriceUSD = &token0Quote.Price
+	}
+	if foundToken1 && token1Quote.Price > 0 {
+		valuation.token1PriceUSD = &token1Quote.Price
+	}
 
 	vol0 := nonZeroAmount(amount0In, amount0Out)
 	vol1 := nonZeroAmount(amount1In, amount1Out)
 
 	switch {
-	case foundToken1 && token1Price == 1.0:
-		return toUSD(vol1, pairMetadata.Token1Decimals, 1.0)
-	case foundToken0 && token0Price == 1.0:
-		return toUSD(vol0, pairMetadata.Token0Decimals, 1.0)
-	case foundToken0 && token0Price > 0:
-		return toUSD(vol0, pairMetadata.Token0Decimals, token0Price)
-	case foundToken1 && token1Price > 0:
-		return toUSD(vol1, pairMetadata.Token1Decimals, token1Price)
-	case price > 0:
-		return toUSD(vol0, pairMetadata.Token0Decimals, price)
-	default:
+	case foundToken1 && token1Quote.Source == oracle.PriceSourceStablecoin:
+		valuation.volumeUSD, valuation.quote = toUSD(vol1, pairMetadata.Token1Decimals, 1.0), token1Quote
+	case foundToken0 && token0Quote.Source == oracle.PriceSourceStablecoin:
+		valuation.volumeUSD, valuation.quote = toUSD(vol0, pairMetadata.Token0Decimals, 1.0), token0Quote
+	case valuation.token0PriceUSD != nil:
+		valuation.volumeUSD, valuation.quote = toUSD(vol0, pairMetadata.Token0Decimals, token0Quote.Price), token0Quote
+	case valuation.token1PriceUSD != nil:
+		valuation.volumeUSD, valuation.quote = toUSD(vol1, pairMetadata.Token1Decimals, token1Quote.Price), token1Quote
+	}
+	return valuation
+}
+
+// priceBlock pins price lookups to the swap's block, so backfilled and finality-delayed swaps
+// are valued at the price of their time rather than the latest one.
+func priceBlock(logEntry types.Log, base events.BaseEvent) chain.Block {
+	return chain.Block{Number: logEntry.BlockNumber, Time: time.Unix(base.BlockTimestamp, 0)}
+}
+
+// bigString keeps integers that may not fit in a long, such as uint80 Chainlink round IDs and
+// wei amounts, as decimal strings.
+func bigString(value *big.Int) *string {
+	if value == nil {
+		return nil
+	}
+	s := value.String()
+	return &s
+}
+
+func unixOrNil(t time.Time) *int64 {
+	if t.IsZero() {
 		return nil
 	}
+	seconds := t.Unix()
+	return &seconds
 }
 
 func nonZeroAmount(amountIn, amountOut *big.Int) *big.Int {
@@ -336,85 +735,96 @@ func toUSD(amount *big.Int, decimals uint8, usdPrice float64) *float64 {
 	return &v
 }
 
-func adjustForDecimals(amount *big.Int, decimals uint8) float64 {
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	ratio := new(big.Rat).SetFrac(amount, divisor)
-	value, _ := ratio.Float64()
-	return value
-}
-
-func ratioToFloat64(numerator, denominator *big.Int, numeratorDecimals, denominatorDecimals uint8) float64 {
-	if denominator.Sign() == 0 {
-		return 0
+// adjustedAmount is amount in whole tokens as a decimal string with events.AmountScale
+// fractional digits, truncating any beyond that for tokens with more decimals. It is nil when
+// the amount needs more than events.AmountPrecision digits.
+func adjustedAmount(amount *big.Int, decimals uint8) *string {
+	scaled := new(big.Int).Set(amount)
+	if shift := events.AmountScale - int(decimals); shift >= 0 {
+		scaled.Mul(scaled, pow10(shift))
+	} else {
+		scaled.Quo(scaled, pow10(-shift))
+	}
+	if len(new(big.Int).Abs(scaled).String()) > events.AmountPrecision {
+		logger.Debug("Amount exceeds decimal precision", "amount", amount.String(), "decimals", decimals)
+		return nil
 	}
+	adjusted := new(big.Rat).SetFrac(scaled, pow10(events.AmountScale)).FloatString(events.AmountScale)
+	return &adjusted
+}
 
-	// price = (numerator / 10^numDec) / (denominator / 10^denomDec)
-	//       = numerator * 10^(denomDec - numDec) / denominator
-	decimalDiff := int(denominatorDecimals) - int(numeratorDecimals)
-
-	adjustedNumerator := new(big.Int).Set(numerator)
-	if decimalDiff > 0 {
-		multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimalDiff)), nil)
-		adjustedNumerator.Mul(adjustedNumerator, multiplier)
-	} else if decimalDiff < 0 {
-		multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-decimalDiff)), nil)
-		denominator = new(big.Int).Mul(denominator, multiplier)
-	}
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
 
-	ratio := new(big.Rat).SetFrac(adjustedNumerator, denominator)
+func adjustForDecimals(amount *big.Int, decimals uint8) float64 {
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	ratio := new(big.Rat).SetFrac(amount, divisor)
 	value, _ := ratio.Float64()
 	return value
 }
 
-func fetchBlockTimestamp(ctx context.Context, client EthClient, blockNumber uint64) (int64, error) {
+func fetchBlockHeader(ctx context.Context, client EthClient, blockNumber uint64) (*types.Header, error) {
 	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
 	if err != nil {
-		return 0, &apperr.ConnectionError{Message: "rpc block header fetch failed", Cause: err}
+		return nil, &apperr.ConnectionError{Message: "rpc block header fetch failed", Cause: err}
 	}
-	return int64(header.Time), nil
+	return header, nil
 }
 
-func fetchGasDetails(ctx context.Context, client EthClient, txHash common.Hash) (int64, string, error) {
+func fetchReceipt(ctx context.Context, client EthClient, txHash common.Hash) (*types.Receipt, error) {
 	receipt, err := client.TransactionReceipt(ctx, txHash)
 	if err != nil {
-		return 0, "", &apperr.ConnectionError{Message: "rpc transaction receipt fetch failed", Cause: err}
+		return nil, &apperr.ConnectionError{Message: "rpc transaction receipt fetch failed", Cause: err}
+	}
+	return receipt, nil
+}
+
+// txIndexOf is the transaction's position in its block; nil when the receipt is unavailable.
+func txIndexOf(receipt *types.Receipt) *int32 {
+	if receipt == nil {
+		return nil
 	}
+	index := int32(receipt.TransactionIndex)
+	return &index
+}
 
+// gasDetails is zero gas at a zero price when the receipt is unavailable.
+func gasDetails(receipt *types.Receipt) (int64, string) {
+	if receipt == nil {
+		return 0, "0"
+	}
 	gasPrice := receipt.EffectiveGasPrice
 	if gasPrice == nil {
 		gasPrice = big.NewInt(0)
 	}
 
-	return int64(receipt.GasUsed), gasPrice.String(), nil
+	return int64(receipt.GasUsed), gasPrice.String()
 }
 
-func fetchPairMetadata(ctx context.Context, client *ethclient.Client, pairAddress common.Address, pairABI abi.ABI) (PairMetadata, error) {
-	pairContract := bind.NewBoundContract(pairAddress, pairABI, client, client, client)
-
-	token0Results := []interface{}{new(common.Address)}
-	if err := pairContract.Call(&bind.CallOpts{Context: ctx}, &token0Results, "token0"); err != nil {
+func fetchPairMetadata(ctx context.Context, client EthClient, tokens *token.Service, pairAddress common.Address) (PairMetadata, error) {
+	var token0Address common.Address
+	if err := contract.CallContract(ctx, client, pairAddress, UniswapV2PairABI, "token0", &token0Address); err != nil {
 		if ctx.Err() != nil {
 			return PairMetadata{}, ctx.Err()
 		}
-		return PairMetadata{}, &apperr.ConnectionError{Message: "rpc pair token0 fetch failed", Cause: err}
+		return PairMetadata{}, &apperr.ConnectionError{Message: fmt.Sprintf("rpc pair token0 fetch failed for %s", pairAddress.Hex()), Cause: err}
 	}
-	token0Address := *token0Results[0].(*common.Address)
 
-	token1Results := []interface{}{new(common.Address)}
-	if err := pairContract.Call(&bind.CallOpts{Context: ctx}, &token1Results, "token1"); err != nil {
+	var token1Address common.Address
+	if err := contract.CallContract(ctx, client, pairAddress, UniswapV2PairABI, "token1", &token1Address); err != nil {
 		if ctx.Err() != nil {
 			return PairMetadata{}, ctx.Err()
 		}
-		return PairMetadata{}, &apperr.ConnectionError{Message: "rpc pair token1 fetch failed", Cause: err}
+		return PairMetadata{}, &apperr.ConnectionError{Message: fmt.Sprintf("rpc pair token1 fetch failed for %s", pairAddress.Hex()), Cause: err}
 	}
-	token1Address := *token1Results[0].(*common.Address)
 
-	token0Decimals, err := fetchTokenDecimals(ctx, client, token0Address)
+	token0, err := tokens.Resolve(ctx, token0Address)
 	if err != nil {
 		return PairMetadata{}, err
 	}
 
-	token1Decimals, err := fetchTokenDecimals(ctx, client, token1Address)
+	token1, err := tokens.Resolve(ctx, token1Address)
 	if err != nil {
 		return PairMetadata{}, err
 	}
@@ -423,49 +833,25 @@ func fetchPairMetadata(ctx context.Context, client *ethclient.Client, pairAddres
 		PairAddress:    pairAddress,
 		Token0Address:  token0Address,
 		Token1Address:  token1Address,
-		Token0Decimals: token0Decimals,
-		Token1Decimals: token1Decimals,
+		Token0Decimals: token0.Decimals,
+		Token1Decimals: token1.Decimals,
 	}, nil
 }
 
-func fetchTokenDecimals(ctx context.Context, client EthClient, tokenAddress common.Address) (uint8, error) {
-	decimalsABI, err := contract.GetABI(contract.ERC20Decimals)
+// tokenMetadata returns the token's symbol and name for event enrichment. They are optional,
+// so a failed lookup yields empty fields rather than an error.
+func (l *Listener) tokenMetadata(ctx context.Context, tokenAddress common.Address) token.Metadata {
+	metadata, err := l.tokens.Resolve(ctx, tokenAddress)
 	if err != nil {
-		return 0, &apperr.ConfigError{Message: "failed to get ERC20 decimals ABI", Cause: err}
-	}
-
-	var decimals uint8
-	if err := contract.CallContract(ctx, client, tokenAddress, decimalsABI, "decimals", &decimals); err != nil {
-		return 0, &apperr.ConnectionError{Message: fmt.Sprintf("rpc token decimals fetch failed for %s", tokenAddress.Hex()), Cause: err}
+		logger.Debug("Token metadata unavailable", "token", tokenAddress.Hex(), "error", err)
+		return token.Metadata{}
 	}
-
-	return decimals, nil
+	return metadata
 }
 
-func (l *Listener) fetchTokenSymbol(ctx context.Context, tokenAddress common.Address) string {
-	symbol, found := l.symbolCache.GetOrFetch(ctx, tokenAddress, func(ctx context.Context, addr common.Address) (string, bool) {
-		symbolABI, err := contract.GetABI(contract.ERC20Symbol)
-		if err != nil {
-			logger.Error("Failed to get symbol ABI", "error", err)
-			return "", false
-		}
-
-		var symbol string
-		if err := contract.CallContract(ctx, l.client, addr, symbolABI, "symbol", &symbol); err != nil {
-			logger.Debug("Symbol fetch failed", "token", addr.Hex(), "error", err)
-			return "", false
-		}
-
-		if symbol == "" || len(symbol) > 20 {
-			logger.Debug("Invalid symbol", "token", addr.Hex(), "symbol", symbol)
-			return "", false
-		}
-
-		return symbol, true
-	})
-
-	if found {
-		return symbol
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
 	}
-	return ""
+	return &value
 }
@@ -0,0 +1,432 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/mock"
+
+	"ingester/internal/blockchain/mocks"
+	"ingester/internal/chain"
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+	"ingester/internal/oracle"
+	"ingester/internal/token"
+)
+
+var (
+	testPairA  = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	testPairB  = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	testToken0 = common.HexToAddress("0x0000000000000000000000000000000000000010")
+	testToken1 = common.HexToAddress("0x0000000000000000000000000000000000000011")
+)
+
+// pairCaller answers token0/token1/decimals calls the way a Uniswap V2 pair and its ERC-20s would.
+func pairCaller(failFor common.Address) func(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return func(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+		if *msg.To == failFor {
+			return nil, fmt.Errorf("rpc unavailable")
+		}
+		switch {
+		case bytes.Equal(msg.Data, UniswapV2PairABI.Methods["token0"].ID):
+			return common.LeftPadBytes(testToken0.Bytes(), 32), nil
+		case bytes.Equal(msg.Data, UniswapV2PairABI.Methods["token1"].ID):
+			return common.LeftPadBytes(testToken1.Bytes(), 32), nil
+		default:
+			return common.LeftPadBytes([]byte{18}, 32), nil
+		}
+	}
+}
+
+func newTestListener(t *testing.T, failFor common.Address, pairs ...common.Address) *Listener {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(pairCaller(failFor)).Maybe()
+
+	metadata := make([]PairMetadata, 0, len(pairs))
+	for _, pair := range pairs {
+		metadata = append(metadata, PairMetadata{PairAddress: pair, Token0Address: testToken0, Token1Address: testToken1})
+	}
+	return NewListenerWith(client, 137, metadata, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 0)
+}
+
+func TestListener_UpdatePairsAddsAndRemoves(t *testing.T) {
+	listener := newTestListener(t, common.Address{}, testPairA)
+
+	diff, err := listener.UpdatePairs(context.Background(), []common.Address{testPairB})
+	if err != nil {
+		t.Fatalf("UpdatePairs failed: %v", err)
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0] != testPairB {
+		t.Errorf("Expected %s added, got %v", testPairB.Hex(), diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != testPairA {
+		t.Errorf("Expected %s removed, got %v", testPairA.Hex(), diff.Removed)
+	}
+
+	pairs := listener.Pairs()
+	if len(pairs) != 1 || pairs[0].PairAddress != testPairB {
+		t.Fatalf("Expected only %s tracked, got %+v", testPairB.Hex(), pairs)
+	}
+	if pairs[0].Token0Address != testToken0 || pairs[0].Token1Decimals != 18 {
+		t.Errorf("Expected metadata fetched for new pair, got %+v", pairs[0])
+	}
+
+	select {
+	case <-listener.resubscribe:
+	default:
+		t.Error("Expected a resubscribe request after the address set changed")
+	}
+}
+
+func TestListener_UpdatePairsUnchangedDoesNotResubscribe(t *testing.T) {
+	listener := newTestListener(t, common.Address{}, testPairA, testPairB)
+
+	diff, err := listener.UpdatePairs(context.Background(), []common.Address{testPairB, testPairA})
+	if err != nil {
+		t.Fatalf("UpdatePairs failed: %v", err)
+	}
+
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("Expected empty diff, got %+v", diff)
+	}
+	select {
+	case <-listener.resubscribe:
+		t.Error("Did not expect a resubscribe request for an unchanged address set")
+	default:
+	}
+}
+
+func TestListener_UpdatePairsFailureKeepsCurrentSet(t *testing.T) {
+	listener := newTestListener(t, testPairB, testPairA)
+
+	_, err := listener.UpdatePairs(context.Background(), []common.Address{testPairA, testPairB})
+
+	var connErr *apperr.ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("Expected ConnectionError, got %v", err)
+	}
+	pairs := listener.Pairs()
+	if len(pairs) != 1 || pairs[0].PairAddress != testPairA {
+		t.Errorf("Expected original pair set to be kept, got %+v", pairs)
+	}
+}
+
+func TestListener_EventFromUntrackedPair(t *testing.T) {
+	listener := newTestListener(t, common.Address{}, testPairA)
+
+	_, err := listener.eventFromLog(context.Background(), types.Log{
+		Address: testPairB,
+		Topics:  []common.Hash{SwapEventTopic},
+	})
+
+	var dataErr *apperr.DataError
+	if !errors.As(err, &dataErr) {
+		t.Errorf("Expected DataError for untracked pair, got %v", err)
+	}
+}
+
+func TestDetectChainID(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().ChainID(mock.Anything).Return(big.NewInt(8453), nil)
+
+	chainID, err := DetectChainID(context.Background(), client, 0)
+	if err != nil || chainID != 8453 {
+		t.Errorf("Expected detected chain 8453, got %d (err=%v)", chainID, err)
+	}
+
+	_, err = DetectChainID(context.Background(), client, 137)
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Errorf("Expected ConfigError for chain mismatch, got %v", err)
+	}
+}
+
+func TestListener_EventsCarryChainID(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(pairCaller(common.Address{})).Maybe()
+	client.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Time: 1700000000}, nil)
+
+	pair := PairMetadata{PairAddress: testPairA, Token0Address: testToken0, Token1Address: testToken1, Token0Decimals: 18}
+	listener := NewListenerWith(client, 42161, []PairMetadata{pair}, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 0)
+
+	base, _, err := listener.buildBase(context.Background(), types.Log{Address: testPairA, BlockNumber: 10}, pair, events.EventTypeTransfer)
+	if err != nil {
+		t.Fatalf("buildBase failed: %v", err)
+	}
+	if base.ChainID != 42161 {
+		t.Errorf("Expected chainId 42161, got %d", base.ChainID)
+	}
+	if base.Token0Decimals == nil || *base.Token0Decimals != 18 || base.Token0Symbol != nil {
+		t.Errorf("Expected pair decimals and no unreadable symbol, got %v / %v", base.Token0Decimals, base.Token0Symbol)
+	}
+}
+
+// stubOracle quotes a fixed price per token.
+type stubOracle map[common.Address]oracle.Quote
+
+func (s stubOracle) FetchPrice(_ context.Context, token common.Address) (float64, bool) {
+	quote, ok := s[token]
+	return quote.Price, ok
+}
+
+func (s stubOracle) QuoteAt(_ context.Context, token common.Address, _ chain.Block) (oracle.Quote, bool) {
+	quote, ok := s[token]
+	return quote, ok
+}
+
+func TestValueSwap_ReportsQuoteProvenance(t *testing.T) {
+	chainlink := oracle.Quote{Price: 0.5, Source: oracle.PriceSourceChainlink, RoundID: big.NewInt(9), UpdatedAt: time.Unix(1700000000, 0)}
+	pair := PairMetadata{Token0Address: testToken0, Token1Address: testToken1, Token0Decimals: 18, Token1Decimals: 18}
+	oneToken := big.NewInt(1e18)
+	zero := big.NewInt(0)
+
+	tests := []struct {
+		name        string
+		stablecoins []common.Address
+		quotes      stubOracle
+		source      oracle.PriceSource
+		volumeUSD   float64
+	}{
+		{"stablecoin side wins", []common.Address{testToken1}, stubOracle{testToken0: chainlink}, oracle.PriceSourceStablecoin, 2},
+		{"feed price", nil, stubOracle{testToken0: chainlink}, oracle.PriceSourceChainlink, 0.5},
+		{"no price", nil, stubOracle{}, oracle.PriceSourceNone, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := oracle.NewRegistry(tt.stablecoins, nil, nil)
+			// 1 token0 in for 2 token1 out
+			valuation := valueSwap(context.Background(), registry, oracle.NewPriceCache(time.Minute, 1), tt.quotes,
+				chain.Latest, oneToken, zero, zero, big.NewInt(2e18), pair)
+
+			if valuation.quote.Source != tt.source {
+				t.Errorf("Expected source %s, got %s", tt.source, valuation.quote.Source)
+			}
+			if tt.source == oracle.PriceSourceNone {
+				if valuation.volumeUSD != nil {
+					t.Errorf("Expected no volume, got %f", *valuation.volumeUSD)
+				}
+				return
+			}
+			if valuation.volumeUSD == nil || *valuation.volumeUSD != tt.volumeUSD {
+				t.Errorf("Expected volume %f, got %v", tt.volumeUSD, valuation.volumeUSD)
+			}
+			if valuation.token0PriceUSD == nil || *valuation.token0PriceUSD != 0.5 {
+				t.Errorf("Expected token0 price 0.5 alongside any source, got %v", valuation.token0PriceUSD)
+			}
+		})
+	}
+}
+
+// gatedOracle prices token0 at 0.5 before block 90 and 0.6 from it, holding the later
+// answers until release is closed.
+type gatedOracle struct {
+	release chan struct{}
+	fetched chan uint64
+}
+
+func (g gatedOracle) FetchPrice(context.Context, common.Address) (float64, bool) {
+	return 0, false
+}
+
+func (g gatedOracle) QuoteAt(_ context.Context, token common.Address, block chain.Block) (oracle.Quote, bool) {
+	if token != testToken0 {
+		return oracle.Quote{}, false
+	}
+	if block.Number < 90 {
+		return oracle.Quote{Price: 0.5, Source: oracle.PriceSourceChainlink}, true
+	}
+	<-g.release
+	defer func() { g.fetched <- block.Number }()
+	return oracle.Quote{Price: 0.6, Source: oracle.PriceSourceChainlink}, true
+}
+
+func TestValueSwap_NextBucketAtTheTipDoesNotWaitOnTheOracle(t *testing.T) {
+	pair := PairMetadata{Token0Address: testToken0, Token1Address: testToken1, Token0Decimals: 18, Token1Decimals: 18}
+	registry := oracle.NewRegistry(nil, nil, nil)
+	priceCache := oracle.NewPriceCache(time.Minute, 30)
+	priceOracle := gatedOracle{release: make(chan struct{}), fetched: make(chan uint64, 1)}
+	value := func(number uint64) *float64 {
+		return valueSwap(context.Background(), registry, priceCache, priceOracle, chain.Block{Number: number},
+			big.NewInt(1e18), big.NewInt(0), big.NewInt(0), big.NewInt(2e18), pair).token0PriceUSD
+	}
+
+	if price := value(75); price == nil || *price != 0.5 {
+		t.Fatalf("Expected $0.5 in the first bucket, got %v", price)
+	}
+
+	// Block 95 opens the next bucket while the oracle is held.
+	done := make(chan *float64)
+	go func() { done <- value(95) }()
+	select {
+	case price := <-done:
+		if price == nil || *price != 0.5 {
+			t.Errorf("Expected the previous bucket's $0.5 while the next one is fetched, got %v", price)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the swap to be valued without waiting on the oracle")
+	}
+
+	close(priceOracle.release)
+	if block := <-priceOracle.fetched; block != 95 {
+		t.Errorf("Expected the next bucket fetched at block 95, got %d", block)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		price := value(100)
+		if price != nil && *price == 0.6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the fetched $0.6 for the next bucket, got %v", price)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListener_CompareWithTWAPWithoutHistoryIsNil(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().HeaderByNumber(mock.Anything, big.NewInt(99)).Return(nil, errors.New("missing trie node")).Once()
+
+	pair := PairMetadata{PairAddress: testPairA, Token0Address: testToken0, Token1Address: testToken1}
+	listener := NewListenerWith(client, 137, []PairMetadata{pair}, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 10)
+
+	for range 2 {
+		twapPrice, deviation := listener.compareWithTWAP(context.Background(), 100, 1.5, pair)
+		if twapPrice != nil || deviation != nil {
+			t.Errorf("Expected no TWAP comparison without history, got %v / %v", twapPrice, deviation)
+		}
+	}
+}
+
+func TestListener_CompareWithTWAPBacksOffAfterFailure(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().HeaderByNumber(mock.Anything, big.NewInt(99)).Return(nil, errors.New("missing trie node")).Once()
+
+	pair := PairMetadata{PairAddress: testPairA, Token0Address: testToken0, Token1Address: testToken1}
+	listener := NewListenerWith(client, 137, []PairMetadata{pair}, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 10)
+	defer listener.stopCaches()
+
+	// Later blocks of the same pair are not read again: the mock fails on any other call.
+	for block := uint64(100); block < 105; block++ {
+		if twapPrice, _ := listener.compareWithTWAP(context.Background(), block, 1.5, pair); twapPrice != nil {
+			t.Errorf("Expected no TWAP at block %d, got %v", block, *twapPrice)
+		}
+	}
+}
+
+// fakeSubscription records Unsubscribe and never fails.
+type fakeSubscription struct {
+	errs         chan error
+	unsubscribed bool
+}
+
+func (s *fakeSubscription) Unsubscribe()      { s.unsubscribed = true }
+func (s *fakeSubscription) Err() <-chan error { return s.errs }
+
+func TestListener_StopDeliversReceivedLogs(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(pairCaller(common.Address{})).Maybe()
+	client.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Time: 1700000000}, nil).Maybe()
+	client.EXPECT().BlockNumber(mock.Anything).Return(uint64(1000), nil)
+	subscription := &fakeSubscription{errs: make(chan error)}
+	client.EXPECT().SubscribeFilterLogs(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(
+		func(_ context.Context, _ ethereum.FilterQuery, logs chan<- types.Log) (ethereum.Subscription, error) {
+			for index := range 3 {
+				logs <- types.Log{
+					Address:     testPairA,
+					BlockNumber: 990,
+					Index:       uint(index),
+					Topics:      []common.Hash{TransferEventTopic, common.BytesToHash(testToken0.Bytes()), common.BytesToHash(testToken1.Bytes())},
+					Data:        common.LeftPadBytes([]byte{5}, 32),
+				}
+			}
+			return subscription, nil
+		})
+
+	pair := PairMetadata{PairAddress: testPairA, Token0Address: testToken0, Token1Address: testToken1}
+	listener := NewListenerWith(client, 137, []PairMetadata{pair}, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), nil, oracle.NewPriceCache(time.Minute, 1), 0)
+	listener.Stop()
+
+	output := make(chan events.Event, 10)
+	if err := listener.Listen(context.Background(), output); err != nil {
+		t.Fatalf("Expected Listen to return nil after Stop, got %v", err)
+	}
+	if len(output) != 3 {
+		t.Errorf("Expected the 3 received logs as events, got %d", len(output))
+	}
+	if !subscription.unsubscribed {
+		t.Error("Expected the subscription to be closed")
+	}
+}
+
+func TestAdjustedAmount(t *testing.T) {
+	tooLarge, _ := new(big.Int).SetString("100000000000000000000000000000000000000", 10) // 1e38
+
+	tests := []struct {
+		name     string
+		amount   *big.Int
+		decimals uint8
+		want     *string
+	}{
+		{"18 decimals", big.NewInt(1_500_000_000_000_000_000), 18, ptr("1.500000000000000000")},
+		{"6 decimals", big.NewInt(2_500_000), 6, ptr("2.500000000000000000")},
+		{"0 decimals", big.NewInt(7), 0, ptr("7.000000000000000000")},
+		{"more than 18 decimals truncate", big.NewInt(123_456_789), 24, ptr("0.000000000000000123")},
+		{"zero", big.NewInt(0), 18, ptr("0.000000000000000000")},
+		{"beyond precision", tooLarge, 18, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adjustedAmount(tt.amount, tt.decimals)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListener_SwapWithoutTransactionOrReceipt(t *testing.T) {
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(pairCaller(common.Address{})).Maybe()
+	client.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Time: 1700000000}, nil)
+	client.EXPECT().TransactionReceipt(mock.Anything, mock.Anything).Return(nil, ethereum.NotFound)
+	client.EXPECT().TransactionByHash(mock.Anything, mock.Anything).Return(nil, false, ethereum.NotFound)
+
+	pair := PairMetadata{PairAddress: testPairA, Token0Address: testToken0, Token1Address: testToken1, Token0Decimals: 18, Token1Decimals: 18}
+	listener := NewListenerWith(client, 137, []PairMetadata{pair}, token.NewService(client, nil, nil), oracle.NewRegistry(nil, nil, nil), stubOracle{}, oracle.NewPriceCache(time.Minute, 1), 0)
+	t.Cleanup(listener.stopCaches)
+	data, err := UniswapV2PairABI.Events["Swap"].Inputs.NonIndexed().Pack(big.NewInt(100), big.NewInt(0), big.NewInt(0), big.NewInt(90))
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	logEntry := types.Log{
+		Address:     testPairA,
+		Topics:      []common.Hash{SwapEventTopic, common.BytesToHash(testPairB.Bytes()), common.BytesToHash(testPairB.Bytes())},
+		Data:        data,
+		BlockNumber: 10,
+		TxIndex:     3,
+	}
+
+	swap, err := listener.parseSwapEvent(context.Background(), logEntry, pair)
+
+	if err != nil {
+		t.Fatalf("Expected the swap despite the missing transaction, got %v", err)
+	}
+	if swap.TxFrom != nil || swap.Nonce != nil || swap.MethodSelector != nil || swap.TxIndex != nil || swap.TxType != nil || swap.TxFee != nil {
+		t.Errorf("Expected no transaction details, got %+v", swap)
+	}
+	if swap.Amount0In != "100" || swap.HopCount != 1 {
+		t.Errorf("Expected the swap itself as the only hop, got %+v", swap)
+	}
+}
@@ -145,6 +145,64 @@ func (_c *MockEthClient_CallContract_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// ChainID provides a mock function with given fields: ctx
+func (_m *MockEthClient) ChainID(ctx context.Context) (*big.Int, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChainID")
+	}
+
+	var r0 *big.Int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*big.Int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *big.Int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockEthClient_ChainID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChainID'
+type MockEthClient_ChainID_Call struct {
+	*mock.Call
+}
+
+// ChainID is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockEthClient_Expecter) ChainID(ctx interface{}) *MockEthClient_ChainID_Call {
+	return &MockEthClient_ChainID_Call{Call: _e.mock.On("ChainID", ctx)}
+}
+
+func (_c *MockEthClient_ChainID_Call) Run(run func(ctx context.Context)) *MockEthClient_ChainID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockEthClient_ChainID_Call) Return(_a0 *big.Int, _a1 error) *MockEthClient_ChainID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockEthClient_ChainID_Call) RunAndReturn(run func(context.Context) (*big.Int, error)) *MockEthClient_ChainID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Close provides a mock function with no fields
 func (_m *MockEthClient) Close() {
 	_m.Called()
@@ -296,6 +354,72 @@ func (_c *MockEthClient_SubscribeFilterLogs_Call) RunAndReturn(run func(context.
 	return _c
 }
 
+// TransactionByHash provides a mock function with given fields: ctx, txHash
+func (_m *MockEthClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	ret := _m.Called(ctx, txHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransactionByHash")
+	}
+
+	var r0 *types.Transaction
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, common.Hash) (*types.Transaction, bool, error)); ok {
+		return rf(ctx, txHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, common.Hash) *types.Transaction); ok {
+		r0 = rf(ctx, txHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, common.Hash) bool); ok {
+		r1 = rf(ctx, txHash)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, common.Hash) error); ok {
+		r2 = rf(ctx, txHash)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockEthClient_TransactionByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TransactionByHash'
+type MockEthClient_TransactionByHash_Call struct {
+	*mock.Call
+}
+
+// TransactionByHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - txHash common.Hash
+func (_e *MockEthClient_Expecter) TransactionByHash(ctx interface{}, txHash interface{}) *MockEthClient_TransactionByHash_Call {
+	return &MockEthClient_TransactionByHash_Call{Call: _e.mock.On("TransactionByHash", ctx, txHash)}
+}
+
+func (_c *MockEthClient_TransactionByHash_Call) Run(run func(ctx context.Context, txHash common.Hash)) *MockEthClient_TransactionByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(common.Hash))
+	})
+	return _c
+}
+
+func (_c *MockEthClient_TransactionByHash_Call) Return(_a0 *types.Transaction, _a1 bool, _a2 error) *MockEthClient_TransactionByHash_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockEthClient_TransactionByHash_Call) RunAndReturn(run func(context.Context, common.Hash) (*types.Transaction, bool, error)) *MockEthClient_TransactionByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TransactionReceipt provides a mock function with given fields: ctx, txHash
 func (_m *MockEthClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	ret := _m.Called(ctx, txHash)
@@ -355,6 +479,67 @@ func (_c *MockEthClient_TransactionReceipt_Call) RunAndReturn(run func(context.C
 	return _c
 }
 
+// TransactionSender provides a mock function with given fields: ctx, tx, block, index
+func (_m *MockEthClient) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	ret := _m.Called(ctx, tx, block, index)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransactionSender")
+	}
+
+	var r0 common.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Transaction, common.Hash, uint) (common.Address, error)); ok {
+		return rf(ctx, tx, block, index)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Transaction, common.Hash, uint) common.Address); ok {
+		r0 = rf(ctx, tx, block, index)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(common.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *types.Transaction, common.Hash, uint) error); ok {
+		r1 = rf(ctx, tx, block, index)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockEthClient_TransactionSender_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TransactionSender'
+type MockEthClient_TransactionSender_Call struct {
+	*mock.Call
+}
+
+// TransactionSender is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tx *types.Transaction
+//   - block common.Hash
+//   - index uint
+func (_e *MockEthClient_Expecter) TransactionSender(ctx interface{}, tx interface{}, block interface{}, index interface{}) *MockEthClient_TransactionSender_Call {
+	return &MockEthClient_TransactionSender_Call{Call: _e.mock.On("TransactionSender", ctx, tx, block, index)}
+}
+
+func (_c *MockEthClient_TransactionSender_Call) Run(run func(ctx context.Context, tx *types.Transaction, block common.Hash, index uint)) *MockEthClient_TransactionSender_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*types.Transaction), args[2].(common.Hash), args[3].(uint))
+	})
+	return _c
+}
+
+func (_c *MockEthClient_TransactionSender_Call) Return(_a0 common.Address, _a1 error) *MockEthClient_TransactionSender_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockEthClient_TransactionSender_Call) RunAndReturn(run func(context.Context, *types.Transaction, common.Hash, uint) (common.Address, error)) *MockEthClient_TransactionSender_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockEthClient creates a new instance of MockEthClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockEthClient(t interface {
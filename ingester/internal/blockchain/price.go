@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"math/big"
+	"strings"
+
+	"ingester/internal/events"
+)
+
+// swapPrice is the exact token1-per-token0 price of a swap, decimals-adjusted; nil when the
+// swap has no input on one side and output on the other.
+func swapPrice(amount0In, amount1In, amount0Out, amount1Out *big.Int, pairMetadata PairMetadata) *big.Rat {
+	if amount0In.Sign() > 0 && amount1Out.Sign() > 0 {
+		return adjustedRatio(amount1Out, amount0In, pairMetadata.Token1Decimals, pairMetadata.Token0Decimals)
+	}
+	if amount1In.Sign() > 0 && amount0Out.Sign() > 0 {
+		return adjustedRatio(amount1In, amount0Out, pairMetadata.Token1Decimals, pairMetadata.Token0Decimals)
+	}
+	return nil
+}
+
+// ratioToFloat64 is adjustedRatio as the nearest float64, or 0 when denominator is zero.
+func ratioToFloat64(numerator, denominator *big.Int, numeratorDecimals, denominatorDecimals uint8) float64 {
+	return ratFloat64(adjustedRatio(numerator, denominator, numeratorDecimals, denominatorDecimals))
+}
+
+// adjustedRatio is (numerator / 10^numeratorDecimals) / (denominator / 10^denominatorDecimals),
+// exactly; nil when denominator is zero.
+func adjustedRatio(numerator, denominator *big.Int, numeratorDecimals, denominatorDecimals uint8) *big.Rat {
+	if denominator.Sign() == 0 {
+		return nil
+	}
+
+	// price = (numerator / 10^numDec) / (denominator / 10^denomDec)
+	//       = numerator * 10^(denomDec - numDec) / denominator
+	decimalDiff := int(denominatorDecimals) - int(numeratorDecimals)
+
+	adjustedNumerator := new(big.Int).Set(numerator)
+	if decimalDiff > 0 {
+		adjustedNumerator.Mul(adjustedNumerator, pow10(decimalDiff))
+	} else if decimalDiff < 0 {
+		denominator = new(big.Int).Mul(denominator, pow10(-decimalDiff))
+	}
+
+	return new(big.Rat).SetFrac(adjustedNumerator, denominator)
+}
+
+// ratFloat64 is the nearest float64 to ratio, or 0 when ratio is nil.
+func ratFloat64(ratio *big.Rat) float64 {
+	if ratio == nil {
+		return 0
+	}
+	value, _ := ratio.Float64()
+	return value
+}
+
+// significantDecimal renders ratio in plain decimal notation, rounded half away from zero to
+// precision significant digits, without trailing fractional zeros. Unlike a float64 it keeps
+// every digit of extreme ratios and never rounds a tiny price to 0; nil when ratio is nil.
+func significantDecimal(ratio *big.Rat, precision int) *string {
+	if ratio == nil {
+		return nil
+	}
+	if ratio.Sign() == 0 {
+		zero := "0"
+		return &zero
+	}
+
+	// exponent is the power of ten of the leading digit: 10^exponent <= |ratio| < 10^(exponent+1).
+	abs := new(big.Rat).Abs(ratio)
+	exponent := len(abs.Num().String()) - len(abs.Denom().String())
+	if abs.Cmp(ratPow10(exponent)) < 0 {
+		exponent--
+	}
+
+	var text string
+	if fractionDigits := precision - 1 - exponent; fractionDigits >= 0 {
+		text = ratio.FloatString(fractionDigits)
+		if strings.Contains(text, ".") {
+			text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+		}
+	} else {
+		// More integer digits than precision: round to a multiple of 10^-fractionDigits.
+		text = new(big.Rat).Quo(ratio, ratPow10(-fractionDigits)).FloatString(0) + strings.Repeat("0", -fractionDigits)
+	}
+	return &text
+}
+
+// scaledDecimal is ratio as a decimal string with events.AmountScale fractional digits, for
+// the Avro decimal twin of a float price. The Avro scale is fixed by the schema, unlike
+// priceExact's precision, so it is nil when ratio is nil, needs more than
+// events.AmountPrecision digits, or is non-zero but rounds to zero at that scale: a price
+// below 5e-19 underflows to null rather than being published as 0.
+func scaledDecimal(ratio *big.Rat) *string {
+	if ratio == nil {
+		return nil
+	}
+	text := ratio.FloatString(events.AmountScale)
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(text), "0")
+	if len(digits) > events.AmountPrecision || (digits == "" && ratio.Sign() != 0) {
+		return nil
+	}
+	return &text
+}
+
+func ratPow10(exponent int) *big.Rat {
+	if exponent < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), pow10(-exponent))
+	}
+	return new(big.Rat).SetInt(pow10(exponent))
+}
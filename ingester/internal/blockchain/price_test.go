@@ -0,0 +1,160 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func bigInt(t *testing.T, value string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		t.Fatalf("invalid integer %q", value)
+	}
+	return n
+}
+
+func TestSwapPrice_ExtremeRatios(t *testing.T) {
+	tests := []struct {
+		name                 string
+		decimals0, decimals1 uint8
+		in0, in1, out0, out1 string
+		precision            int
+		exact                *string
+		decimal              *string
+		float                float64
+	}{
+		{
+			name:      "SHIB/USDC: 1e9 SHIB for 8,500 USDC",
+			decimals0: 18, decimals1: 6,
+			in0: "1000000000000000000000000000", in1: "0", out0: "0", out1: "8500000000",
+			precision: 30,
+			exact:     ptr("0.0000085"),
+			decimal:   ptr("0.000008500000000000"),
+			float:     8.5e-6,
+		},
+		{
+			name:      "USDC/SHIB: 1 USDC for 100,000 SHIB",
+			decimals0: 6, decimals1: 18,
+			in0: "1000000", in1: "0", out0: "0", out1: "100000000000000000000000",
+			precision: 30,
+			exact:     ptr("100000"),
+			decimal:   ptr("100000.000000000000000000"),
+			float:     1e5,
+		},
+		{
+			name:      "repeating decimal keeps the configured digits",
+			decimals0: 18, decimals1: 18,
+			in0: "3", in1: "0", out0: "0", out1: "1",
+			precision: 30,
+			exact:     ptr("0.333333333333333333333333333333"),
+			decimal:   ptr("0.333333333333333333"),
+			float:     1.0 / 3,
+		},
+		{
+			name:      "repeating decimal at low precision",
+			decimals0: 18, decimals1: 18,
+			in0: "0", in1: "2", out0: "3", out1: "0",
+			precision: 5,
+			exact:     ptr("0.66667"),
+			decimal:   ptr("0.666666666666666667"),
+			float:     2.0 / 3,
+		},
+		{
+			name:      "1 wei for 1e18 tokens is not rounded to zero",
+			decimals0: 18, decimals1: 18,
+			in0: "1000000000000000000000000000000000000", in1: "0", out0: "0", out1: "1",
+			precision: 30,
+			exact:     ptr("0.000000000000000000000000000000000001"),
+			decimal:   nil,
+			float:     1e-36,
+		},
+		{
+			name:      "price beyond the decimal's integer digits",
+			decimals0: 0, decimals1: 18,
+			in0: "1", in1: "0", out0: "0", out1: "100000000000000000000000000000000000000000000000000000000000000000000000000000",
+			precision: 30,
+			exact:     ptr("1" + "00000000000000000000000000000000000000000000000000000000000"),
+			decimal:   nil,
+			float:     1e59,
+		},
+		{
+			name:      "more integer digits than precision round to a multiple of ten",
+			decimals0: 18, decimals1: 18,
+			in0: "1", in1: "0", out0: "0", out1: "987654",
+			precision: 3,
+			exact:     ptr("988000"),
+			decimal:   ptr("987654.000000000000000000"),
+			float:     987654,
+		},
+		{
+			name:      "rounding carries into the next digit",
+			decimals0: 18, decimals1: 18,
+			in0: "100000", in1: "0", out0: "0", out1: "99999",
+			precision: 3,
+			exact:     ptr("1"),
+			decimal:   ptr("0.999990000000000000"),
+			float:     0.99999,
+		},
+		{
+			name:      "no priced leg",
+			decimals0: 18, decimals1: 6,
+			in0: "1000", in1: "1000", out0: "0", out1: "0",
+			precision: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair := PairMetadata{Token0Decimals: tt.decimals0, Token1Decimals: tt.decimals1}
+			ratio := swapPrice(bigInt(t, tt.in0), bigInt(t, tt.in1), bigInt(t, tt.out0), bigInt(t, tt.out1), pair)
+
+			if got := significantDecimal(ratio, tt.precision); !equalStrings(got, tt.exact) {
+				t.Errorf("Expected exact price %v, got %v", deref(tt.exact), deref(got))
+			}
+			if got := scaledDecimal(ratio); !equalStrings(got, tt.decimal) {
+				t.Errorf("Expected decimal price %v, got %v", deref(tt.decimal), deref(got))
+			}
+			if got := ratFloat64(ratio); got != tt.float {
+				t.Errorf("Expected float price %g, got %g", tt.float, got)
+			}
+		})
+	}
+}
+
+func TestScaledDecimal_UnderflowIsNull(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio *big.Rat
+		want  *string
+	}{
+		{"below half a unit of the 18th place", new(big.Rat).Mul(big.NewRat(4, 1), ratPow10(-19)), nil},
+		{"half a unit rounds up", new(big.Rat).Mul(big.NewRat(5, 1), ratPow10(-19)), ptr("0.000000000000000001")},
+		{"smallest representable", big.NewRat(1, 1e18), ptr("0.000000000000000001")},
+		{"zero stays zero", new(big.Rat), ptr("0.000000000000000000")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scaledDecimal(tt.ratio); !equalStrings(got, tt.want) {
+				t.Errorf("Expected %v, got %v", deref(tt.want), deref(got))
+			}
+		})
+	}
+}
+
+func TestSignificantDecimal_Negative(t *testing.T) {
+	if got := significantDecimal(big.NewRat(-2, 3), 4); got == nil || *got != "-0.6667" {
+		t.Errorf("Expected -0.6667, got %v", deref(got))
+	}
+}
+
+func equalStrings(a, b *string) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return "<nil>"
+	}
+	return *value
+}
@@ -0,0 +1,98 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/chain"
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+)
+
+// UniswapV3SwapTopic is Swap(address,address,int256,int256,uint160,uint128,int24). V3 pools
+// are not tracked, but their swaps count as hops of a route that also crosses a V2 pair.
+var UniswapV3SwapTopic = common.HexToHash("0xc42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67")
+
+// swapRoute is how a swap sits in its transaction: its hop in a multi-pool route and the
+// router the transaction called.
+type swapRoute struct {
+	hopIndex      int32
+	hopCount      int32
+	router        *string
+	viaAggregator bool
+}
+
+// swapDirection classifies a swap by what the pair received net of what it paid out, so a
+// swap that pays part of its input back (flash swaps) is still classified by its net effect.
+// It returns UNKNOWN, with zero amounts, unless one token went in and the other came out.
+func swapDirection(amount0In, amount1In, amount0Out, amount1Out *big.Int) (string, *big.Int, *big.Int) {
+	net0 := new(big.Int).Sub(amount0In, amount0Out)
+	net1 := new(big.Int).Sub(amount1In, amount1Out)
+	switch {
+	case net0.Sign() > 0 && net1.Sign() < 0:
+		return events.SwapDirectionToken0ToToken1, net0, net1.Neg(net1)
+	case net1.Sign() > 0 && net0.Sign() < 0:
+		return events.SwapDirectionToken1ToToken0, net0.Neg(net0), net1
+	default:
+		return events.SwapDirectionUnknown, new(big.Int), new(big.Int)
+	}
+}
+
+// executionPrice is the output token received per input token, from the absolute net amounts
+// swapDirection returns. It is nil when the direction is unknown.
+func executionPrice(direction string, net0, net1 *big.Int, pairMetadata PairMetadata) *float64 {
+	var price float64
+	switch direction {
+	case events.SwapDirectionToken0ToToken1:
+		price = ratioToFloat64(net1, net0, pairMetadata.Token1Decimals, pairMetadata.Token0Decimals)
+	case events.SwapDirectionToken1ToToken0:
+		price = ratioToFloat64(net0, net1, pairMetadata.Token0Decimals, pairMetadata.Token1Decimals)
+	default:
+		return nil
+	}
+	return &price
+}
+
+// routeOf places the swap logged at logIndex among the swap logs of its receipt and names the
+// router the transaction was sent to. A transaction with several swap logs is a multi-hop
+// route or a batch of swaps; either way each swap reports its position. Without the receipt
+// the swap counts as the only hop, and without the transaction no router is named.
+func routeOf(receipt *types.Receipt, tx *types.Transaction, logIndex uint, profile chain.Profile) swapRoute {
+	var route swapRoute
+	var logs []*types.Log
+	if receipt != nil {
+		logs = receipt.Logs
+	}
+	for _, logEntry := range logs {
+		if len(logEntry.Topics) == 0 || (logEntry.Topics[0] != SwapEventTopic && logEntry.Topics[0] != UniswapV3SwapTopic) {
+			continue
+		}
+		if logEntry.Index < logIndex {
+			route.hopIndex++
+		}
+		route.hopCount++
+	}
+	route.hopCount = max(route.hopCount, 1)
+
+	if tx == nil {
+		return route
+	}
+	if to := tx.To(); to != nil {
+		if router, ok := profile.RouterAt(*to); ok {
+			route.router = &router.Name
+			route.viaAggregator = router.Aggregator
+		}
+	}
+	return route
+}
+
+func fetchTransaction(ctx context.Context, client EthClient, txHash common.Hash) (*types.Transaction, error) {
+	tx, _, err := client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, &apperr.ConnectionError{Message: "rpc transaction fetch failed", Cause: err}
+	}
+	return tx, nil
+}
@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/chain"
+	"ingester/internal/events"
+)
+
+func TestSwapDirection_ExecutionPrice(t *testing.T) {
+	// token0 has 18 decimals, token1 (a stablecoin) 6
+	pair := PairMetadata{Token0Decimals: 18, Token1Decimals: 6}
+	zero := big.NewInt(0)
+
+	tests := []struct {
+		name                                         string
+		amount0In, amount1In, amount0Out, amount1Out *big.Int
+		direction                                    string
+		price                                        *float64
+	}{
+		{"sell token0", big.NewInt(2e18), zero, zero, big.NewInt(3e6), events.SwapDirectionToken0ToToken1, ptr(1.5)},
+		{"sell token1", zero, big.NewInt(3e6), big.NewInt(2e18), zero, events.SwapDirectionToken1ToToken0, ptr(2.0 / 3.0)},
+		// 3e18 token0 in, 1e18 of it paid back out: 2e18 net for 3e6 token1
+		{"flash swap nets out", big.NewInt(3e18), zero, big.NewInt(1e18), big.NewInt(3e6), events.SwapDirectionToken0ToToken1, ptr(1.5)},
+		{"both in", big.NewInt(1e18), big.NewInt(1e6), zero, zero, events.SwapDirectionUnknown, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			direction, net0, net1 := swapDirection(tt.amount0In, tt.amount1In, tt.amount0Out, tt.amount1Out)
+			if direction != tt.direction {
+				t.Fatalf("Expected direction %s, got %s", tt.direction, direction)
+			}
+			price := executionPrice(direction, net0, net1, pair)
+			if (price == nil) != (tt.price == nil) {
+				t.Fatalf("Expected execution price %v, got %v", tt.price, price)
+			}
+			if price != nil && *price != *tt.price {
+				t.Errorf("Expected execution price %f, got %f", *tt.price, *price)
+			}
+		})
+	}
+}
+
+func TestRouteOf_MultiHopThroughAggregator(t *testing.T) {
+	oneInch := common.HexToAddress("0x1111111254EEB25477B68fb85Ed929f73A960582")
+	tx := types.NewTx(&types.DynamicFeeTx{To: &oneInch})
+	receipt := &types.Receipt{Logs: []*types.Log{
+		{Index: 1, Topics: []common.Hash{TransferEventTopic}},
+		{Index: 2, Topics: []common.Hash{UniswapV3SwapTopic}},
+		{Index: 3, Topics: []common.Hash{TransferEventTopic}},
+		{Index: 4, Topics: []common.Hash{SwapEventTopic}},
+		{Index: 5, Topics: []common.Hash{SwapEventTopic}},
+	}}
+
+	route := routeOf(receipt, tx, 4, chain.ProfileFor(137))
+
+	if route.hopIndex != 1 || route.hopCount != 3 {
+		t.Errorf("Expected hop 1 of 3, got %d of %d", route.hopIndex, route.hopCount)
+	}
+	if route.router == nil || *route.router != "1inch v5" || !route.viaAggregator {
+		t.Errorf("Expected the 1inch aggregator, got %v (aggregator %v)", route.router, route.viaAggregator)
+	}
+}
+
+func TestRouteOf_DirectPairCall(t *testing.T) {
+	tx := types.NewTx(&types.DynamicFeeTx{To: &testPairA})
+	receipt := &types.Receipt{Logs: []*types.Log{{Index: 0, Topics: []common.Hash{SwapEventTopic}}}}
+
+	route := routeOf(receipt, tx, 0, chain.ProfileFor(137))
+
+	if route.hopIndex != 0 || route.hopCount != 1 {
+		t.Errorf("Expected a single hop, got %d of %d", route.hopIndex, route.hopCount)
+	}
+	if route.router != nil || route.viaAggregator {
+		t.Errorf("Expected no router, got %v (aggregator %v)", route.router, route.viaAggregator)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txOrigin is who sent a swap's transaction and what they called. Swap.sender is usually a
+// router; txFrom is the externally owned account behind it.
+type txOrigin struct {
+	from           *string
+	to             *string
+	nonce          *int64
+	methodSelector *string
+}
+
+// unrecoverableTypes holds the transaction types a sender could not be recovered for, so each
+// is warned about once rather than on every swap.
+var unrecoverableTypes sync.Map
+
+// originOf asks the node for the transaction's sender and only recovers it from the signature
+// locally when the node cannot tell (no receipt to locate the transaction, or the lookup
+// failed). ethclient answers from the sender it cached when the transaction was fetched, so
+// this is usually free. from is nil when neither works (an unsupported transaction type),
+// which is logged at Warn the first time for each type and at Debug afterwards; to is nil for
+// contract creations and methodSelector when the calldata is shorter than a selector. Every
+// field is nil when tx is, because the node no longer serves the transaction.
+func originOf(ctx context.Context, client EthClient, tx *types.Transaction, receipt *types.Receipt, chainID uint64) txOrigin {
+	if tx == nil {
+		return txOrigin{}
+	}
+	nonce := int64(tx.Nonce())
+	origin := txOrigin{nonce: &nonce}
+	if from, err := senderOf(ctx, client, tx, receipt, chainID); err == nil {
+		origin.from = nonEmpty(from.Hex())
+	} else {
+		if _, warned := unrecoverableTypes.LoadOrStore(tx.Type(), true); warned {
+			logger.Debug("Transaction sender not recoverable", "tx", tx.Hash().Hex(), "type", tx.Type(), "error", err)
+		} else {
+			logger.Warn("Transaction sender not recoverable; further transactions of this type are logged at debug level",
+				"tx", tx.Hash().Hex(), "type", tx.Type(), "error", err)
+		}
+	}
+	if to := tx.To(); to != nil {
+		origin.to = nonEmpty(to.Hex())
+	}
+	if data := tx.Data(); len(data) >= 4 {
+		origin.methodSelector = nonEmpty(hexutil.Encode(data[:4]))
+	}
+	return origin
+}
+
+// senderOf is the sender the node reports for the transaction at the receipt's position,
+// falling back to recovering it from the signature.
+func senderOf(ctx context.Context, client EthClient, tx *types.Transaction, receipt *types.Receipt, chainID uint64) (common.Address, error) {
+	if receipt != nil {
+		from, err := client.TransactionSender(ctx, tx, receipt.BlockHash, receipt.TransactionIndex)
+		if err == nil {
+			return from, nil
+		}
+		logger.Debug("Node did not report the transaction sender, recovering it locally", "tx", tx.Hash().Hex(), "error", err)
+	}
+	return types.Sender(types.LatestSignerForChainID(new(big.Int).SetUint64(chainID)), tx)
+}
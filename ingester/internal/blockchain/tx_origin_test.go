@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/mock"
+
+	"ingester/internal/blockchain/mocks"
+)
+
+func TestOriginOf_RecoversSenderAndSelector(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(137))
+	// swapExactTokensForTokens(uint256,uint256,address[],address,uint256)
+	tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID: big.NewInt(137),
+		Nonce:   7,
+		To:      &testPairA,
+		Data:    []byte{0x38, 0xed, 0x17, 0x39, 0x00},
+	})
+
+	// Without a receipt to locate the transaction the node is not asked.
+	origin := originOf(context.Background(), mocks.NewMockEthClient(t), tx, nil, 137)
+
+	if want := crypto.PubkeyToAddress(key.PublicKey).Hex(); origin.from == nil || *origin.from != want {
+		t.Errorf("Expected txFrom %s, got %v", want, origin.from)
+	}
+	if origin.to == nil || *origin.to != testPairA.Hex() {
+		t.Errorf("Expected txTo %s, got %v", testPairA.Hex(), origin.to)
+	}
+	if origin.nonce == nil || *origin.nonce != 7 {
+		t.Errorf("Expected nonce 7, got %v", origin.nonce)
+	}
+	if origin.methodSelector == nil || *origin.methodSelector != "0x38ed1739" {
+		t.Errorf("Expected selector 0x38ed1739, got %v", origin.methodSelector)
+	}
+}
+
+func TestOriginOf_PrefersTheSenderTheNodeReports(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(big.NewInt(137)), &types.DynamicFeeTx{ChainID: big.NewInt(137), To: &testPairA})
+	receipt := &types.Receipt{BlockHash: common.HexToHash("0xb1"), TransactionIndex: 3}
+	// The node's answer wins over local recovery, e.g. for a type this go-ethereum cannot verify.
+	reported := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().TransactionSender(mock.Anything, tx, receipt.BlockHash, uint(3)).Return(reported, nil)
+
+	origin := originOf(context.Background(), client, tx, receipt, 137)
+
+	if origin.from == nil || *origin.from != reported.Hex() {
+		t.Errorf("Expected txFrom %s, got %v", reported.Hex(), origin.from)
+	}
+}
+
+func TestOriginOf_UnsignedTransactionHasNoSender(t *testing.T) {
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(137), Data: []byte{0x01}})
+
+	client := mocks.NewMockEthClient(t)
+	client.EXPECT().TransactionSender(mock.Anything, tx, mock.Anything, uint(0)).Return(common.Address{}, errors.New("sender not cached"))
+
+	origin := originOf(context.Background(), client, tx, &types.Receipt{}, 137)
+
+	if origin.from != nil || origin.to != nil || origin.methodSelector != nil {
+		t.Errorf("Expected only the nonce, got %+v", origin)
+	}
+	if origin.nonce == nil || *origin.nonce != 0 {
+		t.Errorf("Expected nonce 0, got %v", origin.nonce)
+	}
+	if _, warned := unrecoverableTypes.Load(tx.Type()); !warned {
+		t.Error("Expected the transaction type to be remembered so later failures are not warned about again")
+	}
+}
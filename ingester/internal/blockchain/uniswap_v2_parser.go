@@ -170,14 +170,10 @@ func parseTransferLog(logEntry types.Log) (from, to common.Address, value *big.I
 			&errors.DataError{Message: "transfer value cannot be negative"}
 	}
 
+	// The zero address is valid on either side: LP tokens are minted from it and burned to it.
 	from = common.BytesToAddress(logEntry.Topics[1].Bytes())
 	to = common.BytesToAddress(logEntry.Topics[2].Bytes())
 
-	if from == (common.Address{}) || to == (common.Address{}) {
-		return common.Address{}, common.Address{}, nil,
-			&errors.DataError{Message: "transfer addresses cannot be zero"}
-	}
-
 	return from, to, value, nil
 }
 
@@ -227,6 +227,29 @@ func TestParseTransferLog_ValidLog(t *testing.T) {
 	}
 }
 
+func TestParseTransferLog_AllowsMintAndBurn(t *testing.T) {
+	holder := common.HexToAddress("0x6666666666666666666666666666666666666666")
+
+	for _, parties := range [][2]common.Address{{{}, holder}, {holder, {}}} {
+		logEntry := types.Log{
+			Topics: []common.Hash{
+				TransferEventTopic,
+				common.BytesToHash(parties[0].Bytes()),
+				common.BytesToHash(parties[1].Bytes()),
+			},
+			Data: common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+		}
+
+		from, to, _, err := parseTransferLog(logEntry)
+		if err != nil {
+			t.Fatalf("parseTransferLog failed for %s -> %s: %v", parties[0].Hex(), parties[1].Hex(), err)
+		}
+		if from != parties[0] || to != parties[1] {
+			t.Errorf("Expected %s -> %s, got %s -> %s", parties[0].Hex(), parties[1].Hex(), from.Hex(), to.Hex())
+		}
+	}
+}
+
 func TestReadBigInt_Success(t *testing.T) {
 	value := big.NewInt(12345)
 	values := map[string]any{
@@ -1,54 +1,140 @@
 package cache
 
 import (
+	"container/list"
 	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
 	"sync"
 	"time"
 )
 
-// Cache is a generic thread-safe store with optional TTL.
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// Cache is a generic thread-safe store with optional TTL, size bound and persistent Storage.
+// Concurrent GetOrFetch calls for the same missing key share one fetch. With a soft TTL,
+// GetOrFetch serves entries past it while refreshing them in the background, until the
+// (hard) TTL expires them.
 type Cache[K comparable, V any] struct {
-	mu    sync.RWMutex
-	items map[K]*CacheEntry[V]
-	ttl   time.Duration
+	mu         sync.RWMutex
+	items      map[K]*list.Element
+	recency    *list.List // front is most recently used
+	inFlight   map[K]*fetchCall[V]
+	ttl        time.Duration
+	softTTL    time.Duration
+	softKeys   func(K) bool
+	maxEntries int
+	storage    Storage
+	stats      counters
 }
 
 type CacheEntry[V any] struct {
 	Value     V
 	Found     bool
 	ExpiresAt time.Time
+	// RefreshAt is when the entry turns stale under a soft TTL; zero means never.
+	RefreshAt time.Time `json:",omitempty"`
+}
+
+// Options configures a cache beyond its TTL. The zero value is an unbounded in-memory cache.
+type Options struct {
+	// MaxEntries bounds the cache; inserting beyond it evicts the least recently used entry.
+	// 0 means unbounded.
+	MaxEntries int
+	// SoftTTL, when positive, is how long after a Set GetOrFetch starts refreshing an entry in
+	// the background while still serving it. Only useful below the cache's TTL.
+	SoftTTL time.Duration
+	// Storage persists entries across restarts; nil keeps them in memory only.
+	Storage Storage
+	// Name, when set, publishes the cache's Stats under that key of the "caches" expvar.
+	Name string
+}
+
+type item[K comparable, V any] struct {
+	key   K
+	entry *CacheEntry[V]
+}
+
+// fetchCall is one in-flight fetch; done is closed once value and found are final.
+// invalidated is set when its key is evicted while the fetch runs, so the result, which may
+// predate the eviction, is returned but not stored.
+type fetchCall[V any] struct {
+	done        chan struct{}
+	value       V
+	found       bool
+	invalidated bool
 }
 
 // NewCache creates a cache. ttl=0 means entries never expire.
 func NewCache[K comparable, V any](ttl time.Duration) *Cache[K, V] {
 	return &Cache[K, V]{
-		items: make(map[K]*CacheEntry[V]),
-		ttl:   ttl,
+		items:    make(map[K]*list.Element),
+		recency:  list.New(),
+		inFlight: make(map[K]*fetchCall[V]),
+		ttl:      ttl,
+	}
+}
+
+// NewPersistentCache creates a cache that starts with the unexpired entries in storage and
+// writes every change through to it. Keys and values are stored as JSON, so only their
+// exported fields survive a restart. A failed write is logged and leaves the in-memory cache
+// authoritative.
+func NewPersistentCache[K comparable, V any](ttl time.Duration, storage Storage) (*Cache[K, V], error) {
+	return NewCacheWith[K, V](ttl, Options{Storage: storage})
+}
+
+// NewCacheWith creates a cache configured by opts. It only fails when opts.Storage cannot be
+// loaded; see NewPersistentCache for how entries are persisted.
+func NewCacheWith[K comparable, V any](ttl time.Duration, opts Options) (*Cache[K, V], error) {
+	c := NewCache[K, V](ttl)
+	c.maxEntries = opts.MaxEntries
+	c.softTTL = opts.SoftTTL
+	if opts.Storage != nil {
+		if err := c.load(opts.Storage); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Name != "" {
+		publish(opts.Name, c.Stats)
 	}
+	return c, nil
+}
+
+func (c *Cache[K, V]) load(storage Storage) error {
+	c.storage = storage
+	stored, err := storage.Load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for encodedKey, encodedEntry := range stored {
+		var key K
+		var entry CacheEntry[V]
+		if json.Unmarshal([]byte(encodedKey), &key) != nil || json.Unmarshal(encodedEntry, &entry) != nil {
+			logger.Warn("Dropping unreadable cache entry", "key", encodedKey)
+			c.persistDelete(key, []byte(encodedKey))
+			continue
+		}
+		if !entry.ExpiresAt.IsZero() && now.After(entry.ExpiresAt) {
+			c.persistDelete(key, []byte(encodedKey))
+			continue
+		}
+		c.insert(key, &entry)
+	}
+	return nil
 }
 
 // Get returns (value, found, exists). Expired entries are evicted on read.
 func (c *Cache[K, V]) Get(key K) (V, bool, bool) {
-	c.mu.RLock()
-	entry, exists := c.items[key]
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	entry, exists := c.lookup(key)
 	if !exists {
-		c.mu.RUnlock()
-		var zero V
-		return zero, false, false
-	}
-
-	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
-		c.mu.RUnlock()
-		// Upgrade to write lock to evict
-		c.mu.Lock()
-		delete(c.items, key)
-		c.mu.Unlock()
 		var zero V
 		return zero, false, false
 	}
-
-	c.mu.RUnlock()
 	return entry.Value, entry.Found, true
 }
 
@@ -56,38 +142,141 @@ func (c *Cache[K, V]) Get(key K) (V, bool, bool) {
 func (c *Cache[K, V]) Set(key K, value V, found bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.set(key, value, found)
+}
 
-	var expiresAt time.Time
+// set is Set with c.mu held for writing.
+func (c *Cache[K, V]) set(key K, value V, found bool) {
+	now := time.Now()
+	entry := &CacheEntry[V]{
+		Value: value,
+		Found: found,
+	}
 	if c.ttl > 0 {
-		expiresAt = time.Now().Add(c.ttl)
+		entry.ExpiresAt = now.Add(c.ttl)
 	}
-
-	c.items[key] = &CacheEntry[V]{
-		Value:     value,
-		Found:     found,
-		ExpiresAt: expiresAt,
+	if c.softTTL > 0 && (c.softKeys == nil || c.softKeys(key)) {
+		entry.RefreshAt = now.Add(c.softTTL)
 	}
+	c.insert(key, entry)
+	c.persistPut(key, entry)
+}
+
+// LimitSoftTTL applies the soft TTL only to keys match returns true for; other entries are
+// served until they expire. Call it before the cache is used.
+func (c *Cache[K, V]) LimitSoftTTL(match func(K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.softKeys = match
 }
 
 // GetOrFetch returns a cached value or calls fetch on miss.
 // Failures are only cached when TTL > 0 so transient errors don't become permanent.
+// Callers arriving while a fetch for the same key is running wait for its result instead of
+// fetching again; a waiter whose ctx ends first gets (zero, false).
+// A stale entry (past its soft TTL) is returned at once and refreshed in the background; a
+// failed refresh keeps the stale entry, and the next stale read tries again.
 func (c *Cache[K, V]) GetOrFetch(
 	ctx context.Context,
 	key K,
 	fetch func(context.Context, K) (V, bool),
 ) (V, bool) {
-	value, found, exists := c.Get(key)
-	if exists {
-		return value, found
+	c.mu.Lock()
+	if entry, exists := c.lookup(key); exists {
+		if !entry.RefreshAt.IsZero() && time.Now().After(entry.RefreshAt) {
+			c.stats.stale.Add(1)
+			c.refreshInBackground(ctx, key, fetch)
+		}
+		c.mu.Unlock()
+		return entry.Value, entry.Found
+	}
+	if call, running := c.inFlight[key]; running {
+		c.mu.Unlock()
+		c.stats.coalesced.Add(1)
+		select {
+		case <-call.done:
+			return call.value, call.found
+		case <-ctx.Done():
+			var zero V
+			return zero, false
+		}
 	}
+	call := c.startCall(key)
+	c.mu.Unlock()
 
-	value, found = fetch(ctx, key)
+	c.runCall(ctx, key, call, fetch, false)
+	return call.value, call.found
+}
 
-	if found || c.ttl > 0 {
-		c.Set(key, value, found)
+// GetOrFetchOr is GetOrFetch that, when key is missing and fallback has a live, found entry,
+// returns fallback's value at once and fetches key in the background, as for a stale entry.
+// It lets a caller serve the previous key's value while the next one is fetched.
+func (c *Cache[K, V]) GetOrFetchOr(
+	ctx context.Context,
+	key K,
+	fallback K,
+	fetch func(context.Context, K) (V, bool),
+) (V, bool) {
+	c.mu.Lock()
+	if _, exists := c.peek(key); !exists {
+		if entry, exists := c.peek(fallback); exists && entry.Found {
+			c.stats.stale.Add(1)
+			c.refreshInBackground(ctx, key, fetch)
+			c.mu.Unlock()
+			return entry.Value, true
+		}
 	}
+	c.mu.Unlock()
+	return c.GetOrFetch(ctx, key, fetch)
+}
+
+// refreshInBackground fetches key on its own goroutine unless a fetch is already running. A
+// failed refresh stores nothing. Called with c.mu held for writing.
+func (c *Cache[K, V]) refreshInBackground(ctx context.Context, key K, fetch func(context.Context, K) (V, bool)) {
+	if _, running := c.inFlight[key]; running {
+		return
+	}
+	call := c.startCall(key)
+	go func() {
+		// The caller has its value and may cancel ctx; the refresh outlives it.
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+		c.stats.refreshes.Add(1)
+		c.runCall(refreshCtx, key, call, fetch, true)
+	}()
+}
+
+// refreshTimeout bounds a background refresh, which no caller's ctx does.
+const refreshTimeout = 30 * time.Second
+
+// startCall registers an in-flight fetch for key. Called with c.mu held for writing.
+func (c *Cache[K, V]) startCall(key K) *fetchCall[V] {
+	call := &fetchCall[V]{done: make(chan struct{})}
+	c.inFlight[key] = call
+	return call
+}
+
+// runCall fetches key into call and stores the result. A refresh only replaces the entry
+// with a successful result, so a failure leaves the stale value in place. A result is not
+// stored when its key was evicted while it was fetched.
+func (c *Cache[K, V]) runCall(ctx context.Context, key K, call *fetchCall[V], fetch func(context.Context, K) (V, bool), refresh bool) {
+	// Deferred so waiters are released even if fetch panics.
+	defer func() {
+		c.mu.Lock()
+		delete(c.inFlight, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
 
-	return value, found
+	call.value, call.found = fetch(ctx, key)
+
+	if call.found || (c.ttl > 0 && !refresh) {
+		c.mu.Lock()
+		if !call.invalidated {
+			c.set(key, call.value, call.found)
+		}
+		c.mu.Unlock()
+	}
 }
 
 func (c *Cache[K, V]) Size() int {
@@ -99,13 +288,49 @@ func (c *Cache[K, V]) Size() int {
 func (c *Cache[K, V]) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.items = make(map[K]*CacheEntry[V])
+	c.items = make(map[K]*list.Element)
+	c.recency.Init()
+	for _, call := range c.inFlight {
+		call.invalidated = true
+	}
+	if c.storage != nil {
+		if err := c.storage.Clear(); err != nil {
+			logger.Warn("Failed to clear persistent cache", "error", err)
+		}
+	}
 }
 
+// Evict removes key; a fetch of key already running will not store its result.
 func (c *Cache[K, V]) Evict(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	delete(c.items, key)
+	if element, exists := c.items[key]; exists {
+		c.remove(element)
+	}
+	if call, running := c.inFlight[key]; running {
+		call.invalidated = true
+	}
+}
+
+// EvictFunc removes every entry whose key matches and returns how many were removed. Running
+// fetches of matching keys will not store their results.
+func (c *Cache[K, V]) EvictFunc(match func(K) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := 0
+	for key, element := range c.items {
+		if match(key) {
+			c.remove(element)
+			evicted++
+		}
+	}
+	for key, call := range c.inFlight {
+		if match(key) {
+			call.invalidated = true
+		}
+	}
+	return evicted
 }
 
 func (c *Cache[K, V]) EvictExpired() int {
@@ -119,12 +344,133 @@ func (c *Cache[K, V]) EvictExpired() int {
 	now := time.Now()
 	evicted := 0
 
-	for key, entry := range c.items {
+	for _, element := range c.items {
+		entry := element.Value.(*item[K, V]).entry
 		if !entry.ExpiresAt.IsZero() && now.After(entry.ExpiresAt) {
-			delete(c.items, key)
+			c.remove(element)
 			evicted++
 		}
 	}
 
+	c.stats.expirations.Add(uint64(evicted))
 	return evicted
 }
+
+// StartJanitor calls EvictExpired every interval until ctx ends, so expired entries that are
+// never read again do not accumulate. It does nothing for caches without a TTL.
+func (c *Cache[K, V]) StartJanitor(ctx context.Context, interval time.Duration) {
+	if c.ttl == 0 || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.EvictExpired()
+			}
+		}
+	}()
+}
+
+// Stats returns a snapshot of the cache's size and counters.
+func (c *Cache[K, V]) Stats() Stats {
+	return c.stats.snapshot(c.Size())
+}
+
+// lookup returns key's live entry, counting the hit or miss and marking it most recently
+// used. An expired entry is removed. Called with c.mu held for writing.
+func (c *Cache[K, V]) lookup(key K) (*CacheEntry[V], bool) {
+	element, exists := c.items[key]
+	if !exists {
+		c.stats.misses.Add(1)
+		return nil, false
+	}
+	entry := element.Value.(*item[K, V]).entry
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		c.remove(element)
+		c.stats.expirations.Add(1)
+		c.stats.misses.Add(1)
+		return nil, false
+	}
+	c.recency.MoveToFront(element)
+	c.stats.hits.Add(1)
+	return entry, true
+}
+
+// peek returns key's live entry without counting it or changing its recency. Called with c.mu
+// held.
+func (c *Cache[K, V]) peek(key K) (*CacheEntry[V], bool) {
+	element, exists := c.items[key]
+	if !exists {
+		return nil, false
+	}
+	entry := element.Value.(*item[K, V]).entry
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// insert stores entry as the most recently used one, evicting from the back of the recency
+// list while the cache is over its bound. Called with c.mu held for writing.
+func (c *Cache[K, V]) insert(key K, entry *CacheEntry[V]) {
+	if element, exists := c.items[key]; exists {
+		element.Value.(*item[K, V]).entry = entry
+		c.recency.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.recency.PushFront(&item[K, V]{key: key, entry: entry})
+	for c.maxEntries > 0 && len(c.items) > c.maxEntries {
+		c.remove(c.recency.Back())
+		c.stats.evictions.Add(1)
+	}
+}
+
+// remove drops element from memory and storage. Called with c.mu held for writing.
+func (c *Cache[K, V]) remove(element *list.Element) {
+	key := element.Value.(*item[K, V]).key
+	c.recency.Remove(element)
+	delete(c.items, key)
+	c.persistDelete(key, nil)
+}
+
+// persistPut and persistDelete are called with c.mu held, so storage sees changes in the
+// order the cache applied them.
+func (c *Cache[K, V]) persistPut(key K, entry *CacheEntry[V]) {
+	if c.storage == nil {
+		return
+	}
+	encodedKey, err := json.Marshal(key)
+	if err != nil {
+		logger.Warn("Failed to encode cache key", "error", err)
+		return
+	}
+	encodedEntry, err := json.Marshal(entry)
+	if err != nil {
+		logger.Warn("Failed to encode cache entry", "key", string(encodedKey), "error", err)
+		return
+	}
+	if err := c.storage.Put(encodedKey, encodedEntry); err != nil {
+		logger.Warn("Failed to persist cache entry", "key", string(encodedKey), "error", err)
+	}
+}
+
+// persistDelete removes key from storage; encodedKey may be passed when already known.
+func (c *Cache[K, V]) persistDelete(key K, encodedKey []byte) {
+	if c.storage == nil {
+		return
+	}
+	if encodedKey == nil {
+		var err error
+		if encodedKey, err = json.Marshal(key); err != nil {
+			return
+		}
+	}
+	if err := c.storage.Delete(encodedKey); err != nil {
+		logger.Warn("Failed to delete persistent cache entry", "key", string(encodedKey), "error", err)
+	}
+}
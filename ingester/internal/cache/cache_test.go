@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -182,6 +184,23 @@ func TestCacheEvictSingle(t *testing.T) {
 	}
 }
 
+func TestCacheEvictFunc(t *testing.T) {
+	cache := NewCache[string, int](0)
+
+	cache.Set("price:a:1", 1, true)
+	cache.Set("price:a:2", 2, true)
+	cache.Set("price:b:1", 3, true)
+
+	evicted := cache.EvictFunc(func(key string) bool { return key[:7] == "price:a" })
+
+	if evicted != 2 {
+		t.Errorf("Expected 2 evictions, got %d", evicted)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected 1 remaining entry, got %d", cache.Size())
+	}
+}
+
 func TestCacheConcurrency(t *testing.T) {
 	cache := NewCache[int, int](0)
 
@@ -207,3 +226,245 @@ func TestCacheConcurrency(t *testing.T) {
 		t.Errorf("Expected size 10, got %d", cache.Size())
 	}
 }
+
+func TestCacheGetOrFetchCoalescesConcurrentMisses(t *testing.T) {
+	cache := NewCache[string, int](0)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+
+	fetch := func(ctx context.Context, key string) (int, bool) {
+		fetches.Add(1)
+		<-release
+		return 7, true
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.GetOrFetch(context.Background(), "token", fetch)
+		}()
+	}
+	for cache.Stats().Coalesced < uint64(len(results)-1) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("Expected 1 fetch, got %d", fetches.Load())
+	}
+	for i, result := range results {
+		if result != 7 {
+			t.Errorf("Caller %d got %d, expected 7", i, result)
+		}
+	}
+}
+
+func TestCacheGetOrFetchWaiterHonoursContext(t *testing.T) {
+	cache := NewCache[string, int](0)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+
+	go cache.GetOrFetch(context.Background(), "slow", func(ctx context.Context, key string) (int, bool) {
+		close(started)
+		<-release
+		return 1, true
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, found := cache.GetOrFetch(ctx, "slow", func(context.Context, string) (int, bool) {
+		t.Error("Waiter should not fetch")
+		return 0, false
+	}); found {
+		t.Error("Expected a cancelled waiter to get found=false")
+	}
+}
+
+func TestCacheMaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := NewCacheWith[string, int](0, Options{MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("NewCacheWith failed: %v", err)
+	}
+
+	cache.Set("a", 1, true)
+	cache.Set("b", 2, true)
+	cache.Get("a") // b is now least recently used
+	cache.Set("c", 3, true)
+
+	if _, _, exists := cache.Get("b"); exists {
+		t.Error("Expected b to be evicted")
+	}
+	if _, _, exists := cache.Get("a"); !exists {
+		t.Error("Expected recently read a to stay")
+	}
+	if stats := cache.Stats(); stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected size 2 and 1 eviction, got %+v", stats)
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	cache := NewCache[string, int](30 * time.Millisecond)
+
+	cache.Set("a", 1, true)
+	cache.Get("a")
+	cache.Get("missing")
+	time.Sleep(40 * time.Millisecond)
+	cache.Get("a")
+
+	want := Stats{Size: 0, Hits: 1, Misses: 2, Expirations: 1}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+}
+
+func TestCacheJanitorEvictsExpired(t *testing.T) {
+	cache := NewCache[string, int](10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.StartJanitor(ctx, 5*time.Millisecond)
+
+	cache.Set("a", 1, true)
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Size() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected the janitor to drop the expired entry, size is %d", cache.Size())
+	}
+}
+
+func TestCacheSoftTTLServesStaleAndRefreshes(t *testing.T) {
+	cache, err := NewCacheWith[string, int](time.Hour, Options{SoftTTL: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCacheWith failed: %v", err)
+	}
+	cache.Set("price", 1, true)
+	time.Sleep(30 * time.Millisecond)
+
+	refreshed := make(chan struct{})
+	value, found := cache.GetOrFetch(context.Background(), "price", func(context.Context, string) (int, bool) {
+		defer close(refreshed)
+		return 2, true
+	})
+	if !found || value != 1 {
+		t.Errorf("Expected the stale value 1 at once, got %d (found=%v)", value, found)
+	}
+
+	<-refreshed
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, _, _ = cache.Get("price")
+		if value == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if value != 2 {
+		t.Errorf("Expected the refreshed value 2, got %d", value)
+	}
+	if stats := cache.Stats(); stats.Stale != 1 || stats.Refreshes != 1 {
+		t.Errorf("Expected 1 stale hit and 1 refresh, got %+v", stats)
+	}
+}
+
+func TestCacheSoftTTLKeepsStaleValueWhenRefreshFails(t *testing.T) {
+	cache, err := NewCacheWith[string, int](50*time.Millisecond, Options{SoftTTL: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCacheWith failed: %v", err)
+	}
+	cache.Set("price", 1, true)
+	time.Sleep(20 * time.Millisecond)
+
+	failed := make(chan struct{})
+	cache.GetOrFetch(context.Background(), "price", func(context.Context, string) (int, bool) {
+		defer close(failed)
+		return 0, false
+	})
+	<-failed
+	for cache.Stats().Refreshes == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if value, found, exists := cache.Get("price"); !exists || !found || value != 1 {
+		t.Errorf("Expected the stale value to survive a failed refresh, got %d (found=%v exists=%v)", value, found, exists)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, _, exists := cache.Get("price"); exists {
+		t.Error("Expected the stale value to expire at the hard TTL")
+	}
+}
+
+func TestCacheSoftTTLRefreshDoesNotOutliveEviction(t *testing.T) {
+	cache, err := NewCacheWith[string, int](time.Hour, Options{SoftTTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewCacheWith failed: %v", err)
+	}
+	cache.Set("price", 1, true)
+	time.Sleep(5 * time.Millisecond)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	cache.GetOrFetch(context.Background(), "price", func(context.Context, string) (int, bool) {
+		close(started)
+		<-release
+		return 2, true
+	})
+	<-started
+	cache.EvictFunc(func(string) bool { return true })
+	close(release)
+
+	running := func() bool {
+		cache.mu.RLock()
+		defer cache.mu.RUnlock()
+		return len(cache.inFlight) > 0
+	}
+	deadline := time.Now().Add(time.Second)
+	for running() {
+		if time.Now().After(deadline) {
+			t.Fatal("Refresh did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if value, _, exists := cache.Get("price"); exists {
+		t.Errorf("Expected the refresh started before the eviction not to be stored, got %d", value)
+	}
+}
+
+func TestCacheEvictionOnlyDropsInFlightFetchesOfEvictedKeys(t *testing.T) {
+	cache := NewCache[string, int](time.Hour)
+	started := map[string]chan struct{}{"a": make(chan struct{}), "b": make(chan struct{})}
+	release := make(chan struct{})
+	fetch := func(_ context.Context, key string) (int, bool) {
+		close(started[key])
+		<-release
+		return 1, true
+	}
+
+	var wg sync.WaitGroup
+	for key := range started {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.GetOrFetch(context.Background(), key, fetch)
+		}()
+	}
+	<-started["a"]
+	<-started["b"]
+	cache.Evict("a")
+	close(release)
+	wg.Wait()
+
+	if _, _, exists := cache.Get("a"); exists {
+		t.Error("Expected the fetch of the evicted key not to be stored")
+	}
+	if value, found, exists := cache.Get("b"); !exists || !found || value != 1 {
+		t.Errorf("Expected the fetch of another key to be stored despite the eviction, got %d (found=%v exists=%v)", value, found, exists)
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats counts a cache's activity since it was created.
+type Stats struct {
+	Size int `json:"size"`
+	// Hits and Misses count lookups through Get and GetOrFetch; an expired entry is a miss.
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Coalesced counts GetOrFetch calls that waited for another caller's fetch.
+	Coalesced uint64 `json:"coalesced"`
+	// Stale counts hits served past the soft TTL; Refreshes the background refreshes started.
+	Stale     uint64 `json:"stale"`
+	Refreshes uint64 `json:"refreshes"`
+	// Evictions counts entries dropped to stay within MaxEntries; Expirations those dropped
+	// for their TTL. Explicit Evict, EvictFunc and Clear calls are not counted.
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+}
+
+type counters struct {
+	hits, misses, coalesced, stale, refreshes, evictions, expirations atomic.Uint64
+}
+
+func (c *counters) snapshot(size int) Stats {
+	return Stats{
+		Size:        size,
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Coalesced:   c.coalesced.Load(),
+		Stale:       c.stale.Load(),
+		Refreshes:   c.refreshes.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+var (
+	publishedMu sync.Mutex
+	published   = make(map[string]func() Stats)
+)
+
+func init() {
+	expvar.Publish("caches", expvar.Func(func() any {
+		publishedMu.Lock()
+		defer publishedMu.Unlock()
+
+		snapshot := make(map[string]Stats, len(published))
+		for name, stats := range published {
+			snapshot[name] = stats()
+		}
+		return snapshot
+	}))
+}
+
+// publish exposes stats under name, replacing a cache published earlier under the same name.
+func publish(name string, stats func() Stats) {
+	publishedMu.Lock()
+	defer publishedMu.Unlock()
+	published[name] = stats
+}
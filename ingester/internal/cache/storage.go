@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	apperr "ingester/internal/errors"
+)
+
+// Storage persists cache entries as encoded key/entry pairs. The Cache writes through to it
+// and loads it once at construction.
+type Storage interface {
+	// Load returns every stored entry keyed by its encoded key.
+	Load() (map[string][]byte, error)
+	Put(key, entry []byte) error
+	Delete(key []byte) error
+	Clear() error
+	Close() error
+}
+
+// compactAfter is how many superseded records a FileStorage tolerates before rewriting its
+// file; below it, compaction would cost more than the space it frees.
+const compactAfter = 1024
+
+// FileStorage is an append-only log of JSON lines. Every write appends one record; the file
+// is rewritten with only the live entries at open and whenever superseded records outnumber
+// live ones. A torn last line, left by a crash mid-write, is ignored.
+type FileStorage struct {
+	path    string
+	sync    bool
+	mu      sync.Mutex
+	file    *os.File
+	live    map[string][]byte
+	records int
+}
+
+type storageRecord struct {
+	Key     json.RawMessage `json:"k,omitempty"`
+	Entry   json.RawMessage `json:"e,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+	Cleared bool            `json:"c,omitempty"`
+}
+
+// FileOptions tunes a FileStorage.
+type FileOptions struct {
+	// Sync fsyncs every record before the write returns, so an acknowledged write survives a
+	// crash or power loss. Write-ahead logs need it; caches that can be refetched do not.
+	Sync bool
+}
+
+// OpenFileStorage replays path, creating it and its directory when missing. Records are not
+// synced; see OpenFileStorageWith.
+func OpenFileStorage(path string) (*FileStorage, error) {
+	return OpenFileStorageWith(path, FileOptions{})
+}
+
+// OpenFileStorageWith is OpenFileStorage with options.
+func OpenFileStorageWith(path string, options FileOptions) (*FileStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("create cache directory for %s", path), Cause: err}
+	}
+
+	storage := &FileStorage{path: path, sync: options.Sync, live: make(map[string][]byte)}
+	if err := storage.replay(); err != nil {
+		return nil, err
+	}
+	if err := storage.compact(); err != nil {
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("compact cache file %s", path), Cause: err}
+	}
+	return storage, nil
+}
+
+func (s *FileStorage) replay() error {
+	content, err := os.ReadFile(s.path) // #nosec G304 -- operator-supplied cache path
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return &apperr.ConfigError{Message: fmt.Sprintf("read cache file %s", s.path), Cause: err}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record storageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			logger.Warn("Skipping unreadable cache record", "file", s.path, "error", err)
+			continue
+		}
+		switch {
+		case record.Cleared:
+			s.live = make(map[string][]byte)
+		case record.Deleted:
+			delete(s.live, string(record.Key))
+		default:
+			s.live[string(record.Key)] = record.Entry
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *FileStorage) Load() (map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make(map[string][]byte, len(s.live))
+	for key, entry := range s.live {
+		entries[key] = entry
+	}
+	return entries, nil
+}
+
+func (s *FileStorage) Put(key, entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.live[string(key)] = entry
+	return s.append(storageRecord{Key: key, Entry: entry})
+}
+
+func (s *FileStorage) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.live[string(key)]; !exists {
+		return nil
+	}
+	delete(s.live, string(key))
+	return s.append(storageRecord{Key: key, Deleted: true})
+}
+
+func (s *FileStorage) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.live = make(map[string][]byte)
+	return s.compact()
+}
+
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// append writes one record, compacting first when the log has grown well past the live set.
+func (s *FileStorage) append(record storageRecord) error {
+	if s.records-len(s.live) > max(compactAfter, len(s.live)) {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		return fmt.Errorf("cache file %s is closed", s.path)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append to cache file %s: %w", s.path, err)
+	}
+	if s.sync {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("sync cache file %s: %w", s.path, err)
+		}
+	}
+	s.records++
+	return nil
+}
+
+// compact rewrites the file with the live entries through a temporary file and a rename, so
+// a crash leaves either the old or the new log, and reopens it for appending. The temporary
+// file is synced before the rename and the directory after it, so the rename cannot land
+// before the data it points to.
+func (s *FileStorage) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for key, entry := range s.live {
+		line, err := json.Marshal(storageRecord{Key: json.RawMessage(key), Entry: entry})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600) // #nosec G304 -- operator-supplied cache path
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.records = len(s.live)
+	return nil
+}
+
+// syncDir makes a rename in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 -- operator-supplied cache path
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
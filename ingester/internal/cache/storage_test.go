@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openStorage(t *testing.T, path string) *FileStorage {
+	t.Helper()
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestPersistentCache_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "symbols.cache")
+
+	first, err := NewPersistentCache[string, string](0, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("NewPersistentCache failed: %v", err)
+	}
+	first.Set("0xa", "USDC", true)
+	first.Set("0xb", "WETH", true)
+	first.Set("0xc", "", false)
+	first.Evict("0xb")
+
+	second, err := NewPersistentCache[string, string](0, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if value, found, exists := second.Get("0xa"); !exists || !found || value != "USDC" {
+		t.Errorf("Expected persisted USDC, got %q (found=%v exists=%v)", value, found, exists)
+	}
+	if _, _, exists := second.Get("0xb"); exists {
+		t.Error("Evicted entry should not come back")
+	}
+	if _, found, exists := second.Get("0xc"); !exists || found {
+		t.Errorf("Expected persisted negative lookup, got found=%v exists=%v", found, exists)
+	}
+}
+
+func TestPersistentCache_KeepsTTLAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.cache")
+
+	first, err := NewPersistentCache[string, float64](50*time.Millisecond, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("NewPersistentCache failed: %v", err)
+	}
+	first.Set("short", 1.5, true)
+
+	time.Sleep(60 * time.Millisecond)
+
+	second, err := NewPersistentCache[string, float64](time.Hour, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if second.Size() != 0 {
+		t.Errorf("Entries expired while stopped should not be loaded, got %d", second.Size())
+	}
+
+	calls := 0
+	value, _ := second.GetOrFetch(context.Background(), "short", func(ctx context.Context, key string) (float64, bool) {
+		calls++
+		return 2.5, true
+	})
+	if calls != 1 || value != 2.5 {
+		t.Errorf("Expected one fetch returning 2.5, got %d calls and %f", calls, value)
+	}
+}
+
+func TestPersistentCache_StructKeys(t *testing.T) {
+	type key struct {
+		Token  string
+		Bucket uint64
+	}
+	path := filepath.Join(t.TempDir(), "buckets.cache")
+
+	first, err := NewPersistentCache[key, int](0, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("NewPersistentCache failed: %v", err)
+	}
+	first.Set(key{"0xa", 1}, 10, true)
+	first.Set(key{"0xa", 2}, 20, true)
+	first.EvictFunc(func(k key) bool { return k.Bucket == 1 })
+
+	second, err := NewPersistentCache[key, int](0, openStorage(t, path))
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if _, _, exists := second.Get(key{"0xa", 1}); exists {
+		t.Error("EvictFunc should be persisted")
+	}
+	if value, _, _ := second.Get(key{"0xa", 2}); value != 20 {
+		t.Errorf("Expected 20, got %d", value)
+	}
+}
+
+func TestFileStorage_IgnoresTornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "torn.cache")
+	storage := openStorage(t, path)
+	if err := storage.Put([]byte(`"a"`), []byte(`{"Value":1,"Found":true}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	storage.Close()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	file.WriteString(`{"k":"b","e":{"Val`)
+	file.Close()
+
+	entries, err := openStorage(t, path).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 1 || entries[`"a"`] == nil {
+		t.Errorf("Expected only the complete record, got %v", entries)
+	}
+}
+
+func TestFileStorage_CompactsSupersededRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compact.cache")
+	storage := openStorage(t, path)
+
+	for i := 0; i < 3*compactAfter; i++ {
+		if err := storage.Put([]byte(`"hot"`), []byte(`{"Value":1,"Found":true}`)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines > compactAfter+2 {
+		t.Errorf("Expected the log to be compacted, found %d lines", lines)
+	}
+}
+
+func TestFileStorage_SyncedRecordsAreReadBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	storage, err := OpenFileStorageWith(path, FileOptions{Sync: true})
+	if err != nil {
+		t.Fatalf("OpenFileStorageWith failed: %v", err)
+	}
+	if err := storage.Put([]byte(`"a"`), []byte(`1`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := storage.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if err := storage.Put([]byte(`"b"`), []byte(`2`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	storage.Close()
+
+	entries, err := openStorage(t, path).Load()
+	if err != nil || len(entries) != 1 || string(entries[`"b"`]) != "2" {
+		t.Errorf("Expected only b after reopening, got %v (err=%v)", entries, err)
+	}
+}
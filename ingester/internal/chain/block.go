@@ -0,0 +1,32 @@
+package chain
+
+import (
+	"math/big"
+	"time"
+)
+
+// Block pins a read to the chain state at one block. The zero value means latest.
+type Block struct {
+	Number uint64
+	Time   time.Time
+}
+
+// Latest asks for the current state.
+var Latest = Block{}
+
+// BigNumber is the block argument for eth_call; nil means latest.
+func (b Block) BigNumber() *big.Int {
+	if b.Number == 0 {
+		return nil
+	}
+	return new(big.Int).SetUint64(b.Number)
+}
+
+// Now is the reference time for staleness checks: the block time for historical reads,
+// the wall clock otherwise.
+func (b Block) Now() time.Time {
+	if b.Time.IsZero() {
+		return time.Now()
+	}
+	return b.Time
+}
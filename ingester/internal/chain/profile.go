@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3 is deployed at the same address on every supported chain.
+var Multicall3 = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// Profile holds the per-chain defaults the ingester needs. Stablecoins and Chainlink feeds
+// live in the oracle feed registry, which is keyed by the same chain ID.
+type Profile struct {
+	ID                    uint64
+	Name                  string
+	FinalityConfirmations uint64
+	BlockTime             time.Duration
+	Multicall             common.Address
+	WrappedNative         common.Address
+}
+
+// genericConfirmations is used for chains without a profile; it matches the Polygon depth,
+// the most conservative of the built-in profiles.
+const genericConfirmations uint64 = 64
+
+var profiles = map[uint64]Profile{
+	1: {
+		ID:                    1,
+		Name:                  "ethereum",
+		FinalityConfirmations: 64, // two epochs, when Casper FFG finalizes
+		BlockTime:             12 * time.Second,
+		Multicall:             Multicall3,
+		WrappedNative:         common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+	},
+	137: {
+		ID:                    137,
+		Name:                  "polygon",
+		FinalityConfirmations: 64,
+		BlockTime:             2 * time.Second,
+		Multicall:             Multicall3,
+		WrappedNative:         common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"),
+	},
+	8453: {
+		ID:                    8453,
+		Name:                  "base",
+		FinalityConfirmations: 10, // sequencer reorgs are rare and shallow
+		BlockTime:             2 * time.Second,
+		Multicall:             Multicall3,
+		WrappedNative:         common.HexToAddress("0x4200000000000000000000000000000000000006"),
+	},
+	42161: {
+		ID:                    42161,
+		Name:                  "arbitrum",
+		FinalityConfirmations: 20,
+		BlockTime:             250 * time.Millisecond,
+		Multicall:             Multicall3,
+		WrappedNative:         common.HexToAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1"),
+	},
+}
+
+// Lookup returns the built-in profile for chainID.
+func Lookup(chainID uint64) (Profile, bool) {
+	profile, ok := profiles[chainID]
+	return profile, ok
+}
+
+// ProfileFor returns the built-in profile for chainID, or a conservative generic one
+// (no Multicall or wrapped native token) for chains the ingester does not know.
+func ProfileFor(chainID uint64) Profile {
+	if profile, ok := profiles[chainID]; ok {
+		return profile
+	}
+	return Profile{
+		ID:                    chainID,
+		Name:                  "chain-" + strconv.FormatUint(chainID, 10),
+		FinalityConfirmations: genericConfirmations,
+		BlockTime:             12 * time.Second,
+	}
+}
+
+// BlocksPer returns how many blocks the chain produces in d, at least 1.
+func (p Profile) BlocksPer(d time.Duration) uint64 {
+	if p.BlockTime <= 0 || d < p.BlockTime {
+		return 1
+	}
+	return uint64(d / p.BlockTime)
+}
+
+// CAIP2 returns the CAIP-2 chain identifier, e.g. "eip155:137".
+func (p Profile) CAIP2() string {
+	return "eip155:" + strconv.FormatUint(p.ID, 10)
+}
@@ -0,0 +1,75 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLookup_BuiltInProfiles(t *testing.T) {
+	tests := []struct {
+		chainID       uint64
+		name          string
+		confirmations uint64
+	}{
+		{1, "ethereum", 64},
+		{137, "polygon", 64},
+		{8453, "base", 10},
+		{42161, "arbitrum", 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, ok := Lookup(tt.chainID)
+			if !ok {
+				t.Fatalf("Expected built-in profile for chain %d", tt.chainID)
+			}
+			if profile.Name != tt.name || profile.FinalityConfirmations != tt.confirmations {
+				t.Errorf("Unexpected profile %+v", profile)
+			}
+			if profile.Multicall != Multicall3 {
+				t.Errorf("Expected Multicall3, got %s", profile.Multicall.Hex())
+			}
+		})
+	}
+}
+
+func TestProfileFor_UnknownChain(t *testing.T) {
+	profile := ProfileFor(80002)
+
+	if profile.ID != 80002 || profile.Name != "chain-80002" {
+		t.Errorf("Unexpected generic profile %+v", profile)
+	}
+	if profile.FinalityConfirmations != genericConfirmations {
+		t.Errorf("Expected generic confirmations %d, got %d", genericConfirmations, profile.FinalityConfirmations)
+	}
+	if profile.CAIP2() != "eip155:80002" {
+		t.Errorf("Unexpected CAIP-2 id %q", profile.CAIP2())
+	}
+}
+
+func TestProfile_BlocksPer(t *testing.T) {
+	polygon, _ := Lookup(137)
+	if blocks := polygon.BlocksPer(time.Minute); blocks != 30 {
+		t.Errorf("Expected 30 Polygon blocks per minute, got %d", blocks)
+	}
+	if blocks := polygon.BlocksPer(time.Second); blocks != 1 {
+		t.Errorf("Expected at least 1 block, got %d", blocks)
+	}
+}
+
+func TestProfile_RouterAt(t *testing.T) {
+	polygon := ProfileFor(137)
+	quickSwap := common.HexToAddress("0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff")
+	if router, ok := polygon.RouterAt(quickSwap); !ok || router.Name != "QuickSwap Router" || router.Aggregator {
+		t.Errorf("Unexpected router %+v (found %v)", router, ok)
+	}
+	if _, ok := ProfileFor(1).RouterAt(quickSwap); ok {
+		t.Error("Expected the QuickSwap router to be unknown on Ethereum")
+	}
+	oneInch := common.HexToAddress("0x1111111254EEB25477B68fb85Ed929f73A960582")
+	if router, ok := ProfileFor(80002).RouterAt(oneInch); !ok || !router.Aggregator {
+		t.Errorf("Expected 1inch to be an aggregator on every chain, got %+v (found %v)", router, ok)
+	}
+}
@@ -0,0 +1,45 @@
+package chain
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Router is a known contract that swaps on behalf of traders. Aggregators split or route
+// orders across venues; plain routers only go through their own DEX's pairs.
+type Router struct {
+	Name       string
+	Aggregator bool
+}
+
+// sharedRouters are deployed at the same address on every supported chain.
+var sharedRouters = map[common.Address]Router{
+	common.HexToAddress("0x1111111254EEB25477B68fb85Ed929f73A960582"): {Name: "1inch v5", Aggregator: true},
+	common.HexToAddress("0x111111125421cA6dc452d289314280a0f8842A65"): {Name: "1inch v6", Aggregator: true},
+	common.HexToAddress("0xDEF171Fe48CF0115B1d80b88dc8eAB59176FEe57"): {Name: "ParaSwap v5", Aggregator: true},
+	common.HexToAddress("0xDef1C0ded9bec7F1a1670819833240f027b25EfF"): {Name: "0x Exchange Proxy", Aggregator: true},
+}
+
+var chainRouters = map[uint64]map[common.Address]Router{
+	1: {
+		common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"): {Name: "Uniswap V2 Router"},
+		common.HexToAddress("0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD"): {Name: "Uniswap Universal Router"},
+		common.HexToAddress("0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F"): {Name: "SushiSwap Router"},
+	},
+	137: {
+		common.HexToAddress("0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff"): {Name: "QuickSwap Router"},
+		common.HexToAddress("0x1b02dA8Cb0d097eB8D57A175b88c7D8b47997506"): {Name: "SushiSwap Router"},
+	},
+	8453: {
+		common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"): {Name: "Uniswap V2 Router"},
+	},
+	42161: {
+		common.HexToAddress("0x1b02dA8Cb0d097eB8D57A175b88c7D8b47997506"): {Name: "SushiSwap Router"},
+	},
+}
+
+// RouterAt identifies the router or aggregator deployed at address on the profile's chain.
+func (p Profile) RouterAt(address common.Address) (Router, bool) {
+	if router, ok := chainRouters[p.ID][address]; ok {
+		return router, true
+	}
+	router, ok := sharedRouters[address]
+	return router, ok
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	apperr "ingester/internal/errors"
+)
+
+// ConfigFileEnv names the environment variable that points at an optional config file.
+// The --config flag takes precedence over it.
+const ConfigFileEnv = "INGESTER_CONFIG"
+
+// legacyRPCURLEnv is read when neither RPC_URL nor the config file's rpcUrl is set, for
+// deployments predating multi-chain support.
+const legacyRPCURLEnv = "POLYGON_RPC_URL"
+
+// Config is the fully resolved ingester configuration.
+// Precedence (lowest to highest): defaults -> config file -> environment -> flags.
+//
+// Pairs and the contents of the FeedRegistry file can be changed at runtime (see Watch); every
+// other field needs a restart.
+type Config struct {
+	RPCURL                string        `yaml:"rpcUrl"`
+	Pairs                 []string      `yaml:"pairs"`
+	AppPort               string        `yaml:"appPort"`
+	FinalityConfirmations *uint64       `yaml:"finalityConfirmations,omitempty"`
+	Dapr                  DaprConfig    `yaml:"dapr"`
+	Topics                TopicConfig   `yaml:"topics"`
+	ChainID               uint64        `yaml:"chainId"`
+	FeedRegistry          string        `yaml:"feedRegistry,omitempty"`
+	StrictFeedValidation  bool          `yaml:"strictFeedValidation"`
+	Oracle                OracleConfig  `yaml:"oracle"`
+	TokenMetadata         TokenConfig   `yaml:"tokenMetadata"`
+	CacheDir              string        `yaml:"cacheDir,omitempty"`
+	OutboxFile            string        `yaml:"outboxFile,omitempty"`
+	ShutdownTimeout       time.Duration `yaml:"shutdownTimeout"`
+	PricePrecision        int           `yaml:"pricePrecision"` // significant digits of a swap's priceExact
+
+	// File is the config file that was loaded, if any.
+	File string `yaml:"-"`
+}
+
+type DaprConfig struct {
+	Host       string `yaml:"host"`
+	HTTPPort   string `yaml:"httpPort"`
+	PubSubName string `yaml:"pubsubName"`
+}
+
+// OracleConfig selects how USD price sources are combined.
+type OracleConfig struct {
+	Mode       string        `yaml:"mode,omitempty"`      // priority (default) or consensus
+	MaxSpread  float64       `yaml:"maxSpread,omitempty"` // consensus outlier threshold, fraction of the median
+	PriceFile  string        `yaml:"priceFile,omitempty"` // optional offline CSV price source
+	TWAPWindow time.Duration `yaml:"twapWindow"`          // reference-pool TWAP window; 0 disables
+}
+
+// DefaultTWAPWindow is the TWAP window used when none is configured.
+const DefaultTWAPWindow = 30 * time.Minute
+
+// DefaultShutdownTimeout bounds a graceful shutdown when none is configured.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultPricePrecision is the significant digits of a swap's exact price when none is configured.
+const DefaultPricePrecision = 30
+
+// MaxPricePrecision is as many digits as a uint256 amount has.
+const MaxPricePrecision = 78
+
+// TokenConfig configures token name/symbol/decimals resolution.
+type TokenConfig struct {
+	File string `yaml:"file,omitempty"` // optional per-chain overrides
+}
+
+type TopicConfig struct {
+	TradingEvents   string `yaml:"tradingEvents"`
+	LiquidityEvents string `yaml:"liquidityEvents"`
+	MevEvents       string `yaml:"mevEvents,omitempty"` // MEV candidates; detection is off when empty
+}
+
+// LookupEnv matches os.LookupEnv so tests can supply a fake environment.
+type LookupEnv func(key string) (string, bool)
+
+// setting binds one Config field to its environment variable and command-line flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"RPC_URL", "rpc-url", "WebSocket RPC endpoint (ws:// or wss://)", setString(func(c *Config) *string { return &c.RPCURL })},
+	{"PAIR_ADDRESS", "pair-address", "comma-separated Uniswap V2 pair contracts to monitor", setPairs},
+	{"APP_PORT", "app-port", "health server port", setString(func(c *Config) *string { return &c.AppPort })},
+	{"FINALITY_CONFIRMATIONS", "finality-confirmations", "block confirmations before publishing (0 disables buffering; default from chain profile)", setFinalityConfirmations},
+	{"CHAIN_ID", "chain-id", "expected chain ID; startup fails if eth_chainId differs (default: detect)", setChainID},
+	{"FEED_REGISTRY_FILE", "feed-registry", "JSON/YAML file overriding the embedded Chainlink feed registry", setString(func(c *Config) *string { return &c.FeedRegistry })},
+	{"STRICT_FEED_VALIDATION", "strict-feed-validation", "fail startup when a feed's description() does not match the registry", setStrictFeedValidation},
+	{"ORACLE_MODE", "oracle-mode", "how price sources are combined: priority or consensus (default priority)", setString(func(c *Config) *string { return &c.Oracle.Mode })},
+	{"ORACLE_MAX_SPREAD", "oracle-max-spread", "consensus mode: largest accepted distance from the median, as a fraction (default 0.05)", setOracleMaxSpread},
+	{"PRICE_FILE", "price-file", "offline CSV of token,timestamp,price used as an extra price source", setString(func(c *Config) *string { return &c.Oracle.PriceFile })},
+	{"TWAP_WINDOW", "twap-window", "window of the reference-pool TWAP price source and swap TWAP deviation (0 disables; default 30m)", setDuration(func(c *Config) *time.Duration { return &c.Oracle.TWAPWindow })},
+	{"TOKEN_METADATA_FILE", "token-metadata", "JSON/YAML file overriding on-chain token symbol, name and decimals", setString(func(c *Config) *string { return &c.TokenMetadata.File })},
+	{"CACHE_DIR", "cache-dir", "directory persisting token metadata and prices across restarts (default: memory only)", setString(func(c *Config) *string { return &c.CacheDir })},
+	{"OUTBOX_FILE", "outbox-file", "write-ahead log of unpublished events, replayed at startup (default: memory only)", setString(func(c *Config) *string { return &c.OutboxFile })},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long shutdown may take to finish received events and outstanding publishes (default 30s)", setDuration(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"PRICE_PRECISION", "price-precision", "significant digits of the exact decimal swap price (default 30)", setPricePrecision},
+	{"DAPR_HOST", "dapr-host", "Dapr sidecar host", setString(func(c *Config) *string { return &c.Dapr.Host })},
+	{"DAPR_HTTP_PORT", "dapr-http-port", "Dapr sidecar HTTP port", setString(func(c *Config) *string { return &c.Dapr.HTTPPort })},
+	{"PUBSUB_NAME", "pubsub-name", "Dapr pub/sub component name", setString(func(c *Config) *string { return &c.Dapr.PubSubName })},
+	{"TOPIC_TRADING_EVENTS", "topic-trading-events", "topic for Swap events", setString(func(c *Config) *string { return &c.Topics.TradingEvents })},
+	{"TOPIC_LIQUIDITY_EVENTS", "topic-liquidity-events", "topic for Mint/Burn/Transfer events", setString(func(c *Config) *string { return &c.Topics.LiquidityEvents })},
+	{"TOPIC_MEV_EVENTS", "topic-mev-events", "topic for MEV candidates detected in confirmed blocks (default: detection off)", setString(func(c *Config) *string { return &c.Topics.MevEvents })},
+}
+
+// Default returns the configuration used before any file, env or flag is applied.
+func Default() Config {
+	return Config{
+		Oracle:          OracleConfig{TWAPWindow: DefaultTWAPWindow},
+		ShutdownTimeout: DefaultShutdownTimeout,
+		PricePrecision:  DefaultPricePrecision,
+	}
+}
+
+// Load resolves the configuration from args (without the program name) and the environment,
+// then validates it. The returned Config is populated even when validation fails so callers
+// can still display it; the error joins every *errors.ConfigError found.
+func Load(args []string, lookupEnv LookupEnv) (Config, error) {
+	cfg := Default()
+
+	flagSet := flag.NewFlagSet("ingester", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	configPath := flagSet.String("config", "", "path to YAML or TOML config file (env: "+ConfigFileEnv+")")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.flag] = flagSet.String(s.flag, "", s.usage+" (env: "+s.env+")")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return cfg, &apperr.ConfigError{Message: "invalid command-line flags", Cause: err}
+	}
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv(ConfigFileEnv)
+	}
+	if path = strings.TrimSpace(path); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+		cfg.File = path
+	}
+
+	var errs []error
+	if legacy, _ := lookupEnv(legacyRPCURLEnv); strings.TrimSpace(legacy) != "" {
+		if value, _ := lookupEnv("RPC_URL"); strings.TrimSpace(value) == "" && cfg.RPCURL == "" {
+			logger.Warn(legacyRPCURLEnv + " is deprecated, use RPC_URL")
+			cfg.RPCURL = strings.TrimSpace(legacy)
+		} else {
+			logger.Warn(legacyRPCURLEnv + " is deprecated and ignored because RPC_URL or rpcUrl is set")
+		}
+	}
+	for _, s := range settings {
+		value, ok := lookupEnv(s.env)
+		if value = strings.TrimSpace(value); !ok || value == "" {
+			continue
+		}
+		if err := s.apply(&cfg, value); err != nil {
+			errs = append(errs, &apperr.ConfigError{Message: s.env, Cause: err})
+		}
+	}
+
+	setFlags := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	for _, s := range settings {
+		if !setFlags[s.flag] {
+			continue
+		}
+		if err := s.apply(&cfg, strings.TrimSpace(*flagValues[s.flag])); err != nil {
+			errs = append(errs, &apperr.ConfigError{Message: "--" + s.flag, Cause: err})
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// Usage writes the flag reference, including the environment variable behind each flag.
+func Usage(output io.Writer) {
+	fmt.Fprintf(output, "Usage: ingester [flags]\n       ingester config print [flags]\n\nFlags:\n")
+	fmt.Fprintf(output, "  --config string\n\tpath to YAML or TOML config file (env: %s)\n", ConfigFileEnv)
+	for _, s := range settings {
+		fmt.Fprintf(output, "  --%s string\n\t%s (env: %s)\n", s.flag, s.usage, s.env)
+	}
+}
+
+// Redacted returns a copy safe to print: RPC URL paths and credentials often carry API keys.
+func (c Config) Redacted() Config {
+	redacted := c
+	redacted.RPCURL = redactURL(c.RPCURL)
+	return redacted
+}
+
+// WriteYAML renders the redacted configuration in the same shape the config file accepts.
+func (c Config) WriteYAML(output io.Writer) error {
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return encoder.Close()
+}
+
+// loadFile reads a YAML (.yaml, .yml or no extension) or TOML (.toml) config file; other
+// extensions are rejected rather than misparsed. A TOML document is converted to YAML and
+// decoded like one, so both formats take the same keys, reject the same unknown ones and
+// accept durations such as "30s".
+func loadFile(path string, cfg *Config) error {
+	extension := strings.ToLower(filepath.Ext(path))
+	switch extension {
+	case ".yaml", ".yml", "", ".toml":
+	default:
+		return &apperr.ConfigError{Message: fmt.Sprintf("config file %s: unsupported format, use YAML (.yaml, .yml) or TOML (.toml)", path)}
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return &apperr.ConfigError{Message: fmt.Sprintf("read config file %s", path), Cause: err}
+	}
+	if extension == ".toml" {
+		if content, err = tomlToYAML(content); err != nil {
+			return &apperr.ConfigError{Message: fmt.Sprintf("parse config file %s", path), Cause: err}
+		}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return &apperr.ConfigError{Message: fmt.Sprintf("parse config file %s", path), Cause: err}
+	}
+	return nil
+}
+
+func tomlToYAML(content []byte) ([]byte, error) {
+	var document map[string]any
+	if err := toml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(document)
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func setPairs(cfg *Config, value string) error {
+	cfg.Pairs = nil
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair != "" {
+			cfg.Pairs = append(cfg.Pairs, pair)
+		}
+	}
+	return nil
+}
+
+func setFinalityConfirmations(cfg *Config, value string) error {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be a non-negative integer, got %q", value)
+	}
+	cfg.FinalityConfirmations = &n
+	return nil
+}
+
+func setChainID(cfg *Config, value string) error {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be a non-negative integer, got %q", value)
+	}
+	cfg.ChainID = n
+	return nil
+}
+
+func setStrictFeedValidation(cfg *Config, value string) error {
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("must be true or false, got %q", value)
+	}
+	cfg.StrictFeedValidation = strict
+	return nil
+}
+
+func setOracleMaxSpread(cfg *Config, value string) error {
+	spread, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("must be a number, got %q", value)
+	}
+	cfg.Oracle.MaxSpread = spread
+	return nil
+}
+
+func setPricePrecision(cfg *Config, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be an integer, got %q", value)
+	}
+	cfg.PricePrecision = n
+	return nil
+}
+
+func setDuration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30m, got %q", value)
+		}
+		*field(cfg) = duration
+		return nil
+	}
+}
+
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		if raw == "" {
+			return ""
+		}
+		return "<redacted>"
+	}
+	if parsed.Path == "" && parsed.RawQuery == "" && parsed.User == nil {
+		return raw
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/<redacted>"
+}
@@ -0,0 +1,507 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apperr "ingester/internal/errors"
+)
+
+func validEnv() map[string]string {
+	return map[string]string{
+		"RPC_URL":                "wss://polygon-mainnet.example.com/v2/secret-key",
+		"PAIR_ADDRESS":           "0x6e7a5FAFcec6BB1e78bAE2A1F0B612012BF14827",
+		"APP_PORT":               "3000",
+		"DAPR_HOST":              "localhost",
+		"DAPR_HTTP_PORT":         "3500",
+		"PUBSUB_NAME":            "kafka-pubsub",
+		"TOPIC_TRADING_EVENTS":   "dex-trading-events",
+		"TOPIC_LIQUIDITY_EVENTS": "dex-liquidity-events",
+	}
+}
+
+func lookupFrom(env map[string]string) LookupEnv {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ingester.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_FromEnvironment(t *testing.T) {
+	cfg, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.AppPort != "3000" {
+		t.Errorf("Expected APP_PORT 3000, got %q", cfg.AppPort)
+	}
+	if cfg.Dapr.PubSubName != "kafka-pubsub" {
+		t.Errorf("Expected pubsub kafka-pubsub, got %q", cfg.Dapr.PubSubName)
+	}
+	if cfg.Topics.LiquidityEvents != "dex-liquidity-events" {
+		t.Errorf("Expected liquidity topic, got %q", cfg.Topics.LiquidityEvents)
+	}
+	if cfg.FinalityConfirmations != nil {
+		t.Errorf("Expected confirmations left to the chain profile, got %d", *cfg.FinalityConfirmations)
+	}
+	if cfg.Confirmations(20) != 20 {
+		t.Errorf("Expected profile default 20, got %d", cfg.Confirmations(20))
+	}
+}
+
+func TestLoad_TrimsWhitespace(t *testing.T) {
+	env := validEnv()
+	env["APP_PORT"] = "  3000  "
+
+	cfg, err := Load(nil, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.AppPort != "3000" {
+		t.Errorf("Expected '3000' (trimmed), got %q", cfg.AppPort)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+appPort: "4000"
+finalityConfirmations: 10
+dapr:
+  host: file-host
+topics:
+  tradingEvents: file-trading
+`)
+	env := validEnv()
+	delete(env, "DAPR_HOST")
+	delete(env, "TOPIC_TRADING_EVENTS")
+	env["APP_PORT"] = "5000"
+
+	cfg, err := Load([]string{"--config", path, "--finality-confirmations", "12"}, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Dapr.Host != "file-host" {
+		t.Errorf("Expected file value for dapr host, got %q", cfg.Dapr.Host)
+	}
+	if cfg.Topics.TradingEvents != "file-trading" {
+		t.Errorf("Expected file value for trading topic, got %q", cfg.Topics.TradingEvents)
+	}
+	if cfg.AppPort != "5000" {
+		t.Errorf("Expected env to override file for APP_PORT, got %q", cfg.AppPort)
+	}
+	if cfg.Confirmations(64) != 12 {
+		t.Errorf("Expected flag to override file for confirmations, got %d", cfg.Confirmations(64))
+	}
+}
+
+func TestLoad_ConfigFileFromEnvironment(t *testing.T) {
+	path := writeConfigFile(t, "finalityConfirmations: 0\n")
+	env := validEnv()
+	env[ConfigFileEnv] = path
+
+	cfg, err := Load(nil, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Confirmations(64) != 0 {
+		t.Errorf("Expected pass-through confirmations 0, got %d", cfg.Confirmations(64))
+	}
+}
+
+func TestLoad_ReportsAllErrorsTogether(t *testing.T) {
+	env := map[string]string{
+		"RPC_URL":                "https://not-a-websocket",
+		"PAIR_ADDRESS":           "not-an-address",
+		"FINALITY_CONFIRMATIONS": "-1",
+	}
+
+	_, err := Load(nil, lookupFrom(env))
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError, got %T", err)
+	}
+
+	message := err.Error()
+	for _, expected := range []string{
+		"FINALITY_CONFIRMATIONS",
+		"RPC_URL must be WebSocket",
+		"PAIR_ADDRESS must be valid hex address",
+		"APP_PORT is required",
+		"DAPR_HOST is required",
+		"DAPR_HTTP_PORT is required",
+		"PUBSUB_NAME is required",
+		"TOPIC_TRADING_EVENTS is required",
+		"TOPIC_LIQUIDITY_EVENTS is required",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected error to mention %q, got:\n%s", expected, message)
+		}
+	}
+}
+
+func TestLoad_MultiplePairs(t *testing.T) {
+	env := validEnv()
+	env["PAIR_ADDRESS"] = "0x6e7a5FAFcec6BB1e78bAE2A1F0B612012BF14827, 0xadbF1854e5883eB8aa7BAf50705338739e558E5b"
+
+	cfg, err := Load(nil, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.PairAddresses()) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d", len(cfg.PairAddresses()))
+	}
+	if cfg.PairAddresses()[1].Hex() != "0xadbF1854e5883eB8aa7BAf50705338739e558E5b" {
+		t.Errorf("Unexpected second pair %s", cfg.PairAddresses()[1].Hex())
+	}
+}
+
+func TestLoad_DuplicatePairs(t *testing.T) {
+	env := validEnv()
+	env["PAIR_ADDRESS"] = "0x6e7a5FAFcec6BB1e78bAE2A1F0B612012BF14827,0x6e7a5fafcec6bb1e78bae2a1f0b612012bf14827"
+
+	_, err := Load(nil, lookupFrom(env))
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("Expected duplicate pair error, got %v", err)
+	}
+}
+
+func TestLoad_FeedRegistrySettings(t *testing.T) {
+	path := writeConfigFile(t, "chainId: 42161\nfeedRegistry: /etc/ingester/feeds.yaml\n")
+	env := validEnv()
+	env["STRICT_FEED_VALIDATION"] = "true"
+
+	cfg, err := Load([]string{"--config", path}, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.File != path {
+		t.Errorf("Expected File %q, got %q", path, cfg.File)
+	}
+	if cfg.ChainID != 42161 {
+		t.Errorf("Expected chain 42161, got %d", cfg.ChainID)
+	}
+	if cfg.FeedRegistry != "/etc/ingester/feeds.yaml" {
+		t.Errorf("Unexpected feed registry path %q", cfg.FeedRegistry)
+	}
+	if !cfg.StrictFeedValidation {
+		t.Error("Expected strict feed validation from env")
+	}
+}
+
+func TestLoad_ChainIDDefaultsToDetection(t *testing.T) {
+	cfg, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ChainID != 0 {
+		t.Errorf("Expected chain ID 0 (detect), got %d", cfg.ChainID)
+	}
+}
+
+func TestLoad_LegacyRPCURL(t *testing.T) {
+	env := validEnv()
+	delete(env, "RPC_URL")
+	env[legacyRPCURLEnv] = "wss://legacy.example.com"
+
+	cfg, err := Load(nil, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RPCURL != "wss://legacy.example.com" {
+		t.Errorf("Expected legacy RPC URL fallback, got %q", cfg.RPCURL)
+	}
+
+	env["RPC_URL"] = "wss://current.example.com"
+	cfg, err = Load(nil, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RPCURL != "wss://current.example.com" {
+		t.Errorf("Expected RPC_URL to win over %s, got %q", legacyRPCURLEnv, cfg.RPCURL)
+	}
+
+	delete(env, "RPC_URL")
+	path := writeConfigFile(t, "rpcUrl: wss://file.example.com\n")
+	cfg, err = Load([]string{"--config", path}, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RPCURL != "wss://file.example.com" {
+		t.Errorf("Expected the config file's rpcUrl to win over %s, got %q", legacyRPCURLEnv, cfg.RPCURL)
+	}
+}
+
+func TestLoad_InvalidFeedSettings(t *testing.T) {
+	env := validEnv()
+	env["CHAIN_ID"] = "polygon"
+	env["STRICT_FEED_VALIDATION"] = "maybe"
+
+	_, err := Load(nil, lookupFrom(env))
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	if !strings.Contains(err.Error(), "CHAIN_ID") || !strings.Contains(err.Error(), "STRICT_FEED_VALIDATION") {
+		t.Errorf("Expected both setting errors, got %v", err)
+	}
+}
+
+func TestLoad_OracleSettings(t *testing.T) {
+	path := writeConfigFile(t, "oracle:\n  mode: consensus\n  priceFile: /data/prices.csv\n  twapWindow: 10m\n")
+	env := validEnv()
+	env["ORACLE_MAX_SPREAD"] = "0.1"
+
+	cfg, err := Load([]string{"--config", path}, lookupFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := OracleConfig{Mode: "consensus", MaxSpread: 0.1, PriceFile: "/data/prices.csv", TWAPWindow: 10 * time.Minute}
+	if cfg.Oracle != want {
+		t.Errorf("Expected %+v, got %+v", want, cfg.Oracle)
+	}
+
+	env["ORACLE_MODE"] = "average"
+	env["ORACLE_MAX_SPREAD"] = "1.5"
+	env["TWAP_WINDOW"] = "-1m"
+	_, err = Load(nil, lookupFrom(env))
+	if err == nil || !strings.Contains(err.Error(), "ORACLE_MODE") || !strings.Contains(err.Error(), "ORACLE_MAX_SPREAD") ||
+		!strings.Contains(err.Error(), "TWAP_WINDOW") {
+		t.Errorf("Expected every oracle setting error, got %v", err)
+	}
+}
+
+func TestLoad_ShutdownTimeout(t *testing.T) {
+	cfg, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil || cfg.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Fatalf("Expected the default shutdown timeout, got %s (err=%v)", cfg.ShutdownTimeout, err)
+	}
+
+	cfg, err = Load([]string{"--shutdown-timeout", "2m"}, lookupFrom(validEnv()))
+	if err != nil || cfg.ShutdownTimeout != 2*time.Minute {
+		t.Errorf("Expected 2m, got %s (err=%v)", cfg.ShutdownTimeout, err)
+	}
+
+	env := validEnv()
+	env["SHUTDOWN_TIMEOUT"] = "0s"
+	if _, err := Load(nil, lookupFrom(env)); err == nil || !strings.Contains(err.Error(), "SHUTDOWN_TIMEOUT") {
+		t.Errorf("Expected a SHUTDOWN_TIMEOUT error, got %v", err)
+	}
+}
+
+func TestLoad_PricePrecision(t *testing.T) {
+	cfg, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil || cfg.PricePrecision != DefaultPricePrecision {
+		t.Fatalf("Expected the default price precision, got %d (err=%v)", cfg.PricePrecision, err)
+	}
+
+	cfg, err = Load([]string{"--price-precision", "12"}, lookupFrom(validEnv()))
+	if err != nil || cfg.PricePrecision != 12 {
+		t.Errorf("Expected 12, got %d (err=%v)", cfg.PricePrecision, err)
+	}
+
+	for _, value := range []string{"0", "79", "many"} {
+		env := validEnv()
+		env["PRICE_PRECISION"] = value
+		if _, err := Load(nil, lookupFrom(env)); err == nil || !strings.Contains(err.Error(), "PRICE_PRECISION") {
+			t.Errorf("Expected a PRICE_PRECISION error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestConfig_ReloadableEqual(t *testing.T) {
+	base, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	reloadable := base
+	reloadable.Pairs = []string{"0xadbF1854e5883eB8aa7BAf50705338739e558E5b"}
+	reloadable.FeedRegistry = "/etc/ingester/feeds.yaml"
+	if !base.ReloadableEqual(reloadable) {
+		t.Error("Pair and feed registry changes should be reloadable")
+	}
+
+	restart := base
+	restart.Topics.TradingEvents = "other-topic"
+	if base.ReloadableEqual(restart) {
+		t.Error("Topic change should require a restart")
+	}
+
+	restart = base
+	confirmations := uint64(12)
+	restart.FinalityConfirmations = &confirmations
+	if base.ReloadableEqual(restart) {
+		t.Error("Confirmation change should require a restart")
+	}
+
+	restart = base
+	restart.ChainID = 1
+	if base.ReloadableEqual(restart) {
+		t.Error("Chain change should require a restart")
+	}
+
+	restart = base
+	restart.PricePrecision = base.PricePrecision + 1
+	if base.ReloadableEqual(restart) {
+		t.Error("Price precision change should require a restart")
+	}
+
+	restart = base
+	restart.StrictFeedValidation = !base.StrictFeedValidation
+	if base.ReloadableEqual(restart) {
+		t.Error("Strict feed validation change should require a restart")
+	}
+}
+
+func TestLoad_InvalidPort(t *testing.T) {
+	env := validEnv()
+	env["DAPR_HTTP_PORT"] = "70000"
+
+	_, err := Load(nil, lookupFrom(env))
+	if err == nil || !strings.Contains(err.Error(), "DAPR_HTTP_PORT must be a port number") {
+		t.Errorf("Expected port range error, got %v", err)
+	}
+}
+
+func TestLoad_UnknownFileField(t *testing.T) {
+	path := writeConfigFile(t, "appPortt: \"3000\"\n")
+
+	_, err := Load([]string{"--config", path}, lookupFrom(validEnv()))
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError for unknown field, got %v", err)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := Load([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}, lookupFrom(validEnv()))
+	if err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestLoad_YAMLAndTOMLFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "ingester.yaml", `
+chainId: 42161
+pairs: ["0xadbF1854e5883eB8aa7BAf50705338739e558E5b"]
+shutdownTimeout: 45s
+dapr:
+  host: file-host
+oracle:
+  mode: consensus
+  twapWindow: 10m
+`},
+		{"toml", "ingester.toml", `
+chainId = 42161
+pairs = ["0xadbF1854e5883eB8aa7BAf50705338739e558E5b"]
+shutdownTimeout = "45s"
+
+[dapr]
+host = "file-host"
+
+[oracle]
+mode = "consensus"
+twapWindow = "10m"
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("write config file: %v", err)
+			}
+			env := validEnv()
+			delete(env, "PAIR_ADDRESS")
+			delete(env, "DAPR_HOST")
+
+			cfg, err := Load([]string{"--config", path}, lookupFrom(env))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			if cfg.ChainID != 42161 || cfg.ShutdownTimeout != 45*time.Second || cfg.Dapr.Host != "file-host" ||
+				cfg.Oracle.Mode != "consensus" || cfg.Oracle.TWAPWindow != 10*time.Minute ||
+				len(cfg.Pairs) != 1 || cfg.Pairs[0] != "0xadbF1854e5883eB8aa7BAf50705338739e558E5b" {
+				t.Errorf("Expected the file's values, got %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoad_RejectsUnknownTOMLKeyAndUnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	for file, content := range map[string]string{
+		"ingester.toml": "appPortt = \"3000\"\n",
+		"ingester.json": "{\"appPort\": \"3000\"}\n",
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config file: %v", err)
+		}
+
+		_, err := Load([]string{"--config", path}, lookupFrom(validEnv()))
+
+		var configErr *apperr.ConfigError
+		if !errors.As(err, &configErr) {
+			t.Errorf("%s: expected a ConfigError, got %v", file, err)
+		}
+	}
+}
+
+func TestLoad_UnknownFlag(t *testing.T) {
+	_, err := Load([]string{"--no-such-flag"}, lookupFrom(validEnv()))
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Errorf("Expected ConfigError for unknown flag, got %v", err)
+	}
+}
+
+func TestConfig_WriteYAMLRedactsRPCURL(t *testing.T) {
+	cfg, err := Load(nil, lookupFrom(validEnv()))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := cfg.WriteYAML(&output); err != nil {
+		t.Fatalf("WriteYAML failed: %v", err)
+	}
+
+	printed := output.String()
+	if strings.Contains(printed, "secret-key") {
+		t.Errorf("Expected RPC URL path to be redacted, got:\n%s", printed)
+	}
+	if !strings.Contains(printed, "wss://polygon-mainnet.example.com/<redacted>") {
+		t.Errorf("Expected redacted RPC URL, got:\n%s", printed)
+	}
+	if !strings.Contains(printed, "tradingEvents: dex-trading-events") {
+		t.Errorf("Expected topics in output, got:\n%s", printed)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	apperr "ingester/internal/errors"
+)
+
+// Validate checks every field and reports all problems at once rather than stopping at the first.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(message string) {
+		errs = append(errs, &apperr.ConfigError{Message: message})
+	}
+
+	switch {
+	case c.RPCURL == "":
+		fail("RPC_URL required (must be WebSocket: wss:// or ws://)")
+	case !strings.HasPrefix(c.RPCURL, "wss://") && !strings.HasPrefix(c.RPCURL, "ws://"):
+		fail("RPC_URL must be WebSocket URL (wss:// or ws://)")
+	case len(c.RPCURL) <= len("wss://"):
+		fail("RPC_URL too short")
+	}
+
+	if len(c.Pairs) == 0 {
+		fail("PAIR_ADDRESS is required")
+	}
+	seenPairs := make(map[common.Address]bool, len(c.Pairs))
+	for _, pair := range c.Pairs {
+		if !common.IsHexAddress(pair) {
+			fail(fmt.Sprintf("PAIR_ADDRESS must be valid hex address, got %q", pair))
+			continue
+		}
+		if seenPairs[common.HexToAddress(pair)] {
+			fail(fmt.Sprintf("PAIR_ADDRESS lists %s more than once", pair))
+		}
+		seenPairs[common.HexToAddress(pair)] = true
+	}
+
+	requirePort(fail, "APP_PORT", c.AppPort)
+	requireValue(fail, "DAPR_HOST", c.Dapr.Host)
+	requirePort(fail, "DAPR_HTTP_PORT", c.Dapr.HTTPPort)
+	requireValue(fail, "PUBSUB_NAME", c.Dapr.PubSubName)
+	requireValue(fail, "TOPIC_TRADING_EVENTS", c.Topics.TradingEvents)
+	requireValue(fail, "TOPIC_LIQUIDITY_EVENTS", c.Topics.LiquidityEvents)
+
+	switch c.Oracle.Mode {
+	case "", "priority", "consensus":
+	default:
+		fail(fmt.Sprintf("ORACLE_MODE must be priority or consensus, got %q", c.Oracle.Mode))
+	}
+	if c.Oracle.MaxSpread < 0 || c.Oracle.MaxSpread >= 1 {
+		fail(fmt.Sprintf("ORACLE_MAX_SPREAD must be in [0, 1), got %g", c.Oracle.MaxSpread))
+	}
+	if c.Oracle.TWAPWindow < 0 {
+		fail(fmt.Sprintf("TWAP_WINDOW must not be negative, got %s", c.Oracle.TWAPWindow))
+	}
+	if c.ShutdownTimeout <= 0 {
+		fail(fmt.Sprintf("SHUTDOWN_TIMEOUT must be positive, got %s", c.ShutdownTimeout))
+	}
+	if c.PricePrecision < 1 || c.PricePrecision > MaxPricePrecision {
+		fail(fmt.Sprintf("PRICE_PRECISION must be between 1 and %d, got %d", MaxPricePrecision, c.PricePrecision))
+	}
+
+	return errors.Join(errs...)
+}
+
+// PairAddresses returns the validated pair addresses.
+func (c Config) PairAddresses() []common.Address {
+	return hexAddresses(c.Pairs)
+}
+
+// Confirmations returns the configured finality depth, or profileDefault when none is set.
+func (c Config) Confirmations(profileDefault uint64) uint64 {
+	if c.FinalityConfirmations == nil {
+		return profileDefault
+	}
+	return *c.FinalityConfirmations
+}
+
+// ReloadableEqual reports whether two configs differ only in fields that can be hot reloaded.
+func (c Config) ReloadableEqual(other Config) bool {
+	return c.RPCURL == other.RPCURL &&
+		c.AppPort == other.AppPort &&
+		equalOptional(c.FinalityConfirmations, other.FinalityConfirmations) &&
+		c.ChainID == other.ChainID &&
+		c.StrictFeedValidation == other.StrictFeedValidation &&
+		c.Dapr == other.Dapr &&
+		c.Topics == other.Topics &&
+		c.Oracle == other.Oracle &&
+		c.TokenMetadata == other.TokenMetadata &&
+		c.CacheDir == other.CacheDir &&
+		c.OutboxFile == other.OutboxFile &&
+		c.ShutdownTimeout == other.ShutdownTimeout &&
+		c.PricePrecision == other.PricePrecision
+}
+
+func equalOptional(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func hexAddresses(values []string) []common.Address {
+	if len(values) == 0 {
+		return nil
+	}
+	addresses := make([]common.Address, 0, len(values))
+	for _, value := range values {
+		addresses = append(addresses, common.HexToAddress(value))
+	}
+	return addresses
+}
+
+func requireValue(fail func(string), name, value string) {
+	if value == "" {
+		fail(name + " is required")
+	}
+}
+
+func requirePort(fail func(string), name, value string) {
+	if value == "" {
+		fail(name + " is required")
+		return
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		fail(name + " must be a port number between 1 and 65535")
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	apperr "ingester/internal/errors"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// watchDebounce coalesces the burst of events editors and ConfigMap updates produce for one save.
+const watchDebounce = 250 * time.Millisecond
+
+// Watch sends on the returned channel whenever the config file at path changes.
+// The parent directory is watched rather than the file itself, because atomic saves and
+// Kubernetes ConfigMap updates replace the file (or a symlink to it) instead of writing in place.
+// The channel is closed when ctx is cancelled.
+func Watch(ctx context.Context, path string) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, &apperr.ConfigError{Message: "create config file watcher", Cause: err}
+	}
+
+	directory := filepath.Dir(path)
+	if err := watcher.Add(directory); err != nil {
+		_ = watcher.Close()
+		return nil, &apperr.ConfigError{Message: "watch config directory " + directory, Cause: err}
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer func() { _ = watcher.Close() }()
+
+		target := filepath.Clean(path)
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == target || filepath.Base(event.Name) == "..data" {
+					debounce = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("Config watcher error", "path", path, "error", err)
+			case <-debounce:
+				debounce = nil
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
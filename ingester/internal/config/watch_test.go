@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch_SignalsOnFileChange(t *testing.T) {
+	path := writeConfigFile(t, "appPort: \"3000\"\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Watch(ctx, path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("appPort: \"4000\"\n"), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected change notification after rewriting config file")
+	}
+}
+
+func TestWatch_IgnoresOtherFiles(t *testing.T) {
+	path := writeConfigFile(t, "appPort: \"3000\"\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Watch(ctx, path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "other.yaml"), []byte("x: 1\n"), 0o600); err != nil {
+		t.Fatalf("write sibling file: %v", err)
+	}
+
+	select {
+	case <-changes:
+		t.Fatal("Did not expect a notification for an unrelated file")
+	case <-time.After(2 * watchDebounce):
+	}
+}
+
+func TestWatch_ClosesOnCancel(t *testing.T) {
+	path := writeConfigFile(t, "appPort: \"3000\"\n")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes, err := Watch(ctx, path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("Expected channel to be closed, got a notification")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected channel to close after cancel")
+	}
+}
@@ -31,6 +31,7 @@ func (f ContractCallerFunc) CallContract(ctx context.Context, msg ethereum.CallM
 const (
 	ERC20Decimals       ABIName = "erc20_decimals"
 	ERC20Symbol         ABIName = "erc20_symbol"
+	ERC20Name           ABIName = "erc20_name"
 	ChainlinkAggregator ABIName = "chainlink_aggregator"
 	UniswapV2Pair       ABIName = "uniswap_v2_pair"
 )
@@ -41,6 +42,9 @@ var erc20DecimalsABIJSON string
 //go:embed erc20_symbol.abi.json
 var erc20SymbolABIJSON string
 
+//go:embed erc20_name.abi.json
+var erc20NameABIJSON string
+
 //go:embed chainlink_aggregator.abi.json
 var chainlinkAggregatorABIJSON string
 
@@ -52,6 +56,8 @@ var (
 	erc20DecimalsOnce       sync.Once
 	erc20SymbolABI          abi.ABI
 	erc20SymbolOnce         sync.Once
+	erc20NameABI            abi.ABI
+	erc20NameOnce           sync.Once
 	chainlinkAggregatorABI  abi.ABI
 	chainlinkAggregatorOnce sync.Once
 	uniswapV2PairABI        abi.ABI
@@ -69,6 +75,10 @@ func GetABI(name ABIName) (abi.ABI, error) {
 		var err error
 		erc20SymbolOnce.Do(func() { erc20SymbolABI, err = parseABI(ERC20Symbol) })
 		return erc20SymbolABI, err
+	case ERC20Name:
+		var err error
+		erc20NameOnce.Do(func() { erc20NameABI, err = parseABI(ERC20Name) })
+		return erc20NameABI, err
 	case ChainlinkAggregator:
 		var err error
 		chainlinkAggregatorOnce.Do(func() { chainlinkAggregatorABI, err = parseABI(ChainlinkAggregator) })
@@ -89,6 +99,19 @@ func CallContract[T any](
 	contractABI abi.ABI,
 	methodName string,
 	resultPtr *T,
+) error {
+	return CallContractAt(ctx, caller, contractAddress, contractABI, methodName, nil, resultPtr)
+}
+
+// CallContractAt is CallContract against the state at blockNumber (nil means latest).
+func CallContractAt[T any](
+	ctx context.Context,
+	caller ContractCaller,
+	contractAddress common.Address,
+	contractABI abi.ABI,
+	methodName string,
+	blockNumber *big.Int,
+	resultPtr *T,
 ) error {
 	data, err := contractABI.Pack(methodName)
 	if err != nil {
@@ -98,7 +121,7 @@ func CallContract[T any](
 	result, err := caller.CallContract(ctx, ethereum.CallMsg{
 		To:   &contractAddress,
 		Data: data,
-	}, nil)
+	}, blockNumber)
 	if err != nil {
 		return fmt.Errorf("call contract: %w", err)
 	}
@@ -128,6 +151,8 @@ func parseABI(name ABIName) (abi.ABI, error) {
 		jsonStr = erc20DecimalsABIJSON
 	case ERC20Symbol:
 		jsonStr = erc20SymbolABIJSON
+	case ERC20Name:
+		jsonStr = erc20NameABIJSON
 	case ChainlinkAggregator:
 		jsonStr = chainlinkAggregatorABIJSON
 	case UniswapV2Pair:
@@ -18,6 +18,7 @@ func TestGetABI_AllKnownABIs(t *testing.T) {
 	}{
 		{"ERC20 Decimals", ERC20Decimals},
 		{"ERC20 Symbol", ERC20Symbol},
+		{"ERC20 Name", ERC20Name},
 		{"Chainlink Aggregator", ChainlinkAggregator},
 		{"Uniswap V2 Pair", UniswapV2Pair},
 	}
@@ -75,11 +76,20 @@ func TestGetABI_HasExpectedMethods(t *testing.T) {
 	}{
 		{ERC20Decimals, "decimals"},
 		{ERC20Symbol, "symbol"},
+		{ERC20Name, "name"},
 		{ChainlinkAggregator, "latestRoundData"},
 		{ChainlinkAggregator, "decimals"},
+		{ChainlinkAggregator, "description"},
+		{ChainlinkAggregator, "aggregator"},
+		{ChainlinkAggregator, "minAnswer"},
+		{ChainlinkAggregator, "maxAnswer"},
 		{UniswapV2Pair, "Swap"},
 		{UniswapV2Pair, "Mint"},
 		{UniswapV2Pair, "Burn"},
+		{UniswapV2Pair, "getReserves"},
+		{UniswapV2Pair, "price0CumulativeLast"},
+		{UniswapV2Pair, "price1CumulativeLast"},
+		{UniswapV2Pair, "totalSupply"},
 	}
 
 	for _, tt := range tests {
@@ -104,6 +114,7 @@ func TestParseABI_InvalidJSON(t *testing.T) {
 	allABIs := []ABIName{
 		ERC20Decimals,
 		ERC20Symbol,
+		ERC20Name,
 		ChainlinkAggregator,
 		UniswapV2Pair,
 	}
@@ -347,3 +358,33 @@ func TestCallContract_InvalidMethodName(t *testing.T) {
 		t.Errorf("Expected error to contain 'pack method', got: %v", err)
 	}
 }
+
+func TestCallContractAt_PassesBlockNumber(t *testing.T) {
+	pairABI, err := GetABI(UniswapV2Pair)
+	if err != nil {
+		t.Fatalf("Failed to get pair ABI: %v", err)
+	}
+
+	var requestedBlock *big.Int
+	mockCaller := ContractCallerFunc(func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		requestedBlock = blockNumber
+		return pairABI.Methods["getReserves"].Outputs.Pack(big.NewInt(1000), big.NewInt(2000), uint32(1700000000))
+	})
+
+	var reserves struct {
+		Reserve0           *big.Int
+		Reserve1           *big.Int
+		BlockTimestampLast uint32
+	}
+	err = CallContractAt(context.Background(), mockCaller, common.Address{}, pairABI, "getReserves", big.NewInt(42), &reserves)
+	if err != nil {
+		t.Fatalf("CallContractAt failed: %v", err)
+	}
+
+	if requestedBlock == nil || requestedBlock.Int64() != 42 {
+		t.Errorf("Expected call at block 42, got %v", requestedBlock)
+	}
+	if reserves.Reserve0.Int64() != 1000 || reserves.Reserve1.Int64() != 2000 {
+		t.Errorf("Unexpected reserves %+v", reserves)
+	}
+}
@@ -6,6 +6,19 @@ type BurnEvent struct {
 	Recipient string `json:"recipient"`
 	Amount0   string `json:"amount0"` // Token0 amount removed from pool
 	Amount1   string `json:"amount1"` // Token1 amount removed from pool
+	// LP position: the LP tokens burned, the address that returned them to the pair and their
+	// share of the pair's LP supply before the burn.
+	LPTokens      *string  `json:"lpTokens,omitempty"`
+	Provider      *string  `json:"provider,omitempty"`
+	ShareOfSupply *float64 `json:"shareOfSupply,omitempty"`
+	// USD value of the amounts removed at the block's prices; TotalUSD is twice the priced side
+	// when only one token has a price.
+	Amount0USD *float64 `json:"amount0USD,omitempty"`
+	Amount1USD *float64 `json:"amount1USD,omitempty"`
+	TotalUSD   *float64 `json:"totalUSD,omitempty"`
+	// Amount0 and Amount1 in whole tokens, as decimal strings with AmountScale digits.
+	Amount0Adjusted *string `json:"amount0Adjusted,omitempty"`
+	Amount1Adjusted *string `json:"amount1Adjusted,omitempty"`
 }
 
 func (e BurnEvent) ToMap() map[string]interface{} {
@@ -14,5 +27,13 @@ func (e BurnEvent) ToMap() map[string]interface{} {
 	m["recipient"] = e.Recipient
 	m["amount0"] = e.Amount0
 	m["amount1"] = e.Amount1
+	m["lpTokens"] = toNullable(e.LPTokens)
+	m["provider"] = toNullable(e.Provider)
+	m["shareOfSupply"] = toNullable(e.ShareOfSupply)
+	m["amount0USD"] = toNullable(e.Amount0USD)
+	m["amount1USD"] = toNullable(e.Amount1USD)
+	m["totalUSD"] = toNullable(e.TotalUSD)
+	m["amount0Adjusted"] = toDecimal(e.Amount0Adjusted)
+	m["amount1Adjusted"] = toDecimal(e.Amount1Adjusted)
 	return m
 }
@@ -0,0 +1,24 @@
+package events
+
+import "math/big"
+
+// Decimals-adjusted amounts are Avro decimals of this precision and scale: 18 fractional digits
+// hold every common ERC-20 exactly, and 38 digits is the widest decimal Flink, Spark and most
+// warehouses read.
+const (
+	AmountPrecision = 38
+	AmountScale     = 18
+)
+
+// toDecimal wraps a decimal string for a nullable Avro bytes.decimal union, which goavro
+// encodes from a *big.Rat.
+func toDecimal(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+	rat, ok := new(big.Rat).SetString(*value)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{"bytes.decimal": rat}
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Decode reverses json.Marshal of an Event, picking the concrete type from its eventType.
+func Decode(data []byte) (Event, error) {
+	var base struct {
+		EventType EventType `json:"eventType"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+
+	switch base.EventType {
+	case EventTypeSwap:
+		return decodeAs[SwapEvent](data)
+	case EventTypeMint:
+		return decodeAs[MintEvent](data)
+	case EventTypeBurn:
+		return decodeAs[BurnEvent](data)
+	case EventTypeTransfer:
+		return decodeAs[TransferEvent](data)
+	case EventTypeMevCandidate:
+		return decodeAs[MevCandidateEvent](data)
+	default:
+		return nil, fmt.Errorf("unknown event type %q", base.EventType)
+	}
+}
+
+func decodeAs[E Event](data []byte) (Event, error) {
+	var event E
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -5,6 +5,7 @@ import "strings"
 type EventType string
 
 type Event interface {
+	GetChainID() int64
 	GetEventType() EventType
 	GetEventID() string
 	GetPairAddress() string
@@ -14,6 +15,7 @@ type Event interface {
 }
 
 type BaseEvent struct {
+	ChainID         int64     `json:"chainId"` // EIP-155 chain ID
 	EventType       EventType `json:"eventType"`
 	EventID         string    `json:"eventId"`
 	BlockNumber     int64     `json:"blockNumber"`
@@ -26,6 +28,10 @@ type BaseEvent struct {
 	Token0Symbol    *string   `json:"token0Symbol,omitempty"`
 	Token1Symbol    *string   `json:"token1Symbol,omitempty"`
 	EventTimestamp  int64     `json:"eventTimestamp"`
+	Token0Name      *string   `json:"token0Name,omitempty"`
+	Token1Name      *string   `json:"token1Name,omitempty"`
+	Token0Decimals  *int32    `json:"token0Decimals,omitempty"`
+	Token1Decimals  *int32    `json:"token1Decimals,omitempty"`
 }
 
 const (
@@ -33,6 +39,8 @@ const (
 	EventTypeMint     EventType = "Mint"
 	EventTypeBurn     EventType = "Burn"
 	EventTypeTransfer EventType = "Transfer"
+	// EventTypeMevCandidate is derived by the ingester from other events rather than read from a log.
+	EventTypeMevCandidate EventType = "MevCandidate"
 )
 
 var (
@@ -40,12 +48,14 @@ var (
 	_ Event = (*MintEvent)(nil)
 	_ Event = (*BurnEvent)(nil)
 	_ Event = (*TransferEvent)(nil)
+	_ Event = (*MevCandidateEvent)(nil)
 )
 
 func (et EventType) CloudEventType() string {
 	return "com.dex.events." + strings.ToLower(string(et))
 }
 
+func (base BaseEvent) GetChainID() int64        { return base.ChainID }
 func (base BaseEvent) GetEventType() EventType  { return base.EventType }
 func (base BaseEvent) GetEventID() string       { return base.EventID }
 func (base BaseEvent) GetPairAddress() string   { return base.PairAddress }
@@ -66,6 +76,11 @@ func (base BaseEvent) ToMap() map[string]interface{} {
 		"token0Symbol":    toNullable(base.Token0Symbol),
 		"token1Symbol":    toNullable(base.Token1Symbol),
 		"eventTimestamp":  base.EventTimestamp,
+		"chainId":         base.ChainID,
+		"token0Name":      toNullable(base.Token0Name),
+		"token1Name":      toNullable(base.Token1Name),
+		"token0Decimals":  toNullable(base.Token0Decimals),
+		"token1Decimals":  toNullable(base.Token1Decimals),
 	}
 }
 
@@ -76,6 +91,7 @@ func AllEventTypes() []EventType {
 		EventTypeMint,
 		EventTypeBurn,
 		EventTypeTransfer,
+		EventTypeMevCandidate,
 	}
 }
 
@@ -91,6 +107,10 @@ func toNullable[T any](ptr *T) interface{} {
 		return map[string]interface{}{"string": typed}
 	case float64:
 		return map[string]interface{}{"double": typed}
+	case int32:
+		return map[string]interface{}{"int": typed}
+	case int64:
+		return map[string]interface{}{"long": typed}
 	default:
 		return value
 	}
@@ -1,6 +1,8 @@
 package events
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -27,15 +29,16 @@ func TestEventTypes(t *testing.T) {
 
 func TestAllEventTypes(t *testing.T) {
 	all := AllEventTypes()
-	if len(all) != 4 {
-		t.Errorf("Expected 4 event types, got %d", len(all))
+	if len(all) != 5 {
+		t.Errorf("Expected 5 event types, got %d", len(all))
 	}
 
 	expected := map[EventType]bool{
-		EventTypeSwap:     false,
-		EventTypeMint:     false,
-		EventTypeBurn:     false,
-		EventTypeTransfer: false,
+		EventTypeSwap:         false,
+		EventTypeMint:         false,
+		EventTypeBurn:         false,
+		EventTypeTransfer:     false,
+		EventTypeMevCandidate: false,
 	}
 
 	for _, et := range all {
@@ -150,8 +153,10 @@ func TestTransferEvent_GetMethods(t *testing.T) {
 
 func TestSwapEvent_ToMap(t *testing.T) {
 	volumeUSD := 123.45
+	updatedAt := int64(1700000000)
 	event := SwapEvent{
 		BaseEvent: BaseEvent{
+			ChainID:     137,
 			EventID:     "event-1",
 			BlockNumber: 100,
 		},
@@ -165,6 +170,9 @@ func TestSwapEvent_ToMap(t *testing.T) {
 		VolumeUSD:  &volumeUSD,
 		GasUsed:    21000,
 		GasPrice:   "50000000000",
+
+		PriceSource:    "CHAINLINK",
+		PriceUpdatedAt: &updatedAt,
 	}
 
 	m := event.ToMap()
@@ -173,6 +181,10 @@ func TestSwapEvent_ToMap(t *testing.T) {
 		t.Errorf("Expected eventId 'event-1', got %v", m["eventId"])
 	}
 
+	if m["chainId"] != int64(137) {
+		t.Errorf("Expected chainId 137, got %v", m["chainId"])
+	}
+
 	if m["sender"] != "0xsender" {
 		t.Errorf("Expected sender '0xsender', got %v", m["sender"])
 	}
@@ -185,6 +197,14 @@ func TestSwapEvent_ToMap(t *testing.T) {
 	if !ok || volumeUnion["double"] != 123.45 {
 		t.Errorf("Expected volumeUSD union map with double 123.45, got %v", m["volumeUSD"])
 	}
+
+	if m["priceSource"] != "CHAINLINK" {
+		t.Errorf("Expected priceSource CHAINLINK, got %v", m["priceSource"])
+	}
+	updatedAtUnion, ok := m["priceUpdatedAt"].(map[string]interface{})
+	if !ok || updatedAtUnion["long"] != int64(1700000000) {
+		t.Errorf("Expected priceUpdatedAt union map with long 1700000000, got %v", m["priceUpdatedAt"])
+	}
 }
 
 func TestSwapEvent_ToMap_NilVolumeUSD(t *testing.T) {
@@ -200,6 +220,9 @@ func TestSwapEvent_ToMap_NilVolumeUSD(t *testing.T) {
 	if m["volumeUSD"] != nil {
 		t.Errorf("Expected volumeUSD nil, got %v", m["volumeUSD"])
 	}
+	if m["priceSource"] != "NONE" {
+		t.Errorf("Expected priceSource NONE for an unpriced swap, got %v", m["priceSource"])
+	}
 }
 
 func TestMintEvent_ToMap(t *testing.T) {
@@ -282,11 +305,15 @@ func TestTransferEvent_ToMap(t *testing.T) {
 func TestBaseEvent_ToMap_WithOptionalFields(t *testing.T) {
 	token0Symbol := "WMATIC"
 	token1Symbol := "USDC"
+	token1Name := "USD Coin"
+	token1Decimals := int32(6)
 
 	base := BaseEvent{
-		EventID:      "base-1",
-		Token0Symbol: &token0Symbol,
-		Token1Symbol: &token1Symbol,
+		EventID:        "base-1",
+		Token0Symbol:   &token0Symbol,
+		Token1Symbol:   &token1Symbol,
+		Token1Name:     &token1Name,
+		Token1Decimals: &token1Decimals,
 	}
 
 	m := base.ToMap()
@@ -300,6 +327,16 @@ func TestBaseEvent_ToMap_WithOptionalFields(t *testing.T) {
 	if !ok || token1Union["string"] != "USDC" {
 		t.Errorf("Expected token1Symbol union map with string 'USDC', got %v", m["token1Symbol"])
 	}
+
+	nameUnion, ok := m["token1Name"].(map[string]interface{})
+	if !ok || nameUnion["string"] != "USD Coin" {
+		t.Errorf("Expected token1Name union map with string 'USD Coin', got %v", m["token1Name"])
+	}
+
+	decimalsUnion, ok := m["token1Decimals"].(map[string]interface{})
+	if !ok || decimalsUnion["int"] != int32(6) {
+		t.Errorf("Expected token1Decimals union map with int 6, got %v", m["token1Decimals"])
+	}
 }
 
 func TestBaseEvent_ToMap_WithoutOptionalFields(t *testing.T) {
@@ -319,3 +356,43 @@ func TestBaseEvent_ToMap_WithoutOptionalFields(t *testing.T) {
 		t.Errorf("Expected token1Symbol nil, got %v", m["token1Symbol"])
 	}
 }
+
+func TestDecode_RoundTripsEveryType(t *testing.T) {
+	symbol := "WETH"
+	volume := 1234.5
+	originals := []Event{
+		SwapEvent{
+			BaseEvent: BaseEvent{EventType: EventTypeSwap, EventID: "swap-1", BlockNumber: 10, Token0Symbol: &symbol},
+			Amount0In: "100",
+			VolumeUSD: &volume,
+		},
+		MintEvent{BaseEvent: BaseEvent{EventType: EventTypeMint, EventID: "mint-1"}, Amount0: "5"},
+		BurnEvent{BaseEvent: BaseEvent{EventType: EventTypeBurn, EventID: "burn-1"}, Amount0: "3"},
+		TransferEvent{BaseEvent: BaseEvent{EventType: EventTypeTransfer, EventID: "transfer-1"}, Value: "7"},
+		MevCandidateEvent{
+			BaseEvent: BaseEvent{EventType: EventTypeMevCandidate, EventID: "mev:sandwich:swap-1"},
+			Kind:      MevKindSandwich,
+			VictimTxs: []string{"0xvictim"},
+			Pairs:     []string{"0xpair"},
+		},
+	}
+
+	for _, original := range originals {
+		data, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		decoded, err := Decode(data)
+		if err != nil {
+			t.Errorf("Decode %s failed: %v", original.GetEventType(), err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, original) {
+			t.Errorf("Expected %+v, got %+v", original, decoded)
+		}
+	}
+
+	if _, err := Decode([]byte(`{"eventType":"Sync"}`)); err == nil {
+		t.Error("Expected an error for an unknown event type")
+	}
+}
@@ -0,0 +1,48 @@
+package events
+
+// MevCandidateEvent flags a pattern in one block's events that looks like MEV extraction. The
+// embedded BaseEvent locates the pattern's first event; EventID is derived from it, so the
+// same block always yields the same candidates.
+type MevCandidateEvent struct {
+	BaseEvent
+	Kind       string   `json:"kind"`       // SANDWICH, ARBITRAGE or JIT_LIQUIDITY
+	Actor      string   `json:"actor"`      // address behind the front-run, arbitrage or liquidity
+	FrontRunTx string   `json:"frontRunTx"` // first transaction of the pattern
+	VictimTxs  []string `json:"victimTxs"`  // swaps the pattern traded around; empty for arbitrage
+	BackRunTx  *string  `json:"backRunTx,omitempty"`
+	Pairs      []string `json:"pairs"` // every pair the pattern touched, in log order
+	// Estimated profit in raw units of ProfitToken, and in USD when the token's price is known.
+	ProfitToken  *string  `json:"profitToken,omitempty"`
+	ProfitAmount *string  `json:"profitAmount,omitempty"`
+	ProfitUSD    *float64 `json:"profitUSD,omitempty"`
+}
+
+// MEV candidate kinds.
+const (
+	MevKindSandwich     = "SANDWICH"
+	MevKindArbitrage    = "ARBITRAGE"
+	MevKindJITLiquidity = "JIT_LIQUIDITY"
+)
+
+func (e MevCandidateEvent) ToMap() map[string]interface{} {
+	m := e.BaseEvent.ToMap()
+	m["kind"] = e.Kind
+	m["actor"] = e.Actor
+	m["frontRunTx"] = e.FrontRunTx
+	m["victimTxs"] = toArray(e.VictimTxs)
+	m["backRunTx"] = toNullable(e.BackRunTx)
+	m["pairs"] = toArray(e.Pairs)
+	m["profitToken"] = toNullable(e.ProfitToken)
+	m["profitAmount"] = toNullable(e.ProfitAmount)
+	m["profitUSD"] = toNullable(e.ProfitUSD)
+	return m
+}
+
+// toArray converts a slice for Avro array encoding, which takes []interface{}.
+func toArray(values []string) []interface{} {
+	array := make([]interface{}, len(values))
+	for i, value := range values {
+		array[i] = value
+	}
+	return array
+}
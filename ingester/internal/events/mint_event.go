@@ -5,6 +5,19 @@ type MintEvent struct {
 	Sender  string `json:"sender"`
 	Amount0 string `json:"amount0"` // Token0 amount added to pool
 	Amount1 string `json:"amount1"` // Token1 amount added to pool
+	// LP position: the LP tokens minted, the address they were minted to and their share of
+	// the pair's LP supply.
+	LPTokens      *string  `json:"lpTokens,omitempty"`
+	Provider      *string  `json:"provider,omitempty"`
+	ShareOfSupply *float64 `json:"shareOfSupply,omitempty"`
+	// USD value of the amounts added at the block's prices; TotalUSD is twice the priced side
+	// when only one token has a price.
+	Amount0USD *float64 `json:"amount0USD,omitempty"`
+	Amount1USD *float64 `json:"amount1USD,omitempty"`
+	TotalUSD   *float64 `json:"totalUSD,omitempty"`
+	// Amount0 and Amount1 in whole tokens, as decimal strings with AmountScale digits.
+	Amount0Adjusted *string `json:"amount0Adjusted,omitempty"`
+	Amount1Adjusted *string `json:"amount1Adjusted,omitempty"`
 }
 
 func (e MintEvent) ToMap() map[string]interface{} {
@@ -12,5 +25,13 @@ func (e MintEvent) ToMap() map[string]interface{} {
 	m["sender"] = e.Sender
 	m["amount0"] = e.Amount0
 	m["amount1"] = e.Amount1
+	m["lpTokens"] = toNullable(e.LPTokens)
+	m["provider"] = toNullable(e.Provider)
+	m["shareOfSupply"] = toNullable(e.ShareOfSupply)
+	m["amount0USD"] = toNullable(e.Amount0USD)
+	m["amount1USD"] = toNullable(e.Amount1USD)
+	m["totalUSD"] = toNullable(e.TotalUSD)
+	m["amount0Adjusted"] = toDecimal(e.Amount0Adjusted)
+	m["amount1Adjusted"] = toDecimal(e.Amount1Adjusted)
 	return m
 }
@@ -12,8 +12,57 @@ type SwapEvent struct {
 	VolumeUSD  *float64 `json:"volumeUSD"`
 	GasUsed    int64    `json:"gasUsed"`
 	GasPrice   string   `json:"gasPrice"`
+	// Pricing provenance for VolumeUSD: STABLECOIN, CHAINLINK, REFERENCE_POOL, POOL_TWAP,
+	// OVERRIDE, PRICE_FILE or NONE.
+	PriceSource    string   `json:"priceSource"`
+	PriceRoundID   *string  `json:"priceRoundId,omitempty"`   // Chainlink round behind the price
+	PriceUpdatedAt *int64   `json:"priceUpdatedAt,omitempty"` // Chainlink round update time (seconds)
+	Token0PriceUSD *float64 `json:"token0PriceUSD,omitempty"`
+	Token1PriceUSD *float64 `json:"token1PriceUSD,omitempty"`
+	TWAPPrice      *float64 `json:"twapPrice,omitempty"`     // pair TWAP before this block, token1 per token0
+	TWAPDeviation  *float64 `json:"twapDeviation,omitempty"` // (Price - TWAPPrice) / TWAPPrice
+	Direction      string   `json:"direction"`
+	// Output token received per input token, net of any amount paid back into the pair.
+	ExecutionPrice *float64 `json:"executionPrice,omitempty"`
+	// Position of this swap among the transaction's swap logs, and how many there are.
+	HopIndex      int32   `json:"hopIndex"`
+	HopCount      int32   `json:"hopCount"`
+	Router        *string `json:"router,omitempty"` // known router or aggregator the transaction called
+	ViaAggregator bool    `json:"viaAggregator"`
+	// Transaction origin: the externally owned sender (Sender is usually a router), the called
+	// contract, the sender's nonce, the 4-byte calldata selector and the position in the block.
+	// All are nil when the node no longer serves the transaction or its receipt.
+	TxFrom         *string `json:"txFrom,omitempty"`
+	TxTo           *string `json:"txTo,omitempty"`
+	Nonce          *int64  `json:"nonce,omitempty"`
+	MethodSelector *string `json:"methodSelector,omitempty"`
+	TxIndex        *int32  `json:"txIndex,omitempty"`
+	// EIP-1559 fees: the transaction type, the block's base fee and the priority fee per gas
+	// (wei), and the total fee in wei of the native token and in USD. TxType is nil when the node
+	// no longer serves the transaction.
+	TxType      *int32   `json:"txType,omitempty"`
+	BaseFee     *string  `json:"baseFee,omitempty"`
+	PriorityFee *string  `json:"priorityFee,omitempty"`
+	TxFee       *string  `json:"txFee,omitempty"`
+	TxFeeUSD    *float64 `json:"txFeeUSD,omitempty"`
+	// The four amounts in whole tokens, as decimal strings with AmountScale digits.
+	Amount0InAdjusted  *string `json:"amount0InAdjusted,omitempty"`
+	Amount1InAdjusted  *string `json:"amount1InAdjusted,omitempty"`
+	Amount0OutAdjusted *string `json:"amount0OutAdjusted,omitempty"`
+	Amount1OutAdjusted *string `json:"amount1OutAdjusted,omitempty"`
+	// Price exactly, as a plain decimal string rounded to the configured significant digits,
+	// and as a decimal string with AmountScale digits for the Avro decimal twin.
+	PriceExact   *string `json:"priceExact,omitempty"`
+	PriceDecimal *string `json:"priceDecimal,omitempty"`
 }
 
+// Swap directions, from the trader's side: TOKEN0_TO_TOKEN1 sells token0 for token1.
+const (
+	SwapDirectionToken0ToToken1 = "TOKEN0_TO_TOKEN1"
+	SwapDirectionToken1ToToken0 = "TOKEN1_TO_TOKEN0"
+	SwapDirectionUnknown        = "UNKNOWN"
+)
+
 func (e SwapEvent) WithPrice(price float64) SwapEvent {
 	e.Price = price
 	return e
@@ -31,5 +80,40 @@ func (e SwapEvent) ToMap() map[string]interface{} {
 	m["volumeUSD"] = toNullable(e.VolumeUSD)
 	m["gasUsed"] = e.GasUsed
 	m["gasPrice"] = e.GasPrice
+	m["priceSource"] = e.PriceSource
+	if e.PriceSource == "" {
+		m["priceSource"] = "NONE"
+	}
+	m["priceRoundId"] = toNullable(e.PriceRoundID)
+	m["priceUpdatedAt"] = toNullable(e.PriceUpdatedAt)
+	m["token0PriceUSD"] = toNullable(e.Token0PriceUSD)
+	m["token1PriceUSD"] = toNullable(e.Token1PriceUSD)
+	m["twapPrice"] = toNullable(e.TWAPPrice)
+	m["twapDeviation"] = toNullable(e.TWAPDeviation)
+	m["direction"] = e.Direction
+	if e.Direction == "" {
+		m["direction"] = SwapDirectionUnknown
+	}
+	m["executionPrice"] = toNullable(e.ExecutionPrice)
+	m["hopIndex"] = e.HopIndex
+	m["hopCount"] = max(e.HopCount, 1)
+	m["router"] = toNullable(e.Router)
+	m["viaAggregator"] = e.ViaAggregator
+	m["txFrom"] = toNullable(e.TxFrom)
+	m["txTo"] = toNullable(e.TxTo)
+	m["nonce"] = toNullable(e.Nonce)
+	m["methodSelector"] = toNullable(e.MethodSelector)
+	m["txIndex"] = toNullable(e.TxIndex)
+	m["txType"] = toNullable(e.TxType)
+	m["baseFee"] = toNullable(e.BaseFee)
+	m["priorityFee"] = toNullable(e.PriorityFee)
+	m["txFee"] = toNullable(e.TxFee)
+	m["txFeeUSD"] = toNullable(e.TxFeeUSD)
+	m["amount0InAdjusted"] = toDecimal(e.Amount0InAdjusted)
+	m["amount1InAdjusted"] = toDecimal(e.Amount1InAdjusted)
+	m["amount0OutAdjusted"] = toDecimal(e.Amount0OutAdjusted)
+	m["amount1OutAdjusted"] = toDecimal(e.Amount1OutAdjusted)
+	m["priceExact"] = toNullable(e.PriceExact)
+	m["priceDecimal"] = toDecimal(e.PriceDecimal)
 	return m
 }
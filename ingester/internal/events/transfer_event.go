@@ -8,6 +8,8 @@ type TransferEvent struct {
 	From  string `json:"from"`
 	To    string `json:"to"`
 	Value string `json:"value"`
+	// Value in whole LP tokens, as a decimal string with AmountScale digits.
+	ValueAdjusted *string `json:"valueAdjusted,omitempty"`
 }
 
 func (e TransferEvent) ToMap() map[string]interface{} {
@@ -15,5 +17,6 @@ func (e TransferEvent) ToMap() map[string]interface{} {
 	m["from"] = e.From
 	m["to"] = e.To
 	m["value"] = e.Value
+	m["valueAdjusted"] = toDecimal(e.ValueAdjusted)
 	return m
 }
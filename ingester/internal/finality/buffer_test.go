@@ -1,5 +1,4 @@
 package finality
-package finality
 
 import (
 	"testing"
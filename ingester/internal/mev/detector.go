@@ -0,0 +1,310 @@
+// Package mev flags MEV patterns in confirmed blocks: sandwiches, arbitrage cycles
+// and just-in-time liquidity. It only sees the events of tracked pairs, so a pattern that
+// crosses untracked pools is either missed or reported with the tracked part only.
+package mev
+
+import (
+	"log/slog"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"ingester/internal/events"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// Detector derives MevCandidateEvents from the events of whole blocks. It keeps no state
+// between calls, so replaying a block yields the same candidates with the same IDs.
+type Detector struct{}
+
+// NewDetector builds a detector.
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+// Detect returns the candidates found in released. Events are grouped by block and ordered
+// by log index; released must hold whole blocks, as the finality buffer releases them.
+func (d *Detector) Detect(released []events.Event) []events.Event {
+	blocks := make(map[int64]*block)
+	var order []int64
+	for _, event := range released {
+		number := event.GetBlockNumber()
+		b, exists := blocks[number]
+		if !exists {
+			b = &block{}
+			blocks[number] = b
+			order = append(order, number)
+		}
+		switch typed := event.(type) {
+		case events.SwapEvent:
+			b.swaps = append(b.swaps, typed)
+		case events.MintEvent:
+			b.liquidity = append(b.liquidity, typed)
+		case events.BurnEvent:
+			b.liquidity = append(b.liquidity, typed)
+		}
+	}
+
+	var candidates []events.Event
+	for _, number := range order {
+		b := blocks[number]
+		slices.SortFunc(b.swaps, func(a, b events.SwapEvent) int { return int(a.LogIndex - b.LogIndex) })
+		slices.SortFunc(b.liquidity, func(a, b events.Event) int { return int(logIndexOf(a) - logIndexOf(b)) })
+		candidates = append(candidates, sandwiches(b.swaps)...)
+		candidates = append(candidates, arbitrages(b.swaps)...)
+		candidates = append(candidates, d.jitLiquidity(b)...)
+	}
+	if len(candidates) > 0 {
+		logger.Info("MEV candidates detected", "count", len(candidates), "blocks", len(order))
+	}
+	return candidates
+}
+
+// block is one block's events that patterns are built from; liquidity holds MintEvents and
+// BurnEvents.
+type block struct {
+	swaps     []events.SwapEvent
+	liquidity []events.Event
+}
+
+// sandwiches finds, per pair, a swap followed later in the block by an opposite swap from the
+// same actor in another transaction, with other actors' swaps in the front-run's direction in
+// between. Each swap takes part in at most one sandwich.
+func sandwiches(swaps []events.SwapEvent) []events.Event {
+	var candidates []events.Event
+	used := make(map[int]bool)
+	for i, front := range swaps {
+		if used[i] || !directional(front) {
+			continue
+		}
+		for k := i + 1; k < len(swaps); k++ {
+			back := swaps[k]
+			if used[k] || back.PairAddress != front.PairAddress || actorOf(back) != actorOf(front) ||
+				back.TransactionHash == front.TransactionHash || back.Direction != opposite(front.Direction) {
+				continue
+			}
+			var victims []string
+			for _, victim := range swaps[i+1 : k] {
+				if victim.PairAddress == front.PairAddress && victim.Direction == front.Direction && actorOf(victim) != actorOf(front) {
+					victims = append(victims, victim.TransactionHash)
+				}
+			}
+			if len(victims) == 0 {
+				break
+			}
+			used[i], used[k] = true, true
+
+			candidate := newCandidate(front.BaseEvent, events.MevKindSandwich, actorOf(front))
+			candidate.VictimTxs = slices.Compact(victims)
+			candidate.BackRunTx = &back.TransactionHash
+			candidate.Pairs = []string{front.PairAddress}
+			// The actor sold the front-run's input token and bought it back in the back-run.
+			token, spent, decimals, price := inputSide(front)
+			_, received, _, _ := outputSide(back)
+			setProfit(&candidate, token, new(big.Int).Sub(received, spent), decimals, price)
+			candidates = append(candidates, candidate)
+			break
+		}
+	}
+	return candidates
+}
+
+// arbitrages finds, per actor, runs of swaps through two or more tracked pairs that end in the
+// token their first swap sold. A run spans the actor's swaps in log order, across as many of
+// its transactions as the cycle takes; each swap chains on the token the previous one bought,
+// unless both are in one transaction, whose hops through untracked pools are not seen. A
+// single-transaction arbitrage is the case where the whole run is one transaction. Each swap
+// takes part in at most one arbitrage.
+func arbitrages(swaps []events.SwapEvent) []events.Event {
+	byActor := make(map[string][]events.SwapEvent)
+	var order []string
+	for _, swap := range swaps {
+		if !directional(swap) {
+			continue
+		}
+		actor := strings.ToLower(actorOf(swap))
+		if _, exists := byActor[actor]; !exists {
+			order = append(order, actor)
+		}
+		byActor[actor] = append(byActor[actor], swap)
+	}
+
+	var candidates []events.Event
+	for _, actor := range order {
+		actorSwaps := byActor[actor]
+		used := make(map[int]bool)
+		for i, first := range actorSwaps {
+			if used[i] {
+				continue
+			}
+			token, spent, decimals, price := inputSide(first)
+			held, _, _, _ := outputSide(first)
+			hops := []int{i}
+			for k := i + 1; k < len(actorSwaps); k++ {
+				hop, previous := actorSwaps[k], actorSwaps[hops[len(hops)-1]]
+				hopToken, _, _, _ := inputSide(hop)
+				if used[k] || (!strings.EqualFold(hopToken, held) && hop.TransactionHash != previous.TransactionHash) {
+					continue
+				}
+				hops = append(hops, k)
+				var received *big.Int
+				held, received, _, _ = outputSide(hop)
+				if !strings.EqualFold(held, token) {
+					continue
+				}
+				// The cycle is closed; through a single pair it is a round trip, not an arbitrage.
+				candidate := newCandidate(first.BaseEvent, events.MevKindArbitrage, actorOf(first))
+				candidate.VictimTxs = []string{}
+				for _, h := range hops {
+					if !slices.Contains(candidate.Pairs, actorSwaps[h].PairAddress) {
+						candidate.Pairs = append(candidate.Pairs, actorSwaps[h].PairAddress)
+					}
+				}
+				if len(candidate.Pairs) < 2 {
+					break
+				}
+				if hop.TransactionHash != first.TransactionHash {
+					candidate.BackRunTx = &hop.TransactionHash
+				}
+				for _, h := range hops {
+					used[h] = true
+				}
+				setProfit(&candidate, token, new(big.Int).Sub(received, spent), decimals, price)
+				candidates = append(candidates, candidate)
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+// jitLiquidity finds, per pair, a mint followed later in the block by a burn of the same LP
+// position's provider in another transaction, with other actors' swaps in between. Mints and
+// burns are matched on their Provider, the address the LP tokens were minted to and returned
+// from, rather than on their Sender, which is the router for liquidity added through one.
+// Mints and burns without a known provider are skipped.
+func (d *Detector) jitLiquidity(b *block) []events.Event {
+	var candidates []events.Event
+	for i, event := range b.liquidity {
+		mint, isMint := event.(events.MintEvent)
+		if !isMint || mint.Provider == nil {
+			continue
+		}
+		provider := *mint.Provider
+		for _, later := range b.liquidity[i+1:] {
+			burn, isBurn := later.(events.BurnEvent)
+			if !isBurn || burn.PairAddress != mint.PairAddress || burn.Provider == nil ||
+				!strings.EqualFold(*burn.Provider, provider) || burn.TransactionHash == mint.TransactionHash {
+				continue
+			}
+			var victims []string
+			for _, swap := range b.swaps {
+				if swap.PairAddress == mint.PairAddress && swap.LogIndex > mint.LogIndex && swap.LogIndex < burn.LogIndex &&
+					!strings.EqualFold(actorOf(swap), provider) {
+					victims = append(victims, swap.TransactionHash)
+				}
+			}
+			if len(victims) > 0 {
+				candidate := newCandidate(mint.BaseEvent, events.MevKindJITLiquidity, provider)
+				candidate.VictimTxs = slices.Compact(victims)
+				candidate.BackRunTx = &burn.TransactionHash
+				candidate.Pairs = []string{mint.PairAddress}
+				candidates = append(candidates, candidate)
+			}
+			break
+		}
+	}
+	return candidates
+}
+
+// newCandidate starts a candidate located at the pattern's first event.
+func newCandidate(first events.BaseEvent, kind, actor string) events.MevCandidateEvent {
+	base := first
+	base.EventType = events.EventTypeMevCandidate
+	base.EventID = "mev:" + strings.ToLower(kind) + ":" + first.EventID
+	return events.MevCandidateEvent{
+		BaseEvent:  base,
+		Kind:       kind,
+		Actor:      actor,
+		FrontRunTx: first.TransactionHash,
+	}
+}
+
+func setProfit(candidate *events.MevCandidateEvent, token string, amount *big.Int, decimals *int32, usdPrice *float64) {
+	candidate.ProfitToken = &token
+	profit := amount.String()
+	candidate.ProfitAmount = &profit
+	if decimals != nil && usdPrice != nil {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*decimals)), nil)
+		value, _ := new(big.Rat).SetFrac(amount, scale).Float64()
+		value *= *usdPrice
+		candidate.ProfitUSD = &value
+	}
+}
+
+// actorOf is the address behind a swap: the transaction sender when known, since the swap's
+// own sender is usually a router or the actor's contract.
+func actorOf(swap events.SwapEvent) string {
+	if swap.TxFrom != nil {
+		return *swap.TxFrom
+	}
+	return swap.Sender
+}
+
+func directional(swap events.SwapEvent) bool {
+	return swap.Direction == events.SwapDirectionToken0ToToken1 || swap.Direction == events.SwapDirectionToken1ToToken0
+}
+
+func opposite(direction string) string {
+	switch direction {
+	case events.SwapDirectionToken0ToToken1:
+		return events.SwapDirectionToken1ToToken0
+	case events.SwapDirectionToken1ToToken0:
+		return events.SwapDirectionToken0ToToken1
+	default:
+		return events.SwapDirectionUnknown
+	}
+}
+
+// inputSide returns the token the swap's trader sold, the net amount sold, and the token's
+// decimals and USD price when known.
+func inputSide(swap events.SwapEvent) (string, *big.Int, *int32, *float64) {
+	if swap.Direction == events.SwapDirectionToken0ToToken1 {
+		return swap.Token0, netAmount(swap.Amount0In, swap.Amount0Out), swap.Token0Decimals, swap.Token0PriceUSD
+	}
+	return swap.Token1, netAmount(swap.Amount1In, swap.Amount1Out), swap.Token1Decimals, swap.Token1PriceUSD
+}
+
+// outputSide returns the token the swap's trader bought and the net amount bought.
+func outputSide(swap events.SwapEvent) (string, *big.Int, *int32, *float64) {
+	if swap.Direction == events.SwapDirectionToken0ToToken1 {
+		return swap.Token1, netAmount(swap.Amount1Out, swap.Amount1In), swap.Token1Decimals, swap.Token1PriceUSD
+	}
+	return swap.Token0, netAmount(swap.Amount0Out, swap.Amount0In), swap.Token0Decimals, swap.Token0PriceUSD
+}
+
+// netAmount is plus minus minus; unparsable amounts count as zero.
+func netAmount(plus, minus string) *big.Int {
+	a, _ := new(big.Int).SetString(plus, 10)
+	b, _ := new(big.Int).SetString(minus, 10)
+	if a == nil {
+		a = new(big.Int)
+	}
+	if b == nil {
+		b = new(big.Int)
+	}
+	return a.Sub(a, b)
+}
+
+func logIndexOf(event events.Event) int32 {
+	switch typed := event.(type) {
+	case events.MintEvent:
+		return typed.LogIndex
+	case events.BurnEvent:
+		return typed.LogIndex
+	default:
+		return 0
+	}
+}
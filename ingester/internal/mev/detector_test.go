@@ -0,0 +1,222 @@
+package mev
+
+import (
+	"fmt"
+	"testing"
+
+	"ingester/internal/events"
+)
+
+const (
+	pairA    = "0x00000000000000000000000000000000000000a1"
+	pairB    = "0x00000000000000000000000000000000000000b1"
+	tokenX   = "0x0000000000000000000000000000000000000010"
+	tokenY   = "0x0000000000000000000000000000000000000011"
+	tokenZ   = "0x0000000000000000000000000000000000000012"
+	attacker = "0x00000000000000000000000000000000000000ee"
+	trader   = "0x00000000000000000000000000000000000000cc"
+)
+
+func base(eventType events.EventType, pair, token0, token1 string, logIndex int32, txHash string) events.BaseEvent {
+	return events.BaseEvent{
+		EventType:       eventType,
+		EventID:         fmt.Sprintf("%s:%d", txHash, logIndex),
+		BlockNumber:     100,
+		TransactionHash: txHash,
+		LogIndex:        logIndex,
+		PairAddress:     pair,
+		Token0:          token0,
+		Token1:          token1,
+	}
+}
+
+// swap0For1 sells amountIn of token0 for amountOut of token1; swap1For0 the reverse.
+func swap0For1(pair string, logIndex int32, txHash, from, amountIn, amountOut string) events.SwapEvent {
+	return events.SwapEvent{
+		BaseEvent:  base(events.EventTypeSwap, pair, tokenX, tokenY, logIndex, txHash),
+		TxFrom:     &from,
+		Amount0In:  amountIn,
+		Amount1In:  "0",
+		Amount0Out: "0",
+		Amount1Out: amountOut,
+		Direction:  events.SwapDirectionToken0ToToken1,
+	}
+}
+
+func swap1For0(pair string, logIndex int32, txHash, from, amountIn, amountOut string) events.SwapEvent {
+	return events.SwapEvent{
+		BaseEvent:  base(events.EventTypeSwap, pair, tokenX, tokenY, logIndex, txHash),
+		TxFrom:     &from,
+		Amount0In:  "0",
+		Amount1In:  amountIn,
+		Amount0Out: amountOut,
+		Amount1Out: "0",
+		Direction:  events.SwapDirectionToken1ToToken0,
+	}
+}
+
+func detect(released ...events.Event) []events.MevCandidateEvent {
+	var candidates []events.MevCandidateEvent
+	for _, event := range NewDetector().Detect(released) {
+		candidates = append(candidates, event.(events.MevCandidateEvent))
+	}
+	return candidates
+}
+
+func TestDetect_Sandwich(t *testing.T) {
+	decimals := int32(0)
+	usd := 2.0
+	front := swap0For1(pairA, 1, "0xfront", attacker, "100", "90")
+	front.Token0Decimals, front.Token0PriceUSD = &decimals, &usd
+
+	// Released out of log order: the detector sorts them.
+	candidates := detect(
+		swap1For0(pairA, 3, "0xback", attacker, "90", "110"),
+		swap0For1(pairA, 2, "0xvictim", trader, "50", "40"),
+		front,
+	)
+
+	if len(candidates) != 1 {
+		t.Fatalf("Expected one candidate, got %+v", candidates)
+	}
+	sandwich := candidates[0]
+	if sandwich.Kind != events.MevKindSandwich || sandwich.Actor != attacker || sandwich.EventID != "mev:sandwich:0xfront:1" {
+		t.Errorf("Unexpected candidate %+v", sandwich)
+	}
+	if sandwich.FrontRunTx != "0xfront" || sandwich.BackRunTx == nil || *sandwich.BackRunTx != "0xback" ||
+		len(sandwich.VictimTxs) != 1 || sandwich.VictimTxs[0] != "0xvictim" {
+		t.Errorf("Unexpected transactions %+v", sandwich)
+	}
+	if *sandwich.ProfitToken != tokenX || *sandwich.ProfitAmount != "10" || sandwich.ProfitUSD == nil || *sandwich.ProfitUSD != 20 {
+		t.Errorf("Expected 10 token0 ($20) profit, got %v %v %v", *sandwich.ProfitToken, *sandwich.ProfitAmount, sandwich.ProfitUSD)
+	}
+	if sandwich.EventType != events.EventTypeMevCandidate || sandwich.BlockNumber != 100 {
+		t.Errorf("Unexpected base %+v", sandwich.BaseEvent)
+	}
+}
+
+func TestDetect_NoSandwichWithoutVictim(t *testing.T) {
+	candidates := detect(
+		swap0For1(pairA, 1, "0xfront", attacker, "100", "90"),
+		swap0For1(pairA, 2, "0xother", trader, "50", "40"),
+		swap1For0(pairA, 3, "0xback", trader, "90", "110"), // closed by someone else
+	)
+
+	if len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestDetect_Arbitrage(t *testing.T) {
+	// X -> Y on pair A, then Y -> X on pair B, in one transaction.
+	first := swap0For1(pairA, 4, "0xarb", attacker, "100", "200")
+	second := swap1For0(pairB, 6, "0xarb", attacker, "200", "104")
+	unrelated := swap0For1(pairB, 9, "0xother", trader, "1", "1")
+
+	candidates := detect(first, second, unrelated)
+
+	if len(candidates) != 1 {
+		t.Fatalf("Expected one candidate, got %+v", candidates)
+	}
+	arbitrage := candidates[0]
+	if arbitrage.Kind != events.MevKindArbitrage || arbitrage.BackRunTx != nil || len(arbitrage.VictimTxs) != 0 {
+		t.Errorf("Unexpected candidate %+v", arbitrage)
+	}
+	if len(arbitrage.Pairs) != 2 || arbitrage.Pairs[0] != pairA || arbitrage.Pairs[1] != pairB {
+		t.Errorf("Expected pairs A then B, got %v", arbitrage.Pairs)
+	}
+	if *arbitrage.ProfitAmount != "4" || arbitrage.ProfitUSD != nil {
+		t.Errorf("Expected 4 token0 profit without a USD price, got %v %v", *arbitrage.ProfitAmount, arbitrage.ProfitUSD)
+	}
+}
+
+func TestDetect_ArbitrageAcrossTransactions(t *testing.T) {
+	// X -> Y on pair A in one transaction, then Y -> X on pair B in a later one, with another
+	// trader's swap in between.
+	first := swap0For1(pairA, 4, "0xleg1", attacker, "100", "200")
+	second := swap1For0(pairB, 12, "0xleg2", attacker, "200", "107")
+	between := swap0For1(pairB, 9, "0xother", trader, "1", "1")
+
+	candidates := detect(first, between, second)
+
+	if len(candidates) != 1 {
+		t.Fatalf("Expected one candidate, got %+v", candidates)
+	}
+	arbitrage := candidates[0]
+	if arbitrage.Kind != events.MevKindArbitrage || arbitrage.Actor != attacker || arbitrage.FrontRunTx != "0xleg1" ||
+		arbitrage.BackRunTx == nil || *arbitrage.BackRunTx != "0xleg2" || len(arbitrage.VictimTxs) != 0 {
+		t.Errorf("Unexpected candidate %+v", arbitrage)
+	}
+	if len(arbitrage.Pairs) != 2 || arbitrage.Pairs[0] != pairA || arbitrage.Pairs[1] != pairB {
+		t.Errorf("Expected pairs A then B, got %v", arbitrage.Pairs)
+	}
+	if *arbitrage.ProfitAmount != "7" {
+		t.Errorf("Expected 7 token0 profit, got %v", *arbitrage.ProfitAmount)
+	}
+}
+
+func TestDetect_MultiHopTradeIsNotArbitrage(t *testing.T) {
+	// X -> Y on pair A, then Y -> Z on pair B: the trade ends in another token.
+	second := swap0For1(pairB, 6, "0xroute", trader, "200", "50")
+	second.Token0, second.Token1 = tokenY, tokenZ
+
+	if candidates := detect(swap0For1(pairA, 4, "0xroute", trader, "100", "200"), second); len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestDetect_JITLiquidity(t *testing.T) {
+	const lp = "0x00000000000000000000000000000000000000dd"
+	quickSwapRouter := "0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff"
+	provider := lp
+
+	tests := []struct {
+		name     string
+		sender   string
+		provider *string
+		want     int
+	}{
+		{"own contract", lp, &provider, 1},
+		// The router sends the mint and burn of every LP; the provider tells them apart.
+		{"through a router", quickSwapRouter, &provider, 1},
+		{"unknown provider", lp, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := detect(
+				events.MintEvent{BaseEvent: base(events.EventTypeMint, pairA, tokenX, tokenY, 1, "0xmint"), Sender: tt.sender, Provider: tt.provider},
+				swap0For1(pairA, 2, "0xvictim", trader, "50", "40"),
+				events.BurnEvent{BaseEvent: base(events.EventTypeBurn, pairA, tokenX, tokenY, 3, "0xburn"), Sender: tt.sender, Provider: tt.provider},
+			)
+
+			if len(candidates) != tt.want {
+				t.Fatalf("Expected %d candidates, got %+v", tt.want, candidates)
+			}
+			if tt.want == 0 {
+				return
+			}
+			jit := candidates[0]
+			if jit.Kind != events.MevKindJITLiquidity || jit.Actor != lp || jit.FrontRunTx != "0xmint" || *jit.BackRunTx != "0xburn" ||
+				len(jit.VictimTxs) != 1 || jit.ProfitAmount != nil {
+				t.Errorf("Unexpected candidate %+v", jit)
+			}
+		})
+	}
+}
+
+func TestDetect_JITLiquidityNeedsTheSameProvider(t *testing.T) {
+	quickSwapRouter := "0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff"
+	minter, burner := "0x00000000000000000000000000000000000000d1", "0x00000000000000000000000000000000000000d2"
+
+	// Two LPs adding and removing liquidity through the same router are not one JIT position.
+	candidates := detect(
+		events.MintEvent{BaseEvent: base(events.EventTypeMint, pairA, tokenX, tokenY, 1, "0xmint"), Sender: quickSwapRouter, Provider: &minter},
+		swap0For1(pairA, 2, "0xvictim", trader, "50", "40"),
+		events.BurnEvent{BaseEvent: base(events.EventTypeBurn, pairA, tokenX, tokenY, 3, "0xburn"), Sender: quickSwapRouter, Provider: &burner},
+	)
+
+	if len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %+v", candidates)
+	}
+}
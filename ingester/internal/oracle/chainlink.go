@@ -2,108 +2,215 @@ package oracle
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 
 	"ingester/internal/cache"
+	"ingester/internal/chain"
 	"ingester/internal/contract"
+	apperr "ingester/internal/errors"
 )
 
 // PriceOracle fetches the USD price for a token address.
 type PriceOracle interface {
 	FetchPrice(ctx context.Context, tokenAddr common.Address) (float64, bool)
+	// QuoteAt returns the price as of block with its provenance; chain.Latest reads the current state.
+	QuoteAt(ctx context.Context, tokenAddr common.Address, block chain.Block) (Quote, bool)
 }
 
-// ChainlinkOracle implements PriceOracle using on-chain Chainlink Data Feeds.
+// ChainlinkOracle implements PriceOracle using on-chain Chainlink Data Feeds. Answers from
+// incomplete rounds, non-positive or clamped answers, prices outside the registry bounds, stale
+// rounds and unconfirmed jumps are rejected.
 type ChainlinkOracle struct {
-	caller contract.ContractCaller
+	caller    contract.ContractCaller
+	registry  *Registry
+	bounds    *cache.Cache[common.Address, answerBounds]
+	deviation *deviationGuard
 }
 
-var stablecoinSet = map[common.Address]bool{
-	common.HexToAddress("0x2791bca1f2de4661ed88a30c99a7a9449aa84174"): true, // USDC
-	common.HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f"): true, // USDT
-	common.HexToAddress("0x8f3cf7ad23cd3cadbD9735aff958023239c6a063"): true, // DAI
+// answerBoundsTTL bounds how long a feed keeps using an aggregator's bounds after the proxy
+// is pointed at a new aggregator.
+const answerBoundsTTL = time.Hour
+
+func NewChainlinkOracle(caller contract.ContractCaller, registry *Registry) *ChainlinkOracle {
+	return &ChainlinkOracle{
+		caller:    caller,
+		registry:  registry,
+		bounds:    cache.NewCache[common.Address, answerBounds](answerBoundsTTL),
+		deviation: newDeviationGuard(),
+	}
 }
 
-var chainlinkPriceFeeds = map[common.Address]common.Address{
-	common.HexToAddress("0x0d500b1d8e8ef31e21c99d1db9a6444d3adf1270"): common.HexToAddress("0xab594600376ec9fd91f8e885dadf0ce036862de0"), // WMATIC/USD
-	common.HexToAddress("0x7ceb23fd6bc0add59e62ac25578270cff1b9f619"): common.HexToAddress("0xf9680d99d6c9589e2a93a78a04a279e509205945"), // WETH/USD
-	common.HexToAddress("0x1bfd67037b42cf73acf2047067bd4f2c47d9bfd6"): common.HexToAddress("0xc907e116054ad103354f2d350fd2514433d57f6f"), // WBTC/USD
-	common.HexToAddress("0x2791bca1f2de4661ed88a30c99a7a9449aa84174"): common.HexToAddress("0xfe4a8cc5b5b2366c1b58bea3858e81843581b2f7"), // USDC/USD
-	common.HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f"): common.HexToAddress("0x0a6513e40db6eb1b165753ad52e80663aea50545"), // USDT/USD
-	common.HexToAddress("0x8f3cf7ad23cd3cadbD9735aff958023239c6a063"): common.HexToAddress("0x4746dec9e833a82ec7c2c1356372ccf2cfcd2f3d"), // DAI/USD
+func (o *ChainlinkOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
+	quote, found := o.QuoteAt(ctx, tokenAddress, chain.Latest)
+	return quote.Price, found
 }
 
-func NewChainlinkOracle(caller contract.ContractCaller) *ChainlinkOracle {
-	return &ChainlinkOracle{caller: caller}
+// QuoteAt is Read with the reason logged rather than returned.
+func (o *ChainlinkOracle) QuoteAt(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, bool) {
+	quote, err := o.Read(ctx, tokenAddress, block)
+	if err != nil {
+		var rejection *RejectionError
+		if errors.As(err, &rejection) {
+			logger.Warn("Chainlink answer rejected",
+				"token", tokenAddress.Hex(), "feed", rejection.Aggregator.Hex(), "reason", rejection.Reason, "detail", rejection.Detail)
+		} else {
+			logger.Debug("Chainlink read failed", "token", tokenAddress.Hex(), "block", block.Number, "error", err)
+		}
+		return Quote{}, false
+	}
+	return quote, true
 }
 
-func (o *ChainlinkOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
-	priceFeedAddress, exists := chainlinkPriceFeeds[tokenAddress]
+// Read reads the feed at block and returns a *RejectionError when the answer fails a sanity
+// check. Nodes without archive state cannot serve calls at older blocks; a failed historical
+// call is an error rather than a latest-round answer, which would be cached and reported as
+// the price at block.
+func (o *ChainlinkOracle) Read(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, error) {
+	feed, exists := o.registry.PriceFeed(tokenAddress)
 	if !exists {
-		return 0, false
+		return Quote{}, fmt.Errorf("no Chainlink feed for %s", tokenAddress.Hex())
+	}
+
+	quote, err := o.readFeed(ctx, feed, block)
+	if err != nil {
+		return Quote{}, err
 	}
+	if err := o.deviation.check(feed, quote); err != nil {
+		return Quote{}, err
+	}
+	return quote, nil
+}
 
+func (o *ChainlinkOracle) readFeed(ctx context.Context, feed Feed, block chain.Block) (Quote, error) {
 	aggregatorABI, err := contract.GetABI(contract.ChainlinkAggregator)
 	if err != nil {
-		return 0, false
+		return Quote{}, err
 	}
 
 	var decimals uint8
-	if err := contract.CallContract(ctx, o.caller, priceFeedAddress, aggregatorABI, "decimals", &decimals); err != nil {
-		return 0, false
+	if err := contract.CallContractAt(ctx, o.caller, feed.Aggregator, aggregatorABI, "decimals", block.BigNumber(), &decimals); err != nil {
+		return Quote{}, err
 	}
 
 	data, err := aggregatorABI.Pack("latestRoundData")
 	if err != nil {
-		return 0, false
+		return Quote{}, err
 	}
 
 	responseData, err := o.caller.CallContract(ctx, ethereum.CallMsg{
-		To:   &priceFeedAddress,
+		To:   &feed.Aggregator,
 		Data: data,
-	}, nil)
+	}, block.BigNumber())
 	if err != nil {
-		return 0, false
+		return Quote{}, err
 	}
 
 	values, err := aggregatorABI.Unpack("latestRoundData", responseData)
 	if err != nil {
-		return 0, false
+		return Quote{}, err
 	}
 
 	if len(values) != 5 {
-		return 0, false
+		return Quote{}, fmt.Errorf("latestRoundData returned %d values", len(values))
 	}
 
+	roundID := values[0].(*big.Int)
 	answer := values[1].(*big.Int)
 	updatedAt := values[3].(*big.Int)
+	answeredInRound := values[4].(*big.Int)
+
+	bounds, _ := o.bounds.GetOrFetch(ctx, feed.Aggregator, func(ctx context.Context, proxy common.Address) (answerBounds, bool) {
+		return fetchAnswerBounds(ctx, o.caller, proxy)
+	})
+	if err := checkRound(feed, bounds, roundID, answer, updatedAt, answeredInRound); err != nil {
+		return Quote{}, err
+	}
 
-	// Reject stale prices (>24h old)
-	if time.Now().Unix()-updatedAt.Int64() > 24*60*60 {
-		return 0, false
+	quote := Quote{
+		Price:     priceWithDecimals(answer, decimals),
+		Source:    PriceSourceChainlink,
+		RoundID:   roundID,
+		UpdatedAt: time.Unix(updatedAt.Int64(), 0),
+	}
+	if err := checkPrice(feed, quote, block.Now()); err != nil {
+		return Quote{}, err
+	}
+	return quote, nil
+}
+
+// ValidateFeeds checks that every registered aggregator reports the description() the registry
+// expects, catching a feed address pasted against the wrong token. Comparison ignores case and
+// whitespace. Feeds without an expected description are skipped. All mismatches are joined.
+// A registry loaded for a chain that neither feeds.json nor the registry file lists (such as
+// Amoy, 80002) has nothing to validate and fails with a ConfigError saying so.
+func ValidateFeeds(ctx context.Context, caller contract.ContractCaller, registry *Registry) error {
+	if registry.missingChain != "" {
+		return &apperr.ConfigError{Message: fmt.Sprintf(
+			"feed registry has no entry for chain %s: no stablecoins or Chainlink feeds are known, so swaps get no USD value; add chains.%q to the feed registry file",
+			registry.missingChain, registry.missingChain,
+		)}
+	}
+
+	aggregatorABI, err := contract.GetABI(contract.ChainlinkAggregator)
+	if err != nil {
+		return &apperr.ConfigError{Message: "load Chainlink aggregator ABI", Cause: err}
+	}
+
+	feeds := registry.Feeds()
+	tokens := make(map[common.Address]bool, len(feeds))
+	for token := range feeds {
+		tokens[token] = true
 	}
 
-	return priceWithDecimals(answer, decimals), true
+	var errs []error
+	for _, token := range sortedAddresses(tokens) {
+		feed := feeds[token]
+		if feed.Description == "" {
+			continue
+		}
+		var description string
+		if err := contract.CallContract(ctx, caller, feed.Aggregator, aggregatorABI, "description", &description); err != nil {
+			errs = append(errs, &apperr.ConfigError{
+				Message: fmt.Sprintf("feed %s for %s %s: read description", feed.Aggregator.Hex(), feed.Symbol, token.Hex()),
+				Cause:   err,
+			})
+			continue
+		}
+		if normalizeDescription(description) != normalizeDescription(feed.Description) {
+			errs = append(errs, &apperr.ConfigError{Message: fmt.Sprintf(
+				"feed %s for %s %s reports %q, expected %q",
+				feed.Aggregator.Hex(), feed.Symbol, token.Hex(), description, feed.Description,
+			)})
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// GetTokenUSDPrice resolves price via: stablecoin shortcut -> cache -> oracle.
+// GetTokenUSDPrice resolves a quote via: stablecoin shortcut -> cache -> oracle, as of block.
 func GetTokenUSDPrice(
 	ctx context.Context,
 	tokenAddress common.Address,
-	priceCache *cache.Cache[common.Address, float64],
+	block chain.Block,
+	registry *Registry,
+	priceCache *PriceCache,
 	oracle PriceOracle,
-) (float64, bool) {
-	if stablecoinSet[tokenAddress] {
-		return 1.0, true
+) (Quote, bool) {
+	if registry.IsStablecoin(tokenAddress) {
+		return stablecoinQuote(), true
 	}
 
-	return priceCache.GetOrFetch(ctx, tokenAddress, func(ctx context.Context, addr common.Address) (float64, bool) {
-		return oracle.FetchPrice(ctx, addr)
-	})
+	return priceCache.GetOrFetch(ctx, tokenAddress, block, oracle.QuoteAt)
+}
+
+func normalizeDescription(description string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(description), ""))
 }
 
 func priceWithDecimals(answer *big.Int, decimals uint8) float64 {
@@ -1,23 +1,26 @@
 package oracle
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 
-	"ingester/internal/cache"
+	"ingester/internal/chain"
 	"ingester/internal/contract"
-	"ingester/internal/oracle/mocks"
+	apperr "ingester/internal/errors"
 )
 
 func TestGetTokenUSDPrice_StablecoinOptimization(t *testing.T) {
-	priceCache := cache.NewCache[common.Address, float64](5 * time.Minute)
-	mockOracle := mocks.NewMockPriceOracle(t)
+	priceCache := NewPriceCache(5*time.Minute, 1)
+	mockOracle := NewMockPriceOracle(t)
 
 	stablecoins := []struct {
 		name string
@@ -31,66 +34,66 @@ func TestGetTokenUSDPrice_StablecoinOptimization(t *testing.T) {
 	for _, tt := range stablecoins {
 		t.Run(tt.name, func(t *testing.T) {
 			addr := common.HexToAddress(tt.addr)
-			price, found := GetTokenUSDPrice(context.Background(), addr, priceCache, mockOracle)
+			quote, found := GetTokenUSDPrice(context.Background(), addr, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
 
 			if !found {
 				t.Errorf("Stablecoin %s should always be found", tt.name)
 			}
-			if price != 1.0 {
-				t.Errorf("Stablecoin %s should be $1.0, got %f", tt.name, price)
+			if quote.Price != 1.0 || quote.Source != PriceSourceStablecoin {
+				t.Errorf("Stablecoin %s should be a $1.0 stablecoin quote, got %+v", tt.name, quote)
 			}
 		})
 	}
-	mockOracle.AssertNotCalled(t, "FetchPrice")
+	mockOracle.AssertNotCalled(t, "QuoteAt")
 }
 
 func TestGetTokenUSDPrice_NonStablecoin(t *testing.T) {
-	priceCache := cache.NewCache[common.Address, float64](5 * time.Minute)
-	mockOracle := mocks.NewMockPriceOracle(t)
+	priceCache := NewPriceCache(5*time.Minute, 1)
+	mockOracle := NewMockPriceOracle(t)
 
 	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
-	mockOracle.EXPECT().FetchPrice(context.Background(), wmatic).Return(0.85, true).Once()
+	mockOracle.EXPECT().QuoteAt(context.Background(), wmatic, chain.Latest).Return(Quote{Price: 0.85, Source: PriceSourceChainlink}, true).Once()
 
-	price, found := GetTokenUSDPrice(context.Background(), wmatic, priceCache, mockOracle)
+	quote, found := GetTokenUSDPrice(context.Background(), wmatic, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
 
 	if !found {
 		t.Error("Expected to find price")
 	}
-	if price != 0.85 {
-		t.Errorf("Expected $0.85, got $%f", price)
+	if quote.Price != 0.85 {
+		t.Errorf("Expected $0.85, got $%f", quote.Price)
 	}
 }
 
 func TestGetTokenUSDPrice_Caching(t *testing.T) {
-	priceCache := cache.NewCache[common.Address, float64](5 * time.Minute)
-	mockOracle := mocks.NewMockPriceOracle(t)
+	priceCache := NewPriceCache(5*time.Minute, 1)
+	mockOracle := NewMockPriceOracle(t)
 
 	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
-	mockOracle.EXPECT().FetchPrice(context.Background(), wmatic).Return(0.85, true).Once()
+	mockOracle.EXPECT().QuoteAt(context.Background(), wmatic, chain.Latest).Return(Quote{Price: 0.85, Source: PriceSourceChainlink}, true).Once()
 
-	price1, _ := GetTokenUSDPrice(context.Background(), wmatic, priceCache, mockOracle)
-	price2, _ := GetTokenUSDPrice(context.Background(), wmatic, priceCache, mockOracle)
+	price1, _ := GetTokenUSDPrice(context.Background(), wmatic, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
+	price2, _ := GetTokenUSDPrice(context.Background(), wmatic, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
 
 	if price1 != price2 {
-		t.Errorf("Prices should match: %f vs %f", price1, price2)
+		t.Errorf("Prices should match: %+v vs %+v", price1, price2)
 	}
-	mockOracle.AssertNumberOfCalls(t, "FetchPrice", 1)
+	mockOracle.AssertNumberOfCalls(t, "QuoteAt", 1)
 }
 
 func TestGetTokenUSDPrice_UnknownToken(t *testing.T) {
-	priceCache := cache.NewCache[common.Address, float64](5 * time.Minute)
-	mockOracle := mocks.NewMockPriceOracle(t)
+	priceCache := NewPriceCache(5*time.Minute, 1)
+	mockOracle := NewMockPriceOracle(t)
 
 	unknown := common.HexToAddress("0x0000000000000000000000000000000000000001")
-	mockOracle.EXPECT().FetchPrice(context.Background(), unknown).Return(0.0, false).Once()
+	mockOracle.EXPECT().QuoteAt(context.Background(), unknown, chain.Latest).Return(Quote{}, false).Once()
 
-	price, found := GetTokenUSDPrice(context.Background(), unknown, priceCache, mockOracle)
+	quote, found := GetTokenUSDPrice(context.Background(), unknown, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
 
 	if found {
 		t.Error("Unknown token should not be found")
 	}
-	if price != 0 {
-		t.Errorf("Price should be 0, got %f", price)
+	if quote.Price != 0 {
+		t.Errorf("Price should be 0, got %f", quote.Price)
 	}
 }
 
@@ -99,7 +102,7 @@ func TestChainlinkOracle_FetchPrice_RpcError(t *testing.T) {
 		return nil, fmt.Errorf("rpc error")
 	})
 
-	o := NewChainlinkOracle(caller)
+	o := NewChainlinkOracle(caller, polygonRegistry(t))
 	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
 	_, found := o.FetchPrice(context.Background(), wmatic)
 
@@ -147,19 +150,149 @@ func TestGetTokenUSDPrice_MultipleBehaviors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			priceCache := cache.NewCache[common.Address, float64](5 * time.Minute)
-			mockOracle := mocks.NewMockPriceOracle(t)
-			mockOracle.EXPECT().FetchPrice(context.Background(), wmatic).Return(tt.price, tt.found).Maybe()
+			priceCache := NewPriceCache(5*time.Minute, 1)
+			mockOracle := NewMockPriceOracle(t)
+			mockOracle.EXPECT().QuoteAt(context.Background(), wmatic, chain.Latest).Return(Quote{Price: tt.price}, tt.found).Maybe()
 
-			price, found := GetTokenUSDPrice(context.Background(), wmatic, priceCache, mockOracle)
+			quote, found := GetTokenUSDPrice(context.Background(), wmatic, chain.Latest, polygonRegistry(t), priceCache, mockOracle)
 
 			if found != tt.expectedFound {
 				t.Errorf("Expected found=%v, got %v", tt.expectedFound, found)
 			}
-			if price != tt.expectedPrice {
-				t.Errorf("Expected price=%f, got %f", tt.expectedPrice, price)
+			if quote.Price != tt.expectedPrice {
+				t.Errorf("Expected price=%f, got %f", tt.expectedPrice, quote.Price)
 			}
 		})
 	}
 }
 
+// aggregatorCaller answers decimals/latestRoundData/description the way a Chainlink aggregator would.
+func aggregatorCaller(t *testing.T, description string, updatedAt time.Time) contract.ContractCaller {
+	t.Helper()
+	aggregatorABI, err := contract.GetABI(contract.ChainlinkAggregator)
+	if err != nil {
+		t.Fatalf("load ABI: %v", err)
+	}
+	return contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		method, err := aggregatorABI.MethodById(msg.Data[:4])
+		if err != nil {
+			return nil, err
+		}
+		switch method.Name {
+		case "decimals":
+			return method.Outputs.Pack(uint8(8))
+		case "description":
+			return method.Outputs.Pack(description)
+		case "latestRoundData":
+			return method.Outputs.Pack(big.NewInt(1), big.NewInt(85000000), big.NewInt(updatedAt.Unix()), big.NewInt(updatedAt.Unix()), big.NewInt(1))
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	})
+}
+
+func TestChainlinkOracle_FetchPrice_PerFeedHeartbeat(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{
+		testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour},
+		testTokenB: {Aggregator: testFeed2, Heartbeat: 24 * time.Hour},
+	}, nil)
+	o := NewChainlinkOracle(aggregatorCaller(t, "", time.Now().Add(-2*time.Hour)), registry)
+
+	if _, found := o.FetchPrice(context.Background(), testTokenA); found {
+		t.Error("Answer older than a 1h heartbeat should be stale")
+	}
+	price, found := o.FetchPrice(context.Background(), testTokenB)
+	if !found || price != 0.85 {
+		t.Errorf("Expected $0.85 within a 24h heartbeat, got %f (found=%v)", price, found)
+	}
+}
+
+func TestChainlinkOracle_QuoteAt_StalenessAsOfBlock(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour}}, nil)
+	updatedAt := time.Now().Add(-72 * time.Hour)
+	aggregatorABI, _ := contract.GetABI(contract.ChainlinkAggregator)
+	var blocks []*big.Int
+	inner := aggregatorCaller(t, "", updatedAt)
+	caller := contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		if bytes.Equal(msg.Data[:4], aggregatorABI.Methods["latestRoundData"].ID) {
+			blocks = append(blocks, blockNumber)
+		}
+		return inner.CallContract(ctx, msg, blockNumber)
+	})
+
+	o := NewChainlinkOracle(caller, registry)
+	quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Block{Number: 500, Time: updatedAt.Add(10 * time.Minute)})
+
+	if !found || quote.Price != 0.85 {
+		t.Errorf("Answer 10 minutes old at the swap's block should be fresh, got %f (found=%v)", quote.Price, found)
+	}
+	if quote.Source != PriceSourceChainlink || quote.RoundID.Int64() != 1 || quote.UpdatedAt.Unix() != updatedAt.Unix() {
+		t.Errorf("Expected Chainlink round 1 updated at %d, got %+v", updatedAt.Unix(), quote)
+	}
+	if len(blocks) != 1 || blocks[0] == nil || blocks[0].Int64() != 500 {
+		t.Errorf("Expected the round read at block 500, got %v", blocks)
+	}
+}
+
+func TestChainlinkOracle_QuoteAt_NoLatestFallbackForHistoricalBlock(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour}}, nil)
+	inner := aggregatorCaller(t, "", time.Now())
+	// A pruned node rejects calls at anything but the head.
+	caller := contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		if blockNumber != nil {
+			return nil, fmt.Errorf("missing trie node")
+		}
+		return inner.CallContract(ctx, msg, blockNumber)
+	})
+
+	o := NewChainlinkOracle(caller, registry)
+	if quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Block{Number: 500, Time: time.Now().Add(-time.Minute)}); found {
+		t.Errorf("Expected no quote when the historical round cannot be read, got %+v", quote)
+	}
+	if quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest); !found || quote.Price != 0.85 {
+		t.Errorf("Expected the latest round of $0.85, got %f (found=%v)", quote.Price, found)
+	}
+}
+
+func TestGetTokenUSDPrice_BlockBuckets(t *testing.T) {
+	priceCache := NewPriceCache(5*time.Minute, 30)
+	mockOracle := NewMockPriceOracle(t)
+
+	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
+	mockOracle.EXPECT().QuoteAt(context.Background(), wmatic, chain.Block{Number: 60}).Return(Quote{Price: 0.80, Source: PriceSourceChainlink}, true).Once()
+	mockOracle.EXPECT().QuoteAt(context.Background(), wmatic, chain.Block{Number: 150}).Return(Quote{Price: 0.90, Source: PriceSourceChainlink}, true).Once()
+
+	// Blocks 60-89 share a bucket; block 150 is in another one, past the bucket after the tip.
+	for _, number := range []uint64{60, 75, 89} {
+		if quote, _ := GetTokenUSDPrice(context.Background(), wmatic, chain.Block{Number: number}, polygonRegistry(t), priceCache, mockOracle); quote.Price != 0.80 {
+			t.Errorf("Block %d: expected $0.80, got %f", number, quote.Price)
+		}
+	}
+	if quote, _ := GetTokenUSDPrice(context.Background(), wmatic, chain.Block{Number: 150}, polygonRegistry(t), priceCache, mockOracle); quote.Price != 0.90 {
+		t.Errorf("Block 150: expected $0.90, got %f", quote.Price)
+	}
+
+	priceCache.Invalidate([]common.Address{wmatic})
+	if priceCache.Size() != 0 {
+		t.Errorf("Expected every bucket dropped, %d left", priceCache.Size())
+	}
+}
+
+func TestValidateFeeds(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{
+		testTokenA: {Aggregator: testFeed1, Symbol: "WETH", Description: "ETH / USD"},
+		testTokenB: {Aggregator: testFeed2, Symbol: "NONE"},
+	}, nil)
+
+	if err := ValidateFeeds(context.Background(), aggregatorCaller(t, "eth/usd", time.Now()), registry); err != nil {
+		t.Errorf("Expected case/whitespace-insensitive match, got %v", err)
+	}
+
+	err := ValidateFeeds(context.Background(), aggregatorCaller(t, "BTC / USD", time.Now()), registry)
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError for mismatched description, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "WETH") || strings.Contains(err.Error(), "NONE") {
+		t.Errorf("Expected only the WETH feed to be reported, got %v", err)
+	}
+}
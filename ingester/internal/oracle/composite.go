@@ -0,0 +1,173 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+	apperr "ingester/internal/errors"
+)
+
+// Mode selects how a CompositeOracle combines its sources.
+type Mode string
+
+const (
+	// ModePriority returns the first source that has a price.
+	ModePriority Mode = "priority"
+	// ModeConsensus asks every source, drops outliers and returns the agreeing quote closest
+	// to the median price.
+	ModeConsensus Mode = "consensus"
+)
+
+// DefaultMaxSpread is how far, as a fraction of the median, a consensus quote may sit before
+// it is treated as an outlier.
+const DefaultMaxSpread = 0.05
+
+// CompositeOracle chains several PriceOracles. In consensus mode a price needs a strict
+// majority of the answering sources within maxSpread of their median, so one misbehaving
+// source is outvoted and two disagreeing sources produce no price. The returned quote is the
+// agreeing source's own, the one closest to the median, so its price is one a source actually
+// reported and Quote.Source names that source. With an even number of quotes the median lies
+// between the two middle ones; when both are equally close the earlier source wins.
+type CompositeOracle struct {
+	mode      Mode
+	maxSpread float64
+	sources   []PriceOracle
+}
+
+// NewCompositeOracle queries sources in order. A maxSpread of zero uses DefaultMaxSpread.
+func NewCompositeOracle(mode Mode, maxSpread float64, sources ...PriceOracle) *CompositeOracle {
+	if maxSpread <= 0 {
+		maxSpread = DefaultMaxSpread
+	}
+	return &CompositeOracle{mode: mode, maxSpread: maxSpread, sources: sources}
+}
+
+// Settings configures the oracle stack built by New.
+type Settings struct {
+	Mode             Mode
+	MaxSpread        float64
+	PriceFile        string // optional offline CSV, see FileOracle
+	TWAPWindowBlocks uint64 // reference-pool TWAP window, see TWAPOracle; 0 disables
+}
+
+// Client is the RPC access New needs; *ethclient.Client satisfies it.
+type Client interface {
+	contract.ContractCaller
+	HeaderReader
+}
+
+// New builds the production oracle: registry overrides first, then the live sources (Chainlink
+// feeds with reference-pool routing, reference-pool TWAPs and the price file, when enabled)
+// combined per settings.
+func New(client Client, registry *Registry, settings Settings) (*CompositeOracle, error) {
+	routing := NewRoutingOracle(client, registry, NewChainlinkOracle(client, registry))
+	sources := []PriceOracle{routing}
+	if settings.TWAPWindowBlocks > 0 {
+		sources = append(sources, NewTWAPOracle(client, client, registry, routing, settings.TWAPWindowBlocks))
+	}
+	if settings.PriceFile != "" {
+		file, err := LoadPriceFile(settings.PriceFile)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, file)
+	}
+
+	mode := settings.Mode
+	switch mode {
+	case "":
+		mode = ModePriority
+	case ModePriority, ModeConsensus:
+	default:
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("unknown oracle mode %q", mode)}
+	}
+
+	live := NewCompositeOracle(mode, settings.MaxSpread, sources...)
+	return NewCompositeOracle(ModePriority, 0, NewOverrideOracle(registry), live), nil
+}
+
+func (o *CompositeOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
+	quote, found := o.QuoteAt(ctx, tokenAddress, chain.Latest)
+	return quote.Price, found
+}
+
+func (o *CompositeOracle) QuoteAt(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, bool) {
+	if o.mode != ModeConsensus {
+		for _, source := range o.sources {
+			if quote, found := source.QuoteAt(ctx, tokenAddress, block); found && quote.Price > 0 {
+				return quote, true
+			}
+		}
+		return Quote{}, false
+	}
+
+	var quotes []Quote
+	for _, source := range o.sources {
+		if quote, found := source.QuoteAt(ctx, tokenAddress, block); found && quote.Price > 0 {
+			quotes = append(quotes, quote)
+		}
+	}
+	return o.consensus(tokenAddress, quotes)
+}
+
+func (o *CompositeOracle) consensus(token common.Address, quotes []Quote) (Quote, bool) {
+	if len(quotes) == 0 {
+		return Quote{}, false
+	}
+
+	median := medianPrice(quotes)
+	var agreeing, outliers []Quote
+	for _, quote := range quotes {
+		if relativeChange(median, quote.Price) <= o.maxSpread {
+			agreeing = append(agreeing, quote)
+		} else {
+			outliers = append(outliers, quote)
+		}
+	}
+
+	if 2*len(agreeing) <= len(quotes) {
+		logger.Warn("Price sources disagree, leaving token unpriced",
+			"token", token.Hex(), "quotes", describeQuotes(quotes), "maxSpread", o.maxSpread)
+		return Quote{}, false
+	}
+	if len(outliers) > 0 {
+		logger.Warn("Dropping outlier prices",
+			"token", token.Hex(), "median", median, "outliers", describeQuotes(outliers), "maxSpread", o.maxSpread)
+	}
+
+	// Strictly closer only, so ties keep the earlier source.
+	closest := agreeing[0]
+	for _, quote := range agreeing[1:] {
+		if math.Abs(quote.Price-median) < math.Abs(closest.Price-median) {
+			closest = quote
+		}
+	}
+	return closest, true
+}
+
+func medianPrice(quotes []Quote) float64 {
+	prices := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		prices[i] = quote.Price
+	}
+	sort.Float64s(prices)
+	middle := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[middle-1] + prices[middle]) / 2
+	}
+	return prices[middle]
+}
+
+func describeQuotes(quotes []Quote) []string {
+	described := make([]string, len(quotes))
+	for i, quote := range quotes {
+		described[i] = fmt.Sprintf("%s=%g", quote.Source, quote.Price)
+	}
+	return described
+}
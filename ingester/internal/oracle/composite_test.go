@@ -0,0 +1,92 @@
+package oracle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/mock"
+
+	"ingester/internal/chain"
+)
+
+// quoting returns a mock source that answers price (or nothing when price is zero) for any token.
+func quoting(t *testing.T, source PriceSource, price float64) *MockPriceOracle {
+	t.Helper()
+	oracle := NewMockPriceOracle(t)
+	oracle.EXPECT().QuoteAt(mock.Anything, mock.Anything, mock.Anything).Return(Quote{Price: price, Source: source}, price > 0).Maybe()
+	return oracle
+}
+
+func TestCompositeOracle_PriorityFallsThrough(t *testing.T) {
+	first := quoting(t, PriceSourceChainlink, 0)
+	second := quoting(t, PriceSourcePriceFile, 0.9)
+	third := NewMockPriceOracle(t)
+
+	o := NewCompositeOracle(ModePriority, 0, first, second, third)
+	quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest)
+
+	if !found || quote.Price != 0.9 || quote.Source != PriceSourcePriceFile {
+		t.Errorf("Expected the price file's $0.9, got %+v (found=%v)", quote, found)
+	}
+	third.AssertNotCalled(t, "QuoteAt")
+}
+
+func TestCompositeOracle_ConsensusDropsOutlier(t *testing.T) {
+	o := NewCompositeOracle(ModeConsensus, 0.05,
+		quoting(t, PriceSourceChainlink, 1.00),
+		quoting(t, PriceSourceReferencePool, 5.00),
+		quoting(t, PriceSourcePriceFile, 1.02),
+	)
+
+	quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest)
+
+	if !found || quote.Source != PriceSourcePriceFile || quote.Price != 1.02 {
+		t.Errorf("Expected the median source's $1.02, got %+v (found=%v)", quote, found)
+	}
+}
+
+func TestCompositeOracle_ConsensusEvenCountPicksEarlierOfTheMiddleQuotes(t *testing.T) {
+	// Median of 1.0, 1.03125, 1.0625 and 4.0 is 1.046875, equally far from both middle quotes.
+	o := NewCompositeOracle(ModeConsensus, 0.05,
+		quoting(t, PriceSourceChainlink, 1.0625),
+		quoting(t, PriceSourceReferencePool, 1.03125),
+		quoting(t, PriceSourcePriceFile, 1.0),
+		quoting(t, PriceSourcePoolTWAP, 4.0),
+	)
+
+	quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest)
+
+	if !found || quote.Source != PriceSourceChainlink || quote.Price != 1.0625 {
+		t.Errorf("Expected the earlier middle source's $1.0625, not the median $1.046875, got %+v (found=%v)", quote, found)
+	}
+}
+
+func TestCompositeOracle_ConsensusNeedsMajority(t *testing.T) {
+	o := NewCompositeOracle(ModeConsensus, 0.05,
+		quoting(t, PriceSourceChainlink, 1.00),
+		quoting(t, PriceSourceReferencePool, 2.00),
+	)
+
+	if quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest); found {
+		t.Errorf("Two disagreeing sources should leave the token unpriced, got %+v", quote)
+	}
+}
+
+func TestNew_OverridesWinAndModeIsValidated(t *testing.T) {
+	registry := NewRegistry(nil, nil, nil)
+	registry.SetOverrides(map[common.Address]float64{testTokenA: 0.25})
+
+	o, err := New(rpcClient{poolCaller(t, nil, nil), headerReader{}}, registry, Settings{Mode: ModeConsensus})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Latest)
+	if !found || quote.Price != 0.25 || quote.Source != PriceSourceOverride {
+		t.Errorf("Expected the $0.25 override, got %+v (found=%v)", quote, found)
+	}
+
+	if _, err := New(rpcClient{poolCaller(t, nil, nil), headerReader{}}, registry, Settings{Mode: "average"}); err == nil {
+		t.Error("Expected unknown mode to be rejected")
+	}
+}
@@ -1,9 +1,10 @@
 // Code generated by mockery v2.53.6. DO NOT EDIT.
 
-package mocks
+package oracle
 
 import (
 	context "context"
+	chain "ingester/internal/chain"
 
 	common "github.com/ethereum/go-ethereum/common"
 
@@ -80,6 +81,64 @@ func (_c *MockPriceOracle_FetchPrice_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// QuoteAt provides a mock function with given fields: ctx, tokenAddr, block
+func (_m *MockPriceOracle) QuoteAt(ctx context.Context, tokenAddr common.Address, block chain.Block) (Quote, bool) {
+	ret := _m.Called(ctx, tokenAddr, block)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QuoteAt")
+	}
+
+	var r0 Quote
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(context.Context, common.Address, chain.Block) (Quote, bool)); ok {
+		return rf(ctx, tokenAddr, block)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, common.Address, chain.Block) Quote); ok {
+		r0 = rf(ctx, tokenAddr, block)
+	} else {
+		r0 = ret.Get(0).(Quote)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, common.Address, chain.Block) bool); ok {
+		r1 = rf(ctx, tokenAddr, block)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockPriceOracle_QuoteAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QuoteAt'
+type MockPriceOracle_QuoteAt_Call struct {
+	*mock.Call
+}
+
+// QuoteAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenAddr common.Address
+//   - block chain.Block
+func (_e *MockPriceOracle_Expecter) QuoteAt(ctx interface{}, tokenAddr interface{}, block interface{}) *MockPriceOracle_QuoteAt_Call {
+	return &MockPriceOracle_QuoteAt_Call{Call: _e.mock.On("QuoteAt", ctx, tokenAddr, block)}
+}
+
+func (_c *MockPriceOracle_QuoteAt_Call) Run(run func(ctx context.Context, tokenAddr common.Address, block chain.Block)) *MockPriceOracle_QuoteAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(common.Address), args[2].(chain.Block))
+	})
+	return _c
+}
+
+func (_c *MockPriceOracle_QuoteAt_Call) Return(_a0 Quote, _a1 bool) *MockPriceOracle_QuoteAt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPriceOracle_QuoteAt_Call) RunAndReturn(run func(context.Context, common.Address, chain.Block) (Quote, bool)) *MockPriceOracle_QuoteAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockPriceOracle creates a new instance of MockPriceOracle. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockPriceOracle(t interface {
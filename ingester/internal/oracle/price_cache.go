@@ -0,0 +1,125 @@
+package oracle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/cache"
+	"ingester/internal/chain"
+)
+
+// PriceCache caches USD quotes per token and bucket of consecutive blocks, so one oracle call
+// serves every swap in a bucket while backfilled or delayed swaps still get the price of their
+// own block range rather than the latest one.
+//
+// At the chain tip, the first lookup in a token's next bucket does not wait on the oracle: it
+// is answered with the token's newest cached bucket while the next one is fetched in the
+// background. Lookups further ahead, or behind the newest bucket, are fetched as usual.
+type PriceCache struct {
+	entries      *cache.Cache[priceKey, Quote]
+	bucketBlocks uint64
+
+	mu   sync.Mutex
+	tips map[common.Address]uint64 // newest bucket with a found quote, per token
+}
+
+// priceKey fields are exported so persisted entries round-trip through JSON.
+type priceKey struct {
+	Token  common.Address
+	Bucket uint64
+	Latest bool `json:",omitempty"` // chain.Latest, kept apart from the buckets of real blocks
+}
+
+// NewPriceCache buckets blocks into windows of bucketBlocks (minimum 1).
+// Latest lookups share one entry of their own.
+func NewPriceCache(ttl time.Duration, bucketBlocks uint64) *PriceCache {
+	return newPriceCache(cache.NewCache[priceKey, Quote](ttl), bucketBlocks)
+}
+
+// NewPriceCacheWith is NewPriceCache configured by opts. With opts.Storage set, quotes survive
+// a restart until their TTL runs out. opts.SoftTTL only applies to latest quotes: a past
+// block's price does not change, so refreshing it would only repeat the oracle call.
+func NewPriceCacheWith(ttl time.Duration, bucketBlocks uint64, opts cache.Options) (*PriceCache, error) {
+	entries, err := cache.NewCacheWith[priceKey, Quote](ttl, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newPriceCache(entries, bucketBlocks), nil
+}
+
+func newPriceCache(entries *cache.Cache[priceKey, Quote], bucketBlocks uint64) *PriceCache {
+	if bucketBlocks == 0 {
+		bucketBlocks = 1
+	}
+	entries.LimitSoftTTL(func(key priceKey) bool { return key.Latest })
+	return &PriceCache{entries: entries, bucketBlocks: bucketBlocks, tips: make(map[common.Address]uint64)}
+}
+
+// GetOrFetch returns the cached quote for token in block's bucket or calls fetch on miss.
+func (c *PriceCache) GetOrFetch(
+	ctx context.Context,
+	token common.Address,
+	block chain.Block,
+	fetch func(context.Context, common.Address, chain.Block) (Quote, bool),
+) (Quote, bool) {
+	if block == chain.Latest {
+		return c.entries.GetOrFetch(ctx, priceKey{Token: token, Latest: true}, func(ctx context.Context, key priceKey) (Quote, bool) {
+			return fetch(ctx, key.Token, block)
+		})
+	}
+
+	key := priceKey{Token: token, Bucket: block.Number / c.bucketBlocks}
+	fetchBucket := func(ctx context.Context, key priceKey) (Quote, bool) {
+		quote, found := fetch(ctx, key.Token, block)
+		if found {
+			c.advanceTip(key)
+		}
+		return quote, found
+	}
+	if tip, exists := c.tip(token); exists && key.Bucket == tip+1 {
+		return c.entries.GetOrFetchOr(ctx, key, priceKey{Token: token, Bucket: tip}, fetchBucket)
+	}
+	return c.entries.GetOrFetch(ctx, key, fetchBucket)
+}
+
+func (c *PriceCache) tip(token common.Address) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tip, exists := c.tips[token]
+	return tip, exists
+}
+
+func (c *PriceCache) advanceTip(key priceKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tip, exists := c.tips[key.Token]; !exists || key.Bucket > tip {
+		c.tips[key.Token] = key.Bucket
+	}
+}
+
+// Invalidate drops every cached bucket for the given tokens. Fetches already running when it
+// is called do not store their result.
+func (c *PriceCache) Invalidate(tokens []common.Address) {
+	drop := make(map[common.Address]bool, len(tokens))
+	for _, token := range tokens {
+		drop[token] = true
+	}
+	c.mu.Lock()
+	for token := range drop {
+		delete(c.tips, token)
+	}
+	c.mu.Unlock()
+	c.entries.EvictFunc(func(key priceKey) bool { return drop[key.Token] })
+}
+
+// StartJanitor drops expired quotes every interval until ctx ends.
+func (c *PriceCache) StartJanitor(ctx context.Context, interval time.Duration) {
+	c.entries.StartJanitor(ctx, interval)
+}
+
+func (c *PriceCache) Size() int {
+	return c.entries.Size()
+}
@@ -0,0 +1,86 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/cache"
+	"ingester/internal/chain"
+)
+
+func TestPersistentPriceCache_RestoresQuoteProvenance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.cache")
+	block := chain.Block{Number: 1234}
+	quote := Quote{Price: 0.5, Source: PriceSourceChainlink, RoundID: big.NewInt(7), UpdatedAt: time.Unix(1_700_000_000, 0)}
+
+	storage, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	first, err := NewPriceCacheWith(time.Hour, 30, cache.Options{Storage: storage})
+	if err != nil {
+		t.Fatalf("NewPriceCacheWith failed: %v", err)
+	}
+	first.GetOrFetch(context.Background(), testTokenA, block, func(context.Context, common.Address, chain.Block) (Quote, bool) {
+		return quote, true
+	})
+	storage.Close()
+
+	reopened, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	second, err := NewPriceCacheWith(time.Hour, 30, cache.Options{Storage: reopened})
+	if err != nil {
+		t.Fatalf("NewPriceCacheWith failed: %v", err)
+	}
+	restored, found := second.GetOrFetch(context.Background(), testTokenA, chain.Block{Number: 1250}, func(context.Context, common.Address, chain.Block) (Quote, bool) {
+		t.Error("Expected the persisted quote, not a fetch")
+		return Quote{}, false
+	})
+
+	if !found || restored.Price != 0.5 || restored.Source != PriceSourceChainlink ||
+		restored.RoundID.Int64() != 7 || !restored.UpdatedAt.Equal(quote.UpdatedAt) {
+		t.Errorf("Expected %+v back, got %+v (found=%v)", quote, restored, found)
+	}
+}
+
+func TestPriceCache_SoftTTLOnlyRefreshesLatest(t *testing.T) {
+	priceCache, err := NewPriceCacheWith(time.Hour, 30, cache.Options{SoftTTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewPriceCacheWith failed: %v", err)
+	}
+	fetches := make(chan chain.Block, 4)
+	fetch := func(_ context.Context, _ common.Address, block chain.Block) (Quote, bool) {
+		fetches <- block
+		return Quote{Price: 0.5, Source: PriceSourceChainlink}, true
+	}
+	priceCache.GetOrFetch(context.Background(), testTokenA, chain.Block{Number: 1234}, fetch)
+	priceCache.GetOrFetch(context.Background(), testTokenA, chain.Latest, fetch)
+	<-fetches
+	<-fetches
+	time.Sleep(5 * time.Millisecond)
+
+	priceCache.GetOrFetch(context.Background(), testTokenA, chain.Block{Number: 1234}, fetch)
+	priceCache.GetOrFetch(context.Background(), testTokenA, chain.Latest, fetch)
+
+	select {
+	case block := <-fetches:
+		if block != chain.Latest {
+			t.Errorf("Expected only the latest quote to be refreshed, got block %d", block.Number)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the stale latest quote to be refreshed")
+	}
+	select {
+	case block := <-fetches:
+		t.Errorf("Expected the historical quote to be served without a refresh, got a fetch at block %d", block.Number)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
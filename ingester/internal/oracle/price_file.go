@@ -0,0 +1,126 @@
+package oracle
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/chain"
+	apperr "ingester/internal/errors"
+)
+
+// PriceFileMaxAge is how long a price file entry stays valid after its timestamp.
+const PriceFileMaxAge = DefaultHeartbeat
+
+// FileOracle serves historical USD prices from an offline CSV file, for backfills over ranges
+// a pruned node cannot price or tokens no on-chain source covers. Each row is
+// "token,timestamp,price" with the timestamp in Unix seconds or RFC 3339; a header row is
+// optional. A lookup uses the latest row at or before the block time.
+type FileOracle struct {
+	prices map[common.Address][]filePrice // sorted by time
+}
+
+type filePrice struct {
+	at    time.Time
+	price float64
+}
+
+// LoadPriceFile reads and validates the CSV file at path, reporting every bad row.
+func LoadPriceFile(path string) (*FileOracle, error) {
+	file, err := os.Open(path) // #nosec G304 -- operator-supplied price file path
+	if err != nil {
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("open price file %s", path), Cause: err}
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	prices := make(map[common.Address][]filePrice)
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			errs = append(errs, &apperr.ConfigError{Message: fmt.Sprintf("price file %s line %d", path, line), Cause: err})
+			continue
+		}
+		if line == 1 && strings.EqualFold(record[0], "token") {
+			continue
+		}
+		token, at, price, err := parsePriceRow(record)
+		if err != nil {
+			errs = append(errs, &apperr.ConfigError{Message: fmt.Sprintf("price file %s line %d", path, line), Cause: err})
+			continue
+		}
+		prices[token] = append(prices[token], filePrice{at: at, price: price})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for _, series := range prices {
+		sort.Slice(series, func(i, j int) bool { return series[i].at.Before(series[j].at) })
+	}
+	return &FileOracle{prices: prices}, nil
+}
+
+func parsePriceRow(record []string) (common.Address, time.Time, float64, error) {
+	if !common.IsHexAddress(record[0]) {
+		return common.Address{}, time.Time{}, 0, fmt.Errorf("invalid token address %q", record[0])
+	}
+	at, err := parseTimestamp(record[1])
+	if err != nil {
+		return common.Address{}, time.Time{}, 0, err
+	}
+	price, err := strconv.ParseFloat(record[2], 64)
+	if err != nil || price <= 0 {
+		return common.Address{}, time.Time{}, 0, fmt.Errorf("invalid price %q", record[2])
+	}
+	return common.HexToAddress(record[0]), at, price, nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: want Unix seconds or RFC 3339", value)
+	}
+	return at, nil
+}
+
+func (o *FileOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
+	quote, found := o.QuoteAt(ctx, tokenAddress, chain.Latest)
+	return quote.Price, found
+}
+
+func (o *FileOracle) QuoteAt(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, bool) {
+	series := o.prices[tokenAddress]
+	now := block.Now()
+	// First row after now; the one before it is the latest at or before now.
+	next := sort.Search(len(series), func(i int) bool { return series[i].at.After(now) })
+	if next == 0 {
+		return Quote{}, false
+	}
+	row := series[next-1]
+	if now.Sub(row.at) > PriceFileMaxAge {
+		return Quote{}, false
+	}
+	return Quote{Price: row.price, Source: PriceSourcePriceFile, UpdatedAt: row.at}, true
+}
@@ -0,0 +1,78 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ingester/internal/chain"
+	apperr "ingester/internal/errors"
+)
+
+func writePriceFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prices.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write price file: %v", err)
+	}
+	return path
+}
+
+func TestFileOracle_LatestRowAtOrBeforeBlock(t *testing.T) {
+	path := writePriceFile(t, `token,timestamp,price
+# hourly WMATIC closes
+0x000000000000000000000000000000000000000a,1700003600,0.90
+0x000000000000000000000000000000000000000a,2023-11-14T22:13:20Z,0.80
+`)
+	o, err := LoadPriceFile(path)
+	if err != nil {
+		t.Fatalf("LoadPriceFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		at    int64
+		price float64
+		found bool
+	}{
+		{"before first row", 1699999999, 0, false},
+		{"at first row", 1700000000, 0.80, true},
+		{"between rows", 1700001800, 0.80, true},
+		{"after last row", 1700007200, 0.90, true},
+		{"past max age", 1700003600 + int64(PriceFileMaxAge/time.Second) + 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, found := o.QuoteAt(context.Background(), testTokenA, chain.Block{Number: 1, Time: time.Unix(tt.at, 0)})
+			if found != tt.found || quote.Price != tt.price {
+				t.Errorf("Expected %f (found=%v), got %f (found=%v)", tt.price, tt.found, quote.Price, found)
+			}
+			if found && quote.Source != PriceSourcePriceFile {
+				t.Errorf("Expected PRICE_FILE source, got %s", quote.Source)
+			}
+		})
+	}
+}
+
+func TestLoadPriceFile_ReportsEveryBadRow(t *testing.T) {
+	path := writePriceFile(t, `0xnot-an-address,1700000000,1
+0x000000000000000000000000000000000000000a,yesterday,1
+0x000000000000000000000000000000000000000a,1700000000,-1
+`)
+
+	_, err := LoadPriceFile(path)
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError, got %v", err)
+	}
+	for _, line := range []string{"line 1", "line 2", "line 3"} {
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("Expected %s reported, got %v", line, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package oracle
+
+import (
+	"math/big"
+	"time"
+)
+
+// PriceSource records where a USD price came from. The values match the Avro PriceSource enum.
+type PriceSource string
+
+const (
+	PriceSourceNone          PriceSource = "NONE"
+	PriceSourceStablecoin    PriceSource = "STABLECOIN"
+	PriceSourceChainlink     PriceSource = "CHAINLINK"
+	PriceSourceReferencePool PriceSource = "REFERENCE_POOL"
+	PriceSourceOverride      PriceSource = "OVERRIDE"
+	PriceSourcePriceFile     PriceSource = "PRICE_FILE"
+	PriceSourcePoolTWAP      PriceSource = "POOL_TWAP"
+)
+
+// Quote is a USD price with its provenance. RoundID and UpdatedAt identify the Chainlink round
+// behind the price: a reference-pool quote carries the round of the feed its route ends in, and
+// both stay unset when no feed was involved.
+type Quote struct {
+	Price     float64
+	Source    PriceSource
+	RoundID   *big.Int
+	UpdatedAt time.Time
+}
+
+func stablecoinQuote() Quote {
+	return Quote{Price: 1.0, Source: PriceSourceStablecoin}
+}
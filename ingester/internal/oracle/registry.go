@@ -0,0 +1,472 @@
+package oracle
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+
+	apperr "ingester/internal/errors"
+)
+
+// DefaultHeartbeat applies to feeds that do not declare their own staleness threshold.
+const DefaultHeartbeat = 24 * time.Hour
+
+// DefaultMaxDeviation applies to feeds that do not declare their own deviation threshold.
+const DefaultMaxDeviation = 0.5
+
+// DefaultMinPoolLiquidityUSD applies to reference pools that do not declare their own threshold.
+const DefaultMinPoolLiquidityUSD = 50_000.0
+
+//go:embed feeds.json
+var defaultFeedsJSON []byte
+
+// Feed describes one Chainlink aggregator and what it is expected to price.
+type Feed struct {
+	Aggregator   common.Address
+	Symbol       string
+	Description  string        // expected on-chain description(), e.g. "ETH / USD"
+	Heartbeat    time.Duration // answers older than this are treated as stale
+	MinPrice     float64       // USD circuit-breaker bounds; zero means unbounded
+	MaxPrice     float64
+	MaxDeviation float64 // largest accepted move between consecutive rounds, as a fraction; zero disables the check
+}
+
+// ReferencePool is a Uniswap V2 pair used to price a token that has no Chainlink feed,
+// through the other token in the pair. Pools holding less than MinLiquidityUSD are ignored.
+type ReferencePool struct {
+	Pool            common.Address
+	MinLiquidityUSD float64
+}
+
+// Registry maps tokens to Chainlink feeds and reference pools, marks USD stablecoins and holds
+// operator price overrides. It is safe for concurrent use and can be replaced at runtime (hot reload).
+type Registry struct {
+	mu             sync.RWMutex
+	stablecoins    map[common.Address]bool
+	priceFeeds     map[common.Address]Feed
+	referencePools map[common.Address]ReferencePool
+	overrides      map[common.Address]float64
+	// missingChain is the chain LoadRegistry found no entry for in either source, if any.
+	missingChain string
+}
+
+// RegistryDiff lists what changed between two registries.
+type RegistryDiff struct {
+	AddedFeeds         []common.Address
+	RemovedFeeds       []common.Address
+	ChangedFeeds       []common.Address
+	AddedStablecoins   []common.Address
+	RemovedStablecoins []common.Address
+	ChangedPools       []common.Address // tokens whose reference pool was added, removed or changed
+	ChangedOverrides   []common.Address // tokens whose price override was added, removed or changed
+}
+
+// registryFile is the shape shared by the embedded feeds.json and operator overrides.
+// YAML is a superset of JSON, so one decoder handles both.
+type registryFile struct {
+	Chains map[string]chainEntry `yaml:"chains"`
+}
+
+type chainEntry struct {
+	Stablecoins    []string        `yaml:"stablecoins"`
+	Feeds          []feedEntry     `yaml:"feeds"`
+	ReferencePools []poolEntry     `yaml:"referencePools"`
+	Overrides      []overrideEntry `yaml:"overrides"`
+}
+
+type feedEntry struct {
+	Token       string `yaml:"token"`
+	Symbol      string `yaml:"symbol"`
+	Aggregator  string `yaml:"aggregator"`
+	Description string `yaml:"description"`
+	Heartbeat   string `yaml:"heartbeat"`
+
+	MinPrice     *float64 `yaml:"minPrice"`
+	MaxPrice     *float64 `yaml:"maxPrice"`
+	MaxDeviation *float64 `yaml:"maxDeviation"`
+}
+
+type poolEntry struct {
+	Token           string   `yaml:"token"`
+	Pool            string   `yaml:"pool"`
+	MinLiquidityUSD *float64 `yaml:"minLiquidityUSD"`
+}
+
+type overrideEntry struct {
+	Token string  `yaml:"token"`
+	Price float64 `yaml:"price"`
+}
+
+// NewRegistry copies the given stablecoins, token -> feed map and token -> reference pool map.
+func NewRegistry(
+	stablecoins []common.Address,
+	priceFeeds map[common.Address]Feed,
+	referencePools map[common.Address]ReferencePool,
+) *Registry {
+	registry := &Registry{
+		stablecoins:    make(map[common.Address]bool, len(stablecoins)),
+		priceFeeds:     make(map[common.Address]Feed, len(priceFeeds)),
+		referencePools: make(map[common.Address]ReferencePool, len(referencePools)),
+		overrides:      make(map[common.Address]float64),
+	}
+	for _, token := range stablecoins {
+		registry.stablecoins[token] = true
+	}
+	for token, feed := range priceFeeds {
+		registry.priceFeeds[token] = feed
+	}
+	for token, pool := range referencePools {
+		registry.referencePools[token] = pool
+	}
+	return registry
+}
+
+// LoadRegistry builds the registry for chainID from the embedded feeds.json, then applies the
+// optional override file at path. Override feeds, reference pools and price overrides replace
+// the default for the same token; an override stablecoin list replaces the default list. A chain with no
+// entries yields an empty registry, which ValidateFeeds reports.
+func LoadRegistry(chainID uint64, path string) (*Registry, error) {
+	chain := strconv.FormatUint(chainID, 10)
+
+	defaults, err := decodeRegistryFile(defaultFeedsJSON, "embedded feeds.json")
+	if err != nil {
+		return nil, err
+	}
+	stablecoins, feeds, pools, overrides, err := defaults.Chains[chain].resolve(chain)
+	if err != nil {
+		return nil, err
+	}
+	_, listed := defaults.Chains[chain]
+
+	if path != "" {
+		content, err := os.ReadFile(path) // #nosec G304 -- operator-supplied registry path
+		if err != nil {
+			return nil, &apperr.ConfigError{Message: fmt.Sprintf("read feed registry %s", path), Cause: err}
+		}
+		file, err := decodeRegistryFile(content, path)
+		if err != nil {
+			return nil, err
+		}
+		fileStablecoins, fileFeeds, filePools, fileOverrides, err := file.Chains[chain].resolve(chain)
+		if err != nil {
+			return nil, err
+		}
+		if _, fileListed := file.Chains[chain]; fileListed {
+			listed = true
+		}
+		if fileStablecoins != nil {
+			stablecoins = fileStablecoins
+		}
+		for token, feed := range fileFeeds {
+			feeds[token] = feed
+		}
+		for token, pool := range filePools {
+			pools[token] = pool
+		}
+		for token, price := range fileOverrides {
+			overrides[token] = price
+		}
+	}
+
+	registry := NewRegistry(stablecoins, feeds, pools)
+	registry.SetOverrides(overrides)
+	if !listed {
+		registry.missingChain = chain
+	}
+	return registry, nil
+}
+
+// DefaultRegistry returns the embedded stablecoins and feeds for chainID.
+func DefaultRegistry(chainID uint64) (*Registry, error) {
+	return LoadRegistry(chainID, "")
+}
+
+func (r *Registry) IsStablecoin(tokenAddress common.Address) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stablecoins[tokenAddress]
+}
+
+func (r *Registry) PriceFeed(tokenAddress common.Address) (Feed, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	feed, exists := r.priceFeeds[tokenAddress]
+	return feed, exists
+}
+
+// ReferencePool returns the pool used to price tokenAddress when it has no feed.
+func (r *Registry) ReferencePool(tokenAddress common.Address) (ReferencePool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pool, exists := r.referencePools[tokenAddress]
+	return pool, exists
+}
+
+// Override returns the operator-pinned USD price for tokenAddress.
+func (r *Registry) Override(tokenAddress common.Address) (float64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	price, exists := r.overrides[tokenAddress]
+	return price, exists
+}
+
+// SetOverrides replaces the token -> USD price overrides.
+func (r *Registry) SetOverrides(overrides map[common.Address]float64) {
+	copied := make(map[common.Address]float64, len(overrides))
+	for token, price := range overrides {
+		copied[token] = price
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides = copied
+}
+
+// Feeds returns a copy of the token -> feed map.
+func (r *Registry) Feeds() map[common.Address]Feed {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	feeds := make(map[common.Address]Feed, len(r.priceFeeds))
+	for token, feed := range r.priceFeeds {
+		feeds[token] = feed
+	}
+	return feeds
+}
+
+// Replace swaps in the contents of next and returns what changed.
+func (r *Registry) Replace(next *Registry) RegistryDiff {
+	next.mu.RLock()
+	stablecoins := make(map[common.Address]bool, len(next.stablecoins))
+	for token := range next.stablecoins {
+		stablecoins[token] = true
+	}
+	priceFeeds := make(map[common.Address]Feed, len(next.priceFeeds))
+	for token, feed := range next.priceFeeds {
+		priceFeeds[token] = feed
+	}
+	referencePools := make(map[common.Address]ReferencePool, len(next.referencePools))
+	for token, pool := range next.referencePools {
+		referencePools[token] = pool
+	}
+	overrides := make(map[common.Address]float64, len(next.overrides))
+	for token, price := range next.overrides {
+		overrides[token] = price
+	}
+	next.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	diff := diffRegistries(r.stablecoins, r.priceFeeds, stablecoins, priceFeeds)
+	diff.ChangedPools = changedKeys(r.referencePools, referencePools)
+	diff.ChangedOverrides = changedKeys(r.overrides, overrides)
+	r.stablecoins = stablecoins
+	r.priceFeeds = priceFeeds
+	r.referencePools = referencePools
+	r.overrides = overrides
+	return diff
+}
+
+// Empty reports whether the diff contains no changes.
+func (d RegistryDiff) Empty() bool {
+	return len(d.AddedFeeds) == 0 && len(d.RemovedFeeds) == 0 && len(d.ChangedFeeds) == 0 &&
+		len(d.AddedStablecoins) == 0 && len(d.RemovedStablecoins) == 0 && len(d.ChangedPools) == 0 &&
+		len(d.ChangedOverrides) == 0
+}
+
+// AffectedTokens returns every token whose USD price resolution may have changed.
+func (d RegistryDiff) AffectedTokens() []common.Address {
+	seen := make(map[common.Address]bool)
+	for _, group := range [][]common.Address{d.AddedFeeds, d.RemovedFeeds, d.ChangedFeeds, d.AddedStablecoins, d.RemovedStablecoins, d.ChangedPools, d.ChangedOverrides} {
+		for _, token := range group {
+			seen[token] = true
+		}
+	}
+	return sortedAddresses(seen)
+}
+
+func decodeRegistryFile(content []byte, source string) (registryFile, error) {
+	var file registryFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return registryFile{}, &apperr.ConfigError{Message: fmt.Sprintf("parse feed registry %s", source), Cause: err}
+	}
+	return file, nil
+}
+
+// resolve validates one chain's entries. The returned stablecoin slice is nil when the
+// entry does not list any, so callers can tell "not specified" from "empty".
+func (c chainEntry) resolve(chain string) (
+	[]common.Address,
+	map[common.Address]Feed,
+	map[common.Address]ReferencePool,
+	map[common.Address]float64,
+	error,
+) {
+	var errs []error
+	fail := func(message string) {
+		errs = append(errs, &apperr.ConfigError{Message: fmt.Sprintf("feed registry chain %s: %s", chain, message)})
+	}
+
+	var stablecoins []common.Address
+	for _, token := range c.Stablecoins {
+		if !common.IsHexAddress(token) {
+			fail(fmt.Sprintf("invalid stablecoin address %q", token))
+			continue
+		}
+		stablecoins = append(stablecoins, common.HexToAddress(token))
+	}
+
+	feeds := make(map[common.Address]Feed, len(c.Feeds))
+	for _, entry := range c.Feeds {
+		if !common.IsHexAddress(entry.Token) || !common.IsHexAddress(entry.Aggregator) {
+			fail(fmt.Sprintf("invalid token/aggregator address pair %q -> %q", entry.Token, entry.Aggregator))
+			continue
+		}
+		heartbeat := DefaultHeartbeat
+		if entry.Heartbeat != "" {
+			parsed, err := time.ParseDuration(entry.Heartbeat)
+			if err != nil || parsed <= 0 {
+				fail(fmt.Sprintf("invalid heartbeat %q for %s", entry.Heartbeat, entry.Token))
+				continue
+			}
+			heartbeat = parsed
+		}
+		minPrice, maxPrice := valueOr(entry.MinPrice, 0), valueOr(entry.MaxPrice, 0)
+		if minPrice < 0 || maxPrice < 0 || (maxPrice > 0 && minPrice >= maxPrice) {
+			fail(fmt.Sprintf("invalid price bounds [%g, %g] for %s", minPrice, maxPrice, entry.Token))
+			continue
+		}
+		maxDeviation := valueOr(entry.MaxDeviation, DefaultMaxDeviation)
+		if maxDeviation < 0 {
+			fail(fmt.Sprintf("negative maxDeviation for %s", entry.Token))
+			continue
+		}
+		feeds[common.HexToAddress(entry.Token)] = Feed{
+			Aggregator:   common.HexToAddress(entry.Aggregator),
+			Symbol:       entry.Symbol,
+			Description:  entry.Description,
+			Heartbeat:    heartbeat,
+			MinPrice:     minPrice,
+			MaxPrice:     maxPrice,
+			MaxDeviation: maxDeviation,
+		}
+	}
+
+	pools := make(map[common.Address]ReferencePool, len(c.ReferencePools))
+	for _, entry := range c.ReferencePools {
+		if !common.IsHexAddress(entry.Token) || !common.IsHexAddress(entry.Pool) {
+			fail(fmt.Sprintf("invalid token/pool address pair %q -> %q", entry.Token, entry.Pool))
+			continue
+		}
+		minLiquidity := DefaultMinPoolLiquidityUSD
+		if entry.MinLiquidityUSD != nil {
+			if *entry.MinLiquidityUSD < 0 {
+				fail(fmt.Sprintf("negative minLiquidityUSD for %s", entry.Token))
+				continue
+			}
+			minLiquidity = *entry.MinLiquidityUSD
+		}
+		pools[common.HexToAddress(entry.Token)] = ReferencePool{
+			Pool:            common.HexToAddress(entry.Pool),
+			MinLiquidityUSD: minLiquidity,
+		}
+	}
+
+	overrides := make(map[common.Address]float64, len(c.Overrides))
+	for _, entry := range c.Overrides {
+		if !common.IsHexAddress(entry.Token) || entry.Price <= 0 {
+			fail(fmt.Sprintf("invalid price override %q -> %g", entry.Token, entry.Price))
+			continue
+		}
+		overrides[common.HexToAddress(entry.Token)] = entry.Price
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return stablecoins, feeds, pools, overrides, nil
+}
+
+func diffRegistries(
+	oldStablecoins map[common.Address]bool,
+	oldFeeds map[common.Address]Feed,
+	newStablecoins map[common.Address]bool,
+	newFeeds map[common.Address]Feed,
+) RegistryDiff {
+	added, removed, changed := make(map[common.Address]bool), make(map[common.Address]bool), make(map[common.Address]bool)
+	for token, feed := range newFeeds {
+		oldFeed, existed := oldFeeds[token]
+		switch {
+		case !existed:
+			added[token] = true
+		case oldFeed != feed:
+			changed[token] = true
+		}
+	}
+	for token := range oldFeeds {
+		if _, exists := newFeeds[token]; !exists {
+			removed[token] = true
+		}
+	}
+
+	addedStable, removedStable := make(map[common.Address]bool), make(map[common.Address]bool)
+	for token := range newStablecoins {
+		if !oldStablecoins[token] {
+			addedStable[token] = true
+		}
+	}
+	for token := range oldStablecoins {
+		if !newStablecoins[token] {
+			removedStable[token] = true
+		}
+	}
+
+	return RegistryDiff{
+		AddedFeeds:         sortedAddresses(added),
+		RemovedFeeds:       sortedAddresses(removed),
+		ChangedFeeds:       sortedAddresses(changed),
+		AddedStablecoins:   sortedAddresses(addedStable),
+		RemovedStablecoins: sortedAddresses(removedStable),
+	}
+}
+
+func changedKeys[V comparable](oldMap, newMap map[common.Address]V) []common.Address {
+	changed := make(map[common.Address]bool)
+	for key, value := range newMap {
+		if oldValue, existed := oldMap[key]; !existed || oldValue != value {
+			changed[key] = true
+		}
+	}
+	for key := range oldMap {
+		if _, exists := newMap[key]; !exists {
+			changed[key] = true
+		}
+	}
+	return sortedAddresses(changed)
+}
+
+func valueOr(value *float64, fallback float64) float64 {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func sortedAddresses(set map[common.Address]bool) []common.Address {
+	addresses := make([]common.Address, 0, len(set))
+	for address := range set {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Cmp(addresses[j]) < 0 })
+	return addresses
+}
@@ -0,0 +1,243 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	apperr "ingester/internal/errors"
+)
+
+var (
+	testTokenA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	testTokenB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+	testTokenC = common.HexToAddress("0x000000000000000000000000000000000000000c")
+	testFeed1  = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	testFeed2  = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+)
+
+func polygonRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry, err := DefaultRegistry(137)
+	if err != nil {
+		t.Fatalf("DefaultRegistry failed: %v", err)
+	}
+	return registry
+}
+
+func writeRegistryFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "feeds.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write registry file: %v", err)
+	}
+	return path
+}
+
+func TestDefaultRegistry_PolygonEntries(t *testing.T) {
+	registry := polygonRegistry(t)
+
+	usdc := common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174")
+	if !registry.IsStablecoin(usdc) {
+		t.Error("USDC should be a default stablecoin")
+	}
+
+	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
+	feed, exists := registry.PriceFeed(wmatic)
+	if !exists {
+		t.Fatal("WMATIC should have a default price feed")
+	}
+	if feed.Description != "MATIC / USD" || feed.Heartbeat != time.Hour {
+		t.Errorf("Unexpected WMATIC feed %+v", feed)
+	}
+}
+
+func TestDefaultRegistry_EveryEmbeddedChainParses(t *testing.T) {
+	for _, chainID := range []uint64{1, 137, 8453, 42161} {
+		registry, err := DefaultRegistry(chainID)
+		if err != nil {
+			t.Errorf("chain %d: %v", chainID, err)
+			continue
+		}
+		if len(registry.Feeds()) == 0 {
+			t.Errorf("chain %d: expected embedded feeds", chainID)
+		}
+	}
+}
+
+func TestDefaultRegistry_UnknownChainIsEmpty(t *testing.T) {
+	registry, err := DefaultRegistry(999999)
+	if err != nil {
+		t.Fatalf("DefaultRegistry failed: %v", err)
+	}
+	if len(registry.Feeds()) != 0 {
+		t.Errorf("Expected no feeds for an unknown chain, got %d", len(registry.Feeds()))
+	}
+}
+
+func TestValidateFeeds_AmoyHasNoRegistryEntry(t *testing.T) {
+	registry, err := DefaultRegistry(80002)
+	if err != nil {
+		t.Fatalf("DefaultRegistry failed: %v", err)
+	}
+
+	err = ValidateFeeds(context.Background(), nil, registry)
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(configErr.Message, "no entry for chain 80002") {
+		t.Fatalf("Expected a ConfigError naming the missing chain entry, got %v", err)
+	}
+
+	path := writeRegistryFile(t, "chains:\n  \"80002\":\n    stablecoins: []\n")
+	listed, err := LoadRegistry(80002, path)
+	if err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if err := ValidateFeeds(context.Background(), nil, listed); err != nil {
+		t.Errorf("Expected a chain listed in the registry file to validate, got %v", err)
+	}
+}
+
+func TestLoadRegistry_FileOverridesDefaults(t *testing.T) {
+	path := writeRegistryFile(t, `
+chains:
+  "137":
+    stablecoins: ["0x000000000000000000000000000000000000000a"]
+    feeds:
+      - token: "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"
+        symbol: WPOL
+        aggregator: "0x00000000000000000000000000000000000000f1"
+        description: "POL / USD"
+        heartbeat: 30m
+        minPrice: 0.01
+        maxPrice: 100
+        maxDeviation: 0.2
+      - token: "0x000000000000000000000000000000000000000b"
+        aggregator: "0x00000000000000000000000000000000000000f2"
+    referencePools:
+      - token: "0x000000000000000000000000000000000000000c"
+        pool: "0x00000000000000000000000000000000000000f1"
+    overrides:
+      - token: "0x000000000000000000000000000000000000000c"
+        price: 0.25
+`)
+
+	registry, err := LoadRegistry(137, path)
+	if err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+
+	if !registry.IsStablecoin(testTokenA) {
+		t.Error("File stablecoin should be present")
+	}
+	usdc := common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174")
+	if registry.IsStablecoin(usdc) {
+		t.Error("File stablecoins should replace the defaults")
+	}
+
+	wmatic := common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")
+	if feed, _ := registry.PriceFeed(wmatic); feed.Aggregator != testFeed1 || feed.Heartbeat != 30*time.Minute ||
+		feed.MinPrice != 0.01 || feed.MaxPrice != 100 || feed.MaxDeviation != 0.2 {
+		t.Errorf("Expected overridden WMATIC feed, got %+v", feed)
+	}
+	if feed, _ := registry.PriceFeed(testTokenB); feed.Heartbeat != DefaultHeartbeat || feed.MaxDeviation != DefaultMaxDeviation {
+		t.Errorf("Expected default heartbeat and deviation for feed without them, got %+v", feed)
+	}
+	if pool, _ := registry.ReferencePool(testTokenC); pool.Pool != testFeed1 || pool.MinLiquidityUSD != DefaultMinPoolLiquidityUSD {
+		t.Errorf("Expected reference pool with default threshold, got %+v", pool)
+	}
+	if price, _ := registry.Override(testTokenC); price != 0.25 {
+		t.Errorf("Expected $0.25 override, got %f", price)
+	}
+	weth := common.HexToAddress("0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619")
+	if _, exists := registry.PriceFeed(weth); !exists {
+		t.Error("Default feeds not mentioned in the file should be kept")
+	}
+}
+
+func TestLoadRegistry_InvalidFile(t *testing.T) {
+	path := writeRegistryFile(t, `
+chains:
+  "137":
+    stablecoins: ["not-an-address"]
+    feeds:
+      - token: "0x000000000000000000000000000000000000000b"
+        aggregator: "0x00000000000000000000000000000000000000f2"
+        heartbeat: soon
+      - token: "0x000000000000000000000000000000000000000c"
+        aggregator: "0x00000000000000000000000000000000000000f3"
+        minPrice: 5
+        maxPrice: 1
+`)
+
+	_, err := LoadRegistry(137, path)
+
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "not-an-address") || !strings.Contains(err.Error(), "soon") || !strings.Contains(err.Error(), "[5, 1]") {
+		t.Errorf("Expected every problem reported, got %v", err)
+	}
+}
+
+func TestRegistry_ReplaceReportsDiff(t *testing.T) {
+	registry := NewRegistry(
+		[]common.Address{testTokenA},
+		map[common.Address]Feed{testTokenA: {Aggregator: testFeed1}, testTokenB: {Aggregator: testFeed1}},
+		map[common.Address]ReferencePool{testTokenC: {Pool: testFeed1}},
+	)
+
+	next := NewRegistry(
+		[]common.Address{testTokenC},
+		map[common.Address]Feed{testTokenB: {Aggregator: testFeed2}, testTokenC: {Aggregator: testFeed1}},
+		map[common.Address]ReferencePool{testTokenC: {Pool: testFeed2}},
+	)
+	next.SetOverrides(map[common.Address]float64{testTokenB: 2})
+	diff := registry.Replace(next)
+
+	assertAddresses(t, "AddedFeeds", diff.AddedFeeds, testTokenC)
+	assertAddresses(t, "RemovedFeeds", diff.RemovedFeeds, testTokenA)
+	assertAddresses(t, "ChangedFeeds", diff.ChangedFeeds, testTokenB)
+	assertAddresses(t, "AddedStablecoins", diff.AddedStablecoins, testTokenC)
+	assertAddresses(t, "RemovedStablecoins", diff.RemovedStablecoins, testTokenA)
+	assertAddresses(t, "ChangedPools", diff.ChangedPools, testTokenC)
+	assertAddresses(t, "ChangedOverrides", diff.ChangedOverrides, testTokenB)
+	assertAddresses(t, "AffectedTokens", diff.AffectedTokens(), testTokenA, testTokenB, testTokenC)
+
+	if registry.IsStablecoin(testTokenA) || !registry.IsStablecoin(testTokenC) {
+		t.Error("Replace should swap the stablecoin set")
+	}
+	if feed, _ := registry.PriceFeed(testTokenB); feed.Aggregator != testFeed2 {
+		t.Errorf("Expected updated feed %s, got %s", testFeed2.Hex(), feed.Aggregator.Hex())
+	}
+}
+
+func TestRegistry_ReplaceWithSameContentsIsEmpty(t *testing.T) {
+	registry := polygonRegistry(t)
+
+	diff := registry.Replace(polygonRegistry(t))
+
+	if !diff.Empty() {
+		t.Errorf("Expected empty diff, got %+v", diff)
+	}
+}
+
+func assertAddresses(t *testing.T, name string, got []common.Address, expected ...common.Address) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %d addresses, got %d", name, len(expected), len(got))
+		return
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("%s[%d]: expected %s, got %s", name, i, expected[i].Hex(), got[i].Hex())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package oracle
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/cache"
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// maxRouteHops bounds how many reference pools one price may chain through
+// (TOKEN -> WMATIC -> USD is one hop); it also stops misconfigured cycles.
+const maxRouteHops = 3
+
+// RoutingOracle prices tokens that have no Chainlink feed through the registry's reference
+// pools: the token's price in the pool's other token, times that token's USD price, which in
+// turn comes from a stablecoin, a feed, or another reference pool.
+type RoutingOracle struct {
+	caller     contract.ContractCaller
+	registry   *Registry
+	feeds      PriceOracle
+	poolTokens *cache.Cache[common.Address, poolTokens]
+}
+
+type poolTokens struct {
+	Token0, Token1       common.Address
+	Decimals0, Decimals1 uint8
+}
+
+type poolReserves struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast uint32
+}
+
+// NewRoutingOracle prices tokens with a feed through feeds and everything else through pools.
+func NewRoutingOracle(caller contract.ContractCaller, registry *Registry, feeds PriceOracle) *RoutingOracle {
+	return &RoutingOracle{
+		caller:     caller,
+		registry:   registry,
+		feeds:      feeds,
+		poolTokens: cache.NewCache[common.Address, poolTokens](0),
+	}
+}
+
+func (o *RoutingOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
+	quote, found := o.QuoteAt(ctx, tokenAddress, chain.Latest)
+	return quote.Price, found
+}
+
+// QuoteAt reads pool reserves and feeds at block.
+func (o *RoutingOracle) QuoteAt(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, bool) {
+	return o.price(ctx, tokenAddress, block, maxRouteHops)
+}
+
+func (o *RoutingOracle) price(ctx context.Context, token common.Address, block chain.Block, hopsLeft int) (Quote, bool) {
+	if o.registry.IsStablecoin(token) {
+		return stablecoinQuote(), true
+	}
+	if _, exists := o.registry.PriceFeed(token); exists {
+		return o.feeds.QuoteAt(ctx, token, block)
+	}
+
+	route, exists := o.registry.ReferencePool(token)
+	if !exists || hopsLeft == 0 {
+		return Quote{}, false
+	}
+
+	tokens, found := o.poolTokens.GetOrFetch(ctx, route.Pool, func(ctx context.Context, pool common.Address) (poolTokens, bool) {
+		return fetchPoolTokens(ctx, o.caller, pool)
+	})
+	if !found {
+		return Quote{}, false
+	}
+
+	var quote common.Address
+	var tokenDecimals, quoteDecimals uint8
+	switch token {
+	case tokens.Token0:
+		quote, tokenDecimals, quoteDecimals = tokens.Token1, tokens.Decimals0, tokens.Decimals1
+	case tokens.Token1:
+		quote, tokenDecimals, quoteDecimals = tokens.Token0, tokens.Decimals1, tokens.Decimals0
+	default:
+		logger.Warn("Reference pool does not contain token", "token", token.Hex(), "pool", route.Pool.Hex())
+		return Quote{}, false
+	}
+
+	pairABI, err := contract.GetABI(contract.UniswapV2Pair)
+	if err != nil {
+		return Quote{}, false
+	}
+	var reserves poolReserves
+	if err := contract.CallContractAt(ctx, o.caller, route.Pool, pairABI, "getReserves", block.BigNumber(), &reserves); err != nil {
+		return Quote{}, false
+	}
+
+	tokenReserve, quoteReserve := reserves.Reserve0, reserves.Reserve1
+	if token == tokens.Token1 {
+		tokenReserve, quoteReserve = reserves.Reserve1, reserves.Reserve0
+	}
+	if tokenReserve.Sign() <= 0 || quoteReserve.Sign() <= 0 {
+		return Quote{}, false
+	}
+
+	quoteUSD, found := o.price(ctx, quote, block, hopsLeft-1)
+	if !found || quoteUSD.Price <= 0 {
+		return Quote{}, false
+	}
+
+	quoteAmount := scaledFloat(quoteReserve, quoteDecimals)
+	// Both sides of a V2 pool hold equal value, so TVL is twice the quote side.
+	if liquidityUSD := 2 * quoteAmount * quoteUSD.Price; liquidityUSD < route.MinLiquidityUSD {
+		logger.Debug("Reference pool below liquidity threshold",
+			"token", token.Hex(), "pool", route.Pool.Hex(), "liquidityUSD", liquidityUSD, "minLiquidityUSD", route.MinLiquidityUSD)
+		return Quote{}, false
+	}
+
+	return Quote{
+		Price:     quoteAmount / scaledFloat(tokenReserve, tokenDecimals) * quoteUSD.Price,
+		Source:    PriceSourceReferencePool,
+		RoundID:   quoteUSD.RoundID,
+		UpdatedAt: quoteUSD.UpdatedAt,
+	}, true
+}
+
+func fetchPoolTokens(ctx context.Context, caller contract.ContractCaller, pool common.Address) (poolTokens, bool) {
+	pairABI, err := contract.GetABI(contract.UniswapV2Pair)
+	if err != nil {
+		return poolTokens{}, false
+	}
+	decimalsABI, err := contract.GetABI(contract.ERC20Decimals)
+	if err != nil {
+		return poolTokens{}, false
+	}
+
+	var tokens poolTokens
+	if err := contract.CallContract(ctx, caller, pool, pairABI, "token0", &tokens.Token0); err != nil {
+		return poolTokens{}, false
+	}
+	if err := contract.CallContract(ctx, caller, pool, pairABI, "token1", &tokens.Token1); err != nil {
+		return poolTokens{}, false
+	}
+	if err := contract.CallContract(ctx, caller, tokens.Token0, decimalsABI, "decimals", &tokens.Decimals0); err != nil {
+		return poolTokens{}, false
+	}
+	if err := contract.CallContract(ctx, caller, tokens.Token1, decimalsABI, "decimals", &tokens.Decimals1); err != nil {
+		return poolTokens{}, false
+	}
+	return tokens, true
+}
+
+func scaledFloat(amount *big.Int, decimals uint8) float64 {
+	return priceWithDecimals(amount, decimals)
+}
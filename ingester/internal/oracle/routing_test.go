@@ -0,0 +1,157 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+)
+
+var (
+	testWrapped = common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	testStable  = common.HexToAddress("0x00000000000000000000000000000000000000e2")
+	testPool1   = common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	testPool2   = common.HexToAddress("0x00000000000000000000000000000000000000d2")
+)
+
+type fakePool struct {
+	token0, token1     common.Address
+	reserve0, reserve1 *big.Int
+}
+
+// poolCaller answers token0/token1/getReserves for pools and decimals (18) for every token,
+// recording the block each getReserves call was made at.
+func poolCaller(t *testing.T, pools map[common.Address]fakePool, reserveBlocks *[]*big.Int) contract.ContractCaller {
+	t.Helper()
+	pairABI, err := contract.GetABI(contract.UniswapV2Pair)
+	if err != nil {
+		t.Fatalf("load pair ABI: %v", err)
+	}
+	decimalsABI, err := contract.GetABI(contract.ERC20Decimals)
+	if err != nil {
+		t.Fatalf("load decimals ABI: %v", err)
+	}
+	return contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		pool, isPool := pools[*msg.To]
+		if !isPool {
+			return decimalsABI.Methods["decimals"].Outputs.Pack(uint8(18))
+		}
+		method, err := pairABI.MethodById(msg.Data[:4])
+		if err != nil {
+			return nil, err
+		}
+		switch method.Name {
+		case "token0":
+			return method.Outputs.Pack(pool.token0)
+		case "token1":
+			return method.Outputs.Pack(pool.token1)
+		case "getReserves":
+			if reserveBlocks != nil {
+				*reserveBlocks = append(*reserveBlocks, blockNumber)
+			}
+			return method.Outputs.Pack(pool.reserve0, pool.reserve1, uint32(0))
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	})
+}
+
+func ether(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e18))
+}
+
+func TestRoutingOracle_PricesThroughFeedToken(t *testing.T) {
+	registry := NewRegistry(nil,
+		map[common.Address]Feed{testWrapped: {Aggregator: testFeed1}},
+		map[common.Address]ReferencePool{testTokenA: {Pool: testPool1, MinLiquidityUSD: 50_000}},
+	)
+	// token1 is the priced token here, so reserves are read in reverse order.
+	pools := map[common.Address]fakePool{
+		testPool1: {token0: testWrapped, token1: testTokenA, reserve0: ether(200_000), reserve1: ether(1_000_000)},
+	}
+	var reserveBlocks []*big.Int
+	block := chain.Block{Number: 1234, Time: time.Unix(1_700_000_000, 0)}
+	feeds := NewMockPriceOracle(t)
+	feeds.EXPECT().QuoteAt(context.Background(), testWrapped, block).Return(Quote{Price: 0.5, Source: PriceSourceChainlink, RoundID: big.NewInt(7)}, true)
+
+	o := NewRoutingOracle(poolCaller(t, pools, &reserveBlocks), registry, feeds)
+	quote, found := o.QuoteAt(context.Background(), testTokenA, block)
+
+	if !found || quote.Price != 0.1 {
+		t.Errorf("Expected $0.1, got %f (found=%v)", quote.Price, found)
+	}
+	if quote.Source != PriceSourceReferencePool || quote.RoundID == nil || quote.RoundID.Int64() != 7 {
+		t.Errorf("Expected a reference-pool quote anchored on round 7, got %+v", quote)
+	}
+	if len(reserveBlocks) != 1 || reserveBlocks[0].Int64() != 1234 {
+		t.Errorf("Expected reserves read at block 1234, got %v", reserveBlocks)
+	}
+}
+
+func TestRoutingOracle_MultiHopToStablecoin(t *testing.T) {
+	registry := NewRegistry([]common.Address{testStable}, nil, map[common.Address]ReferencePool{
+		testTokenA:  {Pool: testPool1},
+		testWrapped: {Pool: testPool2},
+	})
+	pools := map[common.Address]fakePool{
+		testPool1: {token0: testTokenA, token1: testWrapped, reserve0: ether(1_000_000), reserve1: ether(500_000)},
+		testPool2: {token0: testWrapped, token1: testStable, reserve0: ether(100_000), reserve1: ether(300_000)},
+	}
+
+	o := NewRoutingOracle(poolCaller(t, pools, nil), registry, NewMockPriceOracle(t))
+	price, found := o.FetchPrice(context.Background(), testTokenA)
+
+	// TOKEN = 0.5 WRAPPED, WRAPPED = $3
+	if !found || price != 1.5 {
+		t.Errorf("Expected $1.5, got %f (found=%v)", price, found)
+	}
+}
+
+func TestRoutingOracle_RejectsThinPool(t *testing.T) {
+	registry := NewRegistry([]common.Address{testStable}, nil, map[common.Address]ReferencePool{
+		testTokenA: {Pool: testPool1, MinLiquidityUSD: 50_000},
+	})
+	pools := map[common.Address]fakePool{
+		testPool1: {token0: testTokenA, token1: testStable, reserve0: ether(10), reserve1: ether(20_000)},
+	}
+
+	o := NewRoutingOracle(poolCaller(t, pools, nil), registry, NewMockPriceOracle(t))
+	if _, found := o.FetchPrice(context.Background(), testTokenA); found {
+		t.Error("Pool with $40k liquidity should not be trusted at a $50k threshold")
+	}
+}
+
+func TestRoutingOracle_PoolWithoutToken(t *testing.T) {
+	registry := NewRegistry([]common.Address{testStable}, nil, map[common.Address]ReferencePool{
+		testTokenA: {Pool: testPool1},
+	})
+	pools := map[common.Address]fakePool{
+		testPool1: {token0: testTokenB, token1: testStable, reserve0: ether(1), reserve1: ether(1)},
+	}
+
+	o := NewRoutingOracle(poolCaller(t, pools, nil), registry, NewMockPriceOracle(t))
+	if _, found := o.FetchPrice(context.Background(), testTokenA); found {
+		t.Error("Misconfigured pool should not produce a price")
+	}
+}
+
+func TestRoutingOracle_CycleTerminates(t *testing.T) {
+	registry := NewRegistry(nil, nil, map[common.Address]ReferencePool{
+		testTokenA: {Pool: testPool1},
+		testTokenB: {Pool: testPool1},
+	})
+	pools := map[common.Address]fakePool{
+		testPool1: {token0: testTokenA, token1: testTokenB, reserve0: ether(1), reserve1: ether(1)},
+	}
+
+	o := NewRoutingOracle(poolCaller(t, pools, nil), registry, NewMockPriceOracle(t))
+	if _, found := o.FetchPrice(context.Background(), testTokenA); found {
+		t.Error("Pools that only reference each other should not produce a price")
+	}
+}
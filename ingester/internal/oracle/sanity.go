@@ -0,0 +1,152 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/contract"
+)
+
+// RejectReason says why a Chainlink answer was read but not used.
+type RejectReason string
+
+const (
+	RejectIncompleteRound RejectReason = "incomplete_round"
+	RejectNonPositive     RejectReason = "non_positive_answer"
+	RejectCircuitBreaker  RejectReason = "circuit_breaker"
+	RejectOutOfBounds     RejectReason = "out_of_bounds"
+	RejectStale           RejectReason = "stale"
+	RejectDeviation       RejectReason = "deviation"
+)
+
+// RejectionError is returned for a feed answer that failed a sanity check, as opposed to a
+// failed RPC call.
+type RejectionError struct {
+	Aggregator common.Address
+	Reason     RejectReason
+	Detail     string
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("chainlink feed %s answer rejected (%s): %s", e.Aggregator.Hex(), e.Reason, e.Detail)
+}
+
+// answerBounds are an OCR aggregator's minAnswer/maxAnswer. The aggregator clamps reports to
+// them, so an answer at either bound means the market left the range and the feed is wrong.
+// Both are nil for aggregators that do not expose bounds.
+type answerBounds struct {
+	Min, Max *big.Int
+}
+
+// fetchAnswerBounds reads the bounds from the aggregator behind a feed proxy. Failures are
+// cached as "no bounds": older aggregator versions do not implement these methods.
+func fetchAnswerBounds(ctx context.Context, caller contract.ContractCaller, proxy common.Address) (answerBounds, bool) {
+	aggregatorABI, err := contract.GetABI(contract.ChainlinkAggregator)
+	if err != nil {
+		return answerBounds{}, false
+	}
+
+	var aggregator common.Address
+	if err := contract.CallContract(ctx, caller, proxy, aggregatorABI, "aggregator", &aggregator); err != nil {
+		logger.Debug("Feed does not expose its aggregator, skipping answer bounds", "feed", proxy.Hex(), "error", err)
+		return answerBounds{}, true
+	}
+	var bounds answerBounds
+	if err := contract.CallContract(ctx, caller, aggregator, aggregatorABI, "minAnswer", &bounds.Min); err != nil {
+		logger.Debug("Aggregator does not expose answer bounds", "feed", proxy.Hex(), "aggregator", aggregator.Hex(), "error", err)
+		return answerBounds{}, true
+	}
+	if err := contract.CallContract(ctx, caller, aggregator, aggregatorABI, "maxAnswer", &bounds.Max); err != nil {
+		logger.Debug("Aggregator does not expose answer bounds", "feed", proxy.Hex(), "aggregator", aggregator.Hex(), "error", err)
+		return answerBounds{}, true
+	}
+	return bounds, true
+}
+
+// checkRound applies the checks that need only the raw round data.
+func checkRound(feed Feed, bounds answerBounds, roundID, answer, updatedAt, answeredInRound *big.Int) error {
+	reject := func(reason RejectReason, detail string) error {
+		return &RejectionError{Aggregator: feed.Aggregator, Reason: reason, Detail: detail}
+	}
+	switch {
+	case updatedAt.Sign() == 0:
+		return reject(RejectIncompleteRound, fmt.Sprintf("round %s has no updatedAt", roundID))
+	case answeredInRound.Cmp(roundID) < 0:
+		return reject(RejectIncompleteRound, fmt.Sprintf("round %s answered in earlier round %s", roundID, answeredInRound))
+	case answer.Sign() <= 0:
+		return reject(RejectNonPositive, fmt.Sprintf("answer %s", answer))
+	case bounds.Min != nil && answer.Cmp(bounds.Min) <= 0:
+		return reject(RejectCircuitBreaker, fmt.Sprintf("answer %s at aggregator minAnswer %s", answer, bounds.Min))
+	case bounds.Max != nil && answer.Cmp(bounds.Max) >= 0:
+		return reject(RejectCircuitBreaker, fmt.Sprintf("answer %s at aggregator maxAnswer %s", answer, bounds.Max))
+	}
+	return nil
+}
+
+// checkPrice applies the registry's USD bounds and the heartbeat, as of the block being priced.
+func checkPrice(feed Feed, quote Quote, now time.Time) error {
+	switch {
+	case feed.MinPrice > 0 && quote.Price < feed.MinPrice:
+		return &RejectionError{Aggregator: feed.Aggregator, Reason: RejectOutOfBounds, Detail: fmt.Sprintf("price %g below %g", quote.Price, feed.MinPrice)}
+	case feed.MaxPrice > 0 && quote.Price > feed.MaxPrice:
+		return &RejectionError{Aggregator: feed.Aggregator, Reason: RejectOutOfBounds, Detail: fmt.Sprintf("price %g above %g", quote.Price, feed.MaxPrice)}
+	case now.Sub(quote.UpdatedAt) > feed.Heartbeat:
+		return &RejectionError{Aggregator: feed.Aggregator, Reason: RejectStale, Detail: fmt.Sprintf("updated %s before %s", now.Sub(quote.UpdatedAt), now.Format(time.RFC3339))}
+	}
+	return nil
+}
+
+// deviationGuard rejects an answer that moved more than the feed's MaxDeviation from the last
+// accepted round. A jump confirmed by the next round is accepted, so a real market move only
+// costs one round. Rounds further apart than the heartbeat are not compared: backfills read
+// blocks far apart in time, where large moves are expected.
+type deviationGuard struct {
+	mu      sync.Mutex
+	last    map[common.Address]Quote
+	pending map[common.Address]Quote
+}
+
+func newDeviationGuard() *deviationGuard {
+	return &deviationGuard{
+		last:    make(map[common.Address]Quote),
+		pending: make(map[common.Address]Quote),
+	}
+}
+
+func (g *deviationGuard) check(feed Feed, quote Quote) error {
+	if feed.MaxDeviation <= 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	last, seen := g.last[feed.Aggregator]
+	compare := seen && last.RoundID.Cmp(quote.RoundID) != 0 &&
+		quote.UpdatedAt.Sub(last.UpdatedAt).Abs() <= feed.Heartbeat
+	if compare && relativeChange(last.Price, quote.Price) > feed.MaxDeviation {
+		pending, confirming := g.pending[feed.Aggregator]
+		if !confirming || pending.RoundID.Cmp(quote.RoundID) == 0 || relativeChange(pending.Price, quote.Price) > feed.MaxDeviation {
+			g.pending[feed.Aggregator] = quote
+			return &RejectionError{
+				Aggregator: feed.Aggregator,
+				Reason:     RejectDeviation,
+				Detail:     fmt.Sprintf("price %g moved more than %g from %g in round %s", quote.Price, feed.MaxDeviation, last.Price, last.RoundID),
+			}
+		}
+	}
+
+	g.last[feed.Aggregator] = quote
+	delete(g.pending, feed.Aggregator)
+	return nil
+}
+
+func relativeChange(from, to float64) float64 {
+	return math.Abs(to-from) / from
+}
@@ -0,0 +1,153 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+)
+
+var testOCRAggregator = common.HexToAddress("0x00000000000000000000000000000000000000f9")
+
+type round struct {
+	id, answer, answeredIn int64
+	updatedAt              time.Time
+}
+
+// roundCaller serves the current round from *r (8 decimals) and the answer bounds when
+// minAnswer/maxAnswer are non-nil. It records the block of every latestRoundData call.
+func roundCaller(t *testing.T, r *round, minAnswer, maxAnswer *big.Int, roundBlocks *[]*big.Int) contract.ContractCaller {
+	t.Helper()
+	aggregatorABI, err := contract.GetABI(contract.ChainlinkAggregator)
+	if err != nil {
+		t.Fatalf("load ABI: %v", err)
+	}
+	return contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		method, err := aggregatorABI.MethodById(msg.Data[:4])
+		if err != nil {
+			return nil, err
+		}
+		switch method.Name {
+		case "decimals":
+			return method.Outputs.Pack(uint8(8))
+		case "latestRoundData":
+			if roundBlocks != nil {
+				*roundBlocks = append(*roundBlocks, blockNumber)
+			}
+			updatedAt := big.NewInt(0)
+			if !r.updatedAt.IsZero() {
+				updatedAt = big.NewInt(r.updatedAt.Unix())
+			}
+			return method.Outputs.Pack(big.NewInt(r.id), big.NewInt(r.answer), updatedAt, updatedAt, big.NewInt(r.answeredIn))
+		case "aggregator":
+			if minAnswer == nil {
+				return nil, fmt.Errorf("execution reverted")
+			}
+			return method.Outputs.Pack(testOCRAggregator)
+		case "minAnswer":
+			return method.Outputs.Pack(minAnswer)
+		case "maxAnswer":
+			return method.Outputs.Pack(maxAnswer)
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	})
+}
+
+func TestChainlinkOracle_Read_SanityChecks(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		round     round
+		minAnswer *big.Int
+		maxAnswer *big.Int
+		reason    RejectReason
+	}{
+		{"valid", round{id: 5, answer: 85_000_000, answeredIn: 5, updatedAt: now}, nil, nil, ""},
+		{"answered in earlier round", round{id: 5, answer: 85_000_000, answeredIn: 4, updatedAt: now}, nil, nil, RejectIncompleteRound},
+		{"round not updated", round{id: 5, answer: 85_000_000, answeredIn: 5}, nil, nil, RejectIncompleteRound},
+		{"zero answer", round{id: 5, answer: 0, answeredIn: 5, updatedAt: now}, nil, nil, RejectNonPositive},
+		{"negative answer", round{id: 5, answer: -1, answeredIn: 5, updatedAt: now}, nil, nil, RejectNonPositive},
+		{"clamped to minAnswer", round{id: 5, answer: 1_000_000, answeredIn: 5, updatedAt: now}, big.NewInt(1_000_000), big.NewInt(1e12), RejectCircuitBreaker},
+		{"clamped to maxAnswer", round{id: 5, answer: 1e12, answeredIn: 5, updatedAt: now}, big.NewInt(1_000_000), big.NewInt(1e12), RejectCircuitBreaker},
+		{"within aggregator bounds", round{id: 5, answer: 85_000_000, answeredIn: 5, updatedAt: now}, big.NewInt(1_000_000), big.NewInt(1e12), ""},
+		{"above registry maxPrice", round{id: 5, answer: 20_000_000_000, answeredIn: 5, updatedAt: now}, nil, nil, RejectOutOfBounds},
+		{"below registry minPrice", round{id: 5, answer: 500_000, answeredIn: 5, updatedAt: now}, nil, nil, RejectOutOfBounds},
+		{"stale", round{id: 5, answer: 85_000_000, answeredIn: 5, updatedAt: now.Add(-2 * time.Hour)}, nil, nil, RejectStale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(nil, map[common.Address]Feed{
+				testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour, MinPrice: 0.01, MaxPrice: 100},
+			}, nil)
+			o := NewChainlinkOracle(roundCaller(t, &tt.round, tt.minAnswer, tt.maxAnswer, nil), registry)
+
+			quote, err := o.Read(context.Background(), testTokenA, chain.Latest)
+
+			if tt.reason == "" {
+				if err != nil || quote.Price != 0.85 {
+					t.Errorf("Expected $0.85, got %f (err=%v)", quote.Price, err)
+				}
+				return
+			}
+			var rejection *RejectionError
+			if !errors.As(err, &rejection) || rejection.Reason != tt.reason {
+				t.Errorf("Expected %s rejection, got %v", tt.reason, err)
+			}
+		})
+	}
+}
+
+func TestChainlinkOracle_Read_RejectionDoesNotFallBack(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour}}, nil)
+	r := round{id: 5, answer: -1, answeredIn: 5, updatedAt: time.Now()}
+	var roundBlocks []*big.Int
+	o := NewChainlinkOracle(roundCaller(t, &r, nil, nil, &roundBlocks), registry)
+
+	if _, err := o.Read(context.Background(), testTokenA, chain.Block{Number: 500, Time: time.Now()}); err == nil {
+		t.Fatal("Expected a rejection")
+	}
+	if len(roundBlocks) != 1 {
+		t.Errorf("A rejected historical answer should not be retried at latest, got reads at %v", roundBlocks)
+	}
+}
+
+func TestChainlinkOracle_Read_DeviationNeedsConfirmation(t *testing.T) {
+	registry := NewRegistry(nil, map[common.Address]Feed{
+		testTokenA: {Aggregator: testFeed1, Heartbeat: time.Hour, MaxDeviation: 0.5},
+	}, nil)
+	start := time.Now().Add(-10 * time.Minute)
+	r := round{id: 1, answer: 100_000_000, answeredIn: 1, updatedAt: start}
+	o := NewChainlinkOracle(roundCaller(t, &r, nil, nil, nil), registry)
+
+	steps := []struct {
+		answer int64
+		reason RejectReason
+	}{
+		{100_000_000, ""},              // $1.00 sets the reference
+		{300_000_000, RejectDeviation}, // $3.00 is a 200% jump
+		{310_000_000, ""},              // $3.10 confirms the move
+		{320_000_000, ""},              // and becomes the new reference
+	}
+	for i, step := range steps {
+		r = round{id: int64(i + 1), answer: step.answer, answeredIn: int64(i + 1), updatedAt: start.Add(time.Duration(i) * time.Minute)}
+
+		_, err := o.Read(context.Background(), testTokenA, chain.Latest)
+
+		var rejection *RejectionError
+		switch {
+		case step.reason == "" && err != nil:
+			t.Errorf("Round %d: expected acceptance, got %v", r.id, err)
+		case step.reason != "" && (!errors.As(err, &rejection) || rejection.Reason != step.reason):
+			t.Errorf("Round %d: expected %s rejection, got %v", r.id, step.reason, err)
+		}
+	}
+}
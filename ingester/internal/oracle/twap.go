@@ -0,0 +1,214 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/cache"
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+)
+
+// HeaderReader reads block headers; *ethclient.Client satisfies it. A nil number asks for the
+// head of the chain.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// q112 is the UQ112x112 fixed-point scale of the V2 cumulative prices.
+var q112 = new(big.Int).Lsh(big.NewInt(1), 112)
+
+// uint256Modulus wraps cumulative price differences: the counters are meant to overflow.
+var uint256Modulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// TWAP is a pair's time-weighted average price between two blocks, in raw token units:
+// Price0 is token1 per token0 and Price1 token0 per token1, before decimal adjustment.
+type TWAP struct {
+	Price0, Price1 float64
+	Start, End     chain.Block
+}
+
+// DecimalAdjusted is Price0 in whole tokens: token1 per token0.
+func (t TWAP) DecimalAdjusted(decimals0, decimals1 uint8) float64 {
+	return t.Price0 * decimalShift(decimals0, decimals1)
+}
+
+// PairTWAP averages pair's price over the windowBlocks blocks ending at end by sampling
+// price0CumulativeLast/price1CumulativeLast at both ends. The counters only advance on the
+// pair's first trade of a block, so each sample is brought up to its block's timestamp with
+// the reserves held since then, as UniswapV2OracleLibrary does. Both calls need archive state
+// for the start block.
+func PairTWAP(ctx context.Context, caller contract.ContractCaller, headers HeaderReader, pair common.Address, end chain.Block, windowBlocks uint64) (TWAP, error) {
+	if windowBlocks == 0 {
+		return TWAP{}, fmt.Errorf("TWAP window must span at least one block")
+	}
+
+	endHeader, err := headers.HeaderByNumber(ctx, end.BigNumber())
+	if err != nil {
+		return TWAP{}, fmt.Errorf("fetch header for block %d: %w", end.Number, err)
+	}
+	if endHeader.Number.Uint64() <= windowBlocks {
+		return TWAP{}, fmt.Errorf("block %d is inside the first %d-block window", endHeader.Number.Uint64(), windowBlocks)
+	}
+	startNumber := endHeader.Number.Uint64() - windowBlocks
+	startHeader, err := headers.HeaderByNumber(ctx, new(big.Int).SetUint64(startNumber))
+	if err != nil {
+		return TWAP{}, fmt.Errorf("fetch header for block %d: %w", startNumber, err)
+	}
+	if endHeader.Time <= startHeader.Time {
+		return TWAP{}, fmt.Errorf("blocks %d and %d share timestamp %d", startNumber, endHeader.Number.Uint64(), endHeader.Time)
+	}
+
+	start0, start1, err := cumulativePricesAt(ctx, caller, pair, startHeader)
+	if err != nil {
+		return TWAP{}, err
+	}
+	end0, end1, err := cumulativePricesAt(ctx, caller, pair, endHeader)
+	if err != nil {
+		return TWAP{}, err
+	}
+
+	elapsed := endHeader.Time - startHeader.Time
+	return TWAP{
+		Price0: averagePrice(start0, end0, elapsed),
+		Price1: averagePrice(start1, end1, elapsed),
+		Start:  chain.Block{Number: startNumber, Time: headerTime(startHeader)},
+		End:    chain.Block{Number: endHeader.Number.Uint64(), Time: headerTime(endHeader)},
+	}, nil
+}
+
+// cumulativePricesAt returns the pair's cumulative prices as of header's timestamp.
+func cumulativePricesAt(ctx context.Context, caller contract.ContractCaller, pair common.Address, header *types.Header) (*big.Int, *big.Int, error) {
+	pairABI, err := contract.GetABI(contract.UniswapV2Pair)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var price0, price1 *big.Int
+	var reserves poolReserves
+	if err := contract.CallContractAt(ctx, caller, pair, pairABI, "price0CumulativeLast", header.Number, &price0); err != nil {
+		return nil, nil, err
+	}
+	if err := contract.CallContractAt(ctx, caller, pair, pairABI, "price1CumulativeLast", header.Number, &price1); err != nil {
+		return nil, nil, err
+	}
+	if err := contract.CallContractAt(ctx, caller, pair, pairABI, "getReserves", header.Number, &reserves); err != nil {
+		return nil, nil, err
+	}
+	if reserves.Reserve0.Sign() <= 0 || reserves.Reserve1.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("pair %s has no reserves at block %s", pair.Hex(), header.Number)
+	}
+
+	// Timestamps are uint32 on-chain; the subtraction wraps the same way the pair's does.
+	if sinceUpdate := uint32(header.Time) - reserves.BlockTimestampLast; sinceUpdate > 0 {
+		seconds := new(big.Int).SetUint64(uint64(sinceUpdate))
+		price0 = new(big.Int).Add(price0, fixedPointRatio(reserves.Reserve1, reserves.Reserve0, seconds))
+		price1 = new(big.Int).Add(price1, fixedPointRatio(reserves.Reserve0, reserves.Reserve1, seconds))
+	}
+	return price0.Mod(price0, uint256Modulus), price1.Mod(price1, uint256Modulus), nil
+}
+
+// fixedPointRatio is numerator/denominator as UQ112x112, times seconds.
+func fixedPointRatio(numerator, denominator, seconds *big.Int) *big.Int {
+	ratio := new(big.Int).Lsh(numerator, 112)
+	ratio.Quo(ratio, denominator)
+	return ratio.Mul(ratio, seconds)
+}
+
+func averagePrice(start, end *big.Int, elapsed uint64) float64 {
+	delta := new(big.Int).Sub(end, start)
+	delta.Mod(delta, uint256Modulus)
+	average := new(big.Float).SetInt(delta)
+	average.Quo(average, new(big.Float).SetUint64(elapsed))
+	average.Quo(average, new(big.Float).SetInt(q112))
+	price, _ := average.Float64()
+	return price
+}
+
+func headerTime(header *types.Header) time.Time {
+	return time.Unix(int64(header.Time), 0)
+}
+
+// TWAPOracle prices tokens through the TWAP of their registry reference pool instead of its
+// spot reserves, so a price cannot be moved within a single block. The pool's other token is
+// priced by quotes.
+type TWAPOracle struct {
+	caller       contract.ContractCaller
+	headers      HeaderReader
+	registry     *Registry
+	quotes       PriceOracle
+	windowBlocks uint64
+	poolTokens   *cache.Cache[common.Address, poolTokens]
+}
+
+// NewTWAPOracle averages reference pools over windowBlocks blocks.
+func NewTWAPOracle(caller contract.ContractCaller, headers HeaderReader, registry *Registry, quotes PriceOracle, windowBlocks uint64) *TWAPOracle {
+	return &TWAPOracle{
+		caller:       caller,
+		headers:      headers,
+		registry:     registry,
+		quotes:       quotes,
+		windowBlocks: windowBlocks,
+		poolTokens:   cache.NewCache[common.Address, poolTokens](0),
+	}
+}
+
+func (o *TWAPOracle) FetchPrice(ctx context.Context, tokenAddress common.Address) (float64, bool) {
+	quote, found := o.QuoteAt(ctx, tokenAddress, chain.Latest)
+	return quote.Price, found
+}
+
+// QuoteAt averages the token's reference pool over the window ending at block. The quote
+// carries the Chainlink round of the other token's price, like a reference-pool quote.
+func (o *TWAPOracle) QuoteAt(ctx context.Context, tokenAddress common.Address, block chain.Block) (Quote, bool) {
+	route, exists := o.registry.ReferencePool(tokenAddress)
+	if !exists {
+		return Quote{}, false
+	}
+	tokens, found := o.poolTokens.GetOrFetch(ctx, route.Pool, func(ctx context.Context, pool common.Address) (poolTokens, bool) {
+		return fetchPoolTokens(ctx, o.caller, pool)
+	})
+	if !found {
+		return Quote{}, false
+	}
+
+	twap, err := PairTWAP(ctx, o.caller, o.headers, route.Pool, block, o.windowBlocks)
+	if err != nil {
+		logger.Debug("Reference pool TWAP unavailable", "token", tokenAddress.Hex(), "pool", route.Pool.Hex(), "error", err)
+		return Quote{}, false
+	}
+
+	var quoteToken common.Address
+	var price float64
+	switch tokenAddress {
+	case tokens.Token0:
+		quoteToken, price = tokens.Token1, twap.Price0*decimalShift(tokens.Decimals0, tokens.Decimals1)
+	case tokens.Token1:
+		quoteToken, price = tokens.Token0, twap.Price1*decimalShift(tokens.Decimals1, tokens.Decimals0)
+	default:
+		logger.Warn("Reference pool does not contain token", "token", tokenAddress.Hex(), "pool", route.Pool.Hex())
+		return Quote{}, false
+	}
+
+	quoteUSD, found := o.quotes.QuoteAt(ctx, quoteToken, block)
+	if !found || quoteUSD.Price <= 0 || price <= 0 {
+		return Quote{}, false
+	}
+	return Quote{
+		Price:     price * quoteUSD.Price,
+		Source:    PriceSourcePoolTWAP,
+		RoundID:   quoteUSD.RoundID,
+		UpdatedAt: quoteUSD.UpdatedAt,
+	}, true
+}
+
+// decimalShift is 10^(base-quote), turning a raw quote-per-base ratio into whole tokens.
+func decimalShift(baseDecimals, quoteDecimals uint8) float64 {
+	return math.Pow10(int(baseDecimals) - int(quoteDecimals))
+}
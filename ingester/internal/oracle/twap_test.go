@@ -0,0 +1,161 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"ingester/internal/chain"
+	"ingester/internal/contract"
+)
+
+// pairState is what a V2 pair reports at one block.
+type pairState struct {
+	price0Cumulative, price1Cumulative *big.Int
+	reserve0, reserve1                 *big.Int
+	blockTimestampLast                 uint32
+}
+
+// twapCaller serves token0/token1/decimals (18) and per-block cumulative prices and reserves
+// for a single pair.
+func twapCaller(t *testing.T, token0, token1 common.Address, states map[uint64]pairState) contract.ContractCaller {
+	t.Helper()
+	pairABI, err := contract.GetABI(contract.UniswapV2Pair)
+	if err != nil {
+		t.Fatalf("load pair ABI: %v", err)
+	}
+	decimalsABI, err := contract.GetABI(contract.ERC20Decimals)
+	if err != nil {
+		t.Fatalf("load decimals ABI: %v", err)
+	}
+	return contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		if *msg.To != testPool1 {
+			return decimalsABI.Methods["decimals"].Outputs.Pack(uint8(18))
+		}
+		method, err := pairABI.MethodById(msg.Data[:4])
+		if err != nil {
+			return nil, err
+		}
+		switch method.Name {
+		case "token0":
+			return method.Outputs.Pack(token0)
+		case "token1":
+			return method.Outputs.Pack(token1)
+		}
+		state, exists := states[blockNumber.Uint64()]
+		if !exists {
+			return nil, fmt.Errorf("missing trie node for block %s", blockNumber)
+		}
+		switch method.Name {
+		case "price0CumulativeLast":
+			return method.Outputs.Pack(state.price0Cumulative)
+		case "price1CumulativeLast":
+			return method.Outputs.Pack(state.price1Cumulative)
+		case "getReserves":
+			return method.Outputs.Pack(state.reserve0, state.reserve1, state.blockTimestampLast)
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	})
+}
+
+// headerReader serves headers by number; a nil number returns the head.
+type headerReader struct {
+	head  uint64
+	times map[uint64]uint64
+}
+
+func (r headerReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	n := r.head
+	if number != nil {
+		n = number.Uint64()
+	}
+	timestamp, exists := r.times[n]
+	if !exists {
+		return nil, fmt.Errorf("header %d not found", n)
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(n), Time: timestamp}, nil
+}
+
+// rpcClient joins a caller and a header reader into a Client.
+type rpcClient struct {
+	contract.ContractCaller
+	headerReader
+}
+
+// uq112 is value * 2^112 * seconds, a cumulative-price increment.
+func uq112(value, seconds int64) *big.Int {
+	increment := new(big.Int).Lsh(big.NewInt(value), 112)
+	return increment.Mul(increment, big.NewInt(seconds))
+}
+
+// twapStates moves the price from 2 to 4 token1 per token0 halfway through blocks 100..110.
+// The end sample's counter stops at the trade 50s in and must be extended by 50s of the new
+// reserves; start0 sits just below 2^256 so the counter wraps.
+func twapStates() map[uint64]pairState {
+	start0 := new(big.Int).Sub(uint256Modulus, uq112(1, 10))
+	start1 := uq112(7, 1)
+	return map[uint64]pairState{
+		100: {
+			price0Cumulative: start0, price1Cumulative: start1,
+			reserve0: ether(1000), reserve1: ether(2000), blockTimestampLast: 1000,
+		},
+		110: {
+			price0Cumulative: new(big.Int).Mod(new(big.Int).Add(start0, uq112(2, 50)), uint256Modulus),
+			price1Cumulative: new(big.Int).Add(start1, new(big.Int).Rsh(uq112(1, 50), 1)),
+			reserve0:         ether(1000), reserve1: ether(4000), blockTimestampLast: 1050,
+		},
+	}
+}
+
+func TestPairTWAP_AveragesCumulativePrices(t *testing.T) {
+	headers := headerReader{head: 110, times: map[uint64]uint64{100: 1000, 110: 1100}}
+	caller := twapCaller(t, testTokenA, testWrapped, twapStates())
+
+	twap, err := PairTWAP(context.Background(), caller, headers, testPool1, chain.Latest, 10)
+	if err != nil {
+		t.Fatalf("PairTWAP failed: %v", err)
+	}
+
+	// (2*50s + 4*50s) / 100s and (0.5*50s + 0.25*50s) / 100s
+	if twap.Price0 != 3 || twap.Price1 != 0.375 {
+		t.Errorf("Expected TWAP 3 / 0.375, got %g / %g", twap.Price0, twap.Price1)
+	}
+	if twap.Start.Number != 100 || twap.End.Number != 110 || twap.End.Time.Unix() != 1100 {
+		t.Errorf("Expected window 100..110 ending at 1100, got %+v..%+v", twap.Start, twap.End)
+	}
+}
+
+func TestPairTWAP_RequiresHistoricalState(t *testing.T) {
+	headers := headerReader{head: 110, times: map[uint64]uint64{100: 1000, 110: 1100}}
+	states := twapStates()
+	delete(states, 100)
+
+	_, err := PairTWAP(context.Background(), twapCaller(t, testTokenA, testWrapped, states), headers, testPool1, chain.Latest, 10)
+
+	if err == nil {
+		t.Error("Expected an error when the start block's state is unavailable")
+	}
+}
+
+func TestTWAPOracle_PricesThroughQuoteToken(t *testing.T) {
+	registry := NewRegistry(nil, nil, map[common.Address]ReferencePool{testTokenA: {Pool: testPool1}})
+	headers := headerReader{times: map[uint64]uint64{100: 1000, 110: 1100}}
+	block := chain.Block{Number: 110}
+	quotes := NewMockPriceOracle(t)
+	quotes.EXPECT().QuoteAt(context.Background(), testWrapped, block).Return(Quote{Price: 0.5, Source: PriceSourceChainlink, RoundID: big.NewInt(9)}, true)
+
+	o := NewTWAPOracle(twapCaller(t, testTokenA, testWrapped, twapStates()), headers, registry, quotes, 10)
+	quote, found := o.QuoteAt(context.Background(), testTokenA, block)
+
+	if !found || quote.Price != 1.5 {
+		t.Errorf("Expected $1.5 (3 WRAPPED at $0.5), got %f (found=%v)", quote.Price, found)
+	}
+	if quote.Source != PriceSourcePoolTWAP || quote.RoundID == nil || quote.RoundID.Int64() != 9 {
+		t.Errorf("Expected a TWAP quote anchored on round 9, got %+v", quote)
+	}
+}
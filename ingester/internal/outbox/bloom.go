@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFalsePositiveRate is the target rate at a filter's capacity. A false positive makes
+// an old event look published, so it is kept far below the duplicate rate it prevents.
+const bloomFalsePositiveRate = 1e-6
+
+// bloomFilter is a fixed-size Bloom filter over event IDs. Fields are exported so a filter
+// round-trips through JSON.
+type bloomFilter struct {
+	Bits   []byte `json:"bits"`
+	Hashes uint32 `json:"hashes"`
+	Count  int    `json:"count"`
+	// FromBlock and ToBlock bound the blocks of the IDs added, for logging and rotation.
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// newBloomFilter sizes a filter for capacity IDs at bloomFalsePositiveRate.
+func newBloomFilter(capacity int) *bloomFilter {
+	capacity = max(capacity, 1)
+	bits := math.Ceil(-float64(capacity) * math.Log(bloomFalsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / float64(capacity) * math.Ln2)
+	return &bloomFilter{
+		Bits:   make([]byte, (int(bits)+7)/8),
+		Hashes: uint32(max(hashes, 1)),
+	}
+}
+
+func (f *bloomFilter) add(eventID string, block uint64) {
+	for _, bit := range f.positions(eventID) {
+		f.Bits[bit/8] |= 1 << (bit % 8)
+	}
+	if f.Count == 0 || block < f.FromBlock {
+		f.FromBlock = block
+	}
+	f.ToBlock = max(f.ToBlock, block)
+	f.Count++
+}
+
+func (f *bloomFilter) contains(eventID string) bool {
+	if f.Count == 0 {
+		return false
+	}
+	for _, bit := range f.positions(eventID) {
+		if f.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// positions derives the filter's bit positions from two halves of one 64-bit FNV hash
+// (Kirsch-Mitzenmacher double hashing).
+func (f *bloomFilter) positions(eventID string) []uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(eventID))
+	sum := hash.Sum64()
+	low, high := sum&math.MaxUint32, sum>>32|1
+
+	size := uint64(len(f.Bits)) * 8
+	positions := make([]uint64, f.Hashes)
+	for i := range positions {
+		positions[i] = (low + uint64(i)*high) % size
+	}
+	return positions
+}
@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"ingester/internal/cache"
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+const (
+	eventKeyPrefix = "event:"
+	checkpointKey  = `"checkpoint"`
+)
+
+// Outbox is a write-ahead log of the events between the listener and the publisher. Every
+// event is recorded before it enters the finality buffer and removed once the publisher
+// acknowledges it, so a restart replays everything that was buffered or released but not yet
+// published.
+//
+// The checkpoint is the highest block whose events have all been acknowledged, as far as the
+// outbox has seen them; the listener resumes from it (inclusive) after a restart.
+type Outbox struct {
+	storage      cache.Storage
+	mu           sync.Mutex
+	pending      map[string]entry
+	nextSeq      uint64
+	highestAcked uint64
+	checkpoint   uint64
+}
+
+// entry is one unacknowledged event; Seq keeps replay in arrival order.
+type entry struct {
+	Seq   uint64          `json:"seq"`
+	Event json.RawMessage `json:"event"`
+
+	event events.Event
+}
+
+// Open loads the unacknowledged events and the checkpoint from storage. A nil storage keeps
+// the outbox in memory only, which still tracks acknowledgements but does not survive a restart.
+func Open(storage cache.Storage) (*Outbox, error) {
+	o := &Outbox{storage: storage, pending: make(map[string]entry)}
+	if storage == nil {
+		return o, nil
+	}
+
+	stored, err := storage.Load()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range stored {
+		if key == checkpointKey {
+			if err := json.Unmarshal(value, &o.checkpoint); err != nil {
+				return nil, &apperr.DataError{Message: "decode outbox checkpoint", Cause: err}
+			}
+			continue
+		}
+		var eventID string
+		if err := json.Unmarshal([]byte(key), &eventID); err != nil || !strings.HasPrefix(eventID, eventKeyPrefix) {
+			logger.Warn("Skipping unknown outbox record", "key", key)
+			continue
+		}
+		var pending entry
+		if err := json.Unmarshal(value, &pending); err != nil {
+			return nil, &apperr.DataError{Message: fmt.Sprintf("decode outbox entry %s", eventID), Cause: err}
+		}
+		if pending.event, err = events.Decode(pending.Event); err != nil {
+			return nil, &apperr.DataError{Message: fmt.Sprintf("decode outbox event %s", eventID), Cause: err}
+		}
+		o.pending[strings.TrimPrefix(eventID, eventKeyPrefix)] = pending
+		o.nextSeq = max(o.nextSeq, pending.Seq+1)
+	}
+	o.highestAcked = o.checkpoint
+	return o, nil
+}
+
+// Append records event and reports whether it was new. An event already pending (the
+// listener re-reading blocks after a restart) is not recorded twice.
+func (o *Outbox) Append(event events.Event) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	eventID := event.GetEventID()
+	if _, exists := o.pending[eventID]; exists {
+		return false, nil
+	}
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return false, &apperr.DataError{Message: fmt.Sprintf("encode outbox event %s", eventID), Cause: err}
+	}
+	pending := entry{Seq: o.nextSeq, Event: encoded, event: event}
+	if err := o.put(eventKeyPrefix+eventID, pending); err != nil {
+		return false, err
+	}
+	o.pending[eventID] = pending
+	o.nextSeq++
+	return true, nil
+}
+
+// Ack removes a published event and advances the checkpoint past every block that no longer
+// has pending events.
+func (o *Outbox) Ack(event events.Event) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	eventID := event.GetEventID()
+	if _, exists := o.pending[eventID]; !exists {
+		return nil
+	}
+	if o.storage != nil {
+		key, err := json.Marshal(eventKeyPrefix + eventID)
+		if err != nil {
+			return err
+		}
+		if err := o.storage.Delete(key); err != nil {
+			return &apperr.DataError{Message: fmt.Sprintf("remove outbox event %s", eventID), Cause: err}
+		}
+	}
+	delete(o.pending, eventID)
+
+	o.highestAcked = max(o.highestAcked, blockOf(event))
+	checkpoint := o.highestAcked
+	for _, pending := range o.pending {
+		if block := blockOf(pending.event); block <= checkpoint {
+			checkpoint = block - min(block, 1)
+		}
+	}
+	if checkpoint <= o.checkpoint {
+		return nil
+	}
+	if o.storage != nil {
+		value, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+		if err := o.storage.Put([]byte(checkpointKey), value); err != nil {
+			return &apperr.DataError{Message: "persist outbox checkpoint", Cause: err}
+		}
+	}
+	o.checkpoint = checkpoint
+	return nil
+}
+
+// Pending returns the unacknowledged events in the order they were appended.
+func (o *Outbox) Pending() []events.Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries := make([]entry, 0, len(o.pending))
+	for _, pending := range o.pending {
+		entries = append(entries, pending)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+
+	pendingEvents := make([]events.Event, len(entries))
+	for i, pending := range entries {
+		pendingEvents[i] = pending.event
+	}
+	return pendingEvents
+}
+
+// Checkpoint returns the highest fully acknowledged block; 0 when nothing was acknowledged.
+func (o *Outbox) Checkpoint() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.checkpoint
+}
+
+// Durable reports whether the outbox survives a restart.
+func (o *Outbox) Durable() bool {
+	return o.storage != nil
+}
+
+// Len returns the number of unacknowledged events.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+func (o *Outbox) put(key string, pending entry) error {
+	if o.storage == nil {
+		return nil
+	}
+	encodedKey, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	encodedEntry, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	if err := o.storage.Put(encodedKey, encodedEntry); err != nil {
+		return &apperr.DataError{Message: fmt.Sprintf("persist outbox %s", key), Cause: err}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ingester/internal/cache"
+	"ingester/internal/events"
+)
+
+func makeEvent(blockNumber int64, eventID string) events.Event {
+	return events.SwapEvent{
+		BaseEvent: events.BaseEvent{
+			EventType:   events.EventTypeSwap,
+			EventID:     eventID,
+			BlockNumber: blockNumber,
+		},
+		Amount0In: "100",
+	}
+}
+
+func openOutbox(t *testing.T, path string) *Outbox {
+	t.Helper()
+	storage, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	outbox, err := Open(storage)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return outbox
+}
+
+func eventIDs(pending []events.Event) []string {
+	ids := make([]string, len(pending))
+	for i, event := range pending {
+		ids[i] = event.GetEventID()
+	}
+	return ids
+}
+
+func TestOutbox_ReplaysUnacknowledgedEventsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	first := openOutbox(t, path)
+	for _, event := range []events.Event{makeEvent(12, "c"), makeEvent(10, "a"), makeEvent(11, "b")} {
+		if _, err := first.Append(event); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if err := first.Ack(makeEvent(10, "a")); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	second := openOutbox(t, path)
+	pending := second.Pending()
+	if ids := eventIDs(pending); len(ids) != 2 || ids[0] != "c" || ids[1] != "b" {
+		t.Fatalf("Expected c then b, got %v", ids)
+	}
+	if swap, ok := pending[0].(events.SwapEvent); !ok || swap.Amount0In != "100" {
+		t.Errorf("Expected the SwapEvent back, got %#v", pending[0])
+	}
+	if second.Checkpoint() != 10 {
+		t.Errorf("Expected checkpoint 10, got %d", second.Checkpoint())
+	}
+}
+
+func TestOutbox_CheckpointWaitsForLowerPendingBlocks(t *testing.T) {
+	outbox, err := Open(nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for _, event := range []events.Event{makeEvent(10, "a"), makeEvent(10, "b"), makeEvent(11, "c")} {
+		outbox.Append(event)
+	}
+
+	outbox.Ack(makeEvent(11, "c"))
+	if outbox.Checkpoint() != 9 {
+		t.Errorf("Expected checkpoint 9 while block 10 is pending, got %d", outbox.Checkpoint())
+	}
+	outbox.Ack(makeEvent(10, "a"))
+	if outbox.Checkpoint() != 9 {
+		t.Errorf("Expected checkpoint 9 while b is pending, got %d", outbox.Checkpoint())
+	}
+	outbox.Ack(makeEvent(10, "b"))
+	if outbox.Checkpoint() != 11 {
+		t.Errorf("Expected checkpoint 11 once everything is acknowledged, got %d", outbox.Checkpoint())
+	}
+}
+
+func TestOutbox_AppendIgnoresPendingDuplicates(t *testing.T) {
+	outbox, err := Open(nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	added, _ := outbox.Append(makeEvent(10, "a"))
+	again, _ := outbox.Append(makeEvent(10, "a"))
+
+	if !added || again || outbox.Len() != 1 {
+		t.Errorf("Expected one pending event, got added=%v again=%v len=%d", added, again, outbox.Len())
+	}
+}
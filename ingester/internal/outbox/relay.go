@@ -0,0 +1,177 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+	"ingester/internal/finality"
+)
+
+// PublishFunc delivers one event; a nil error acknowledges it.
+type PublishFunc func(ctx context.Context, event events.Event) error
+
+// DeriveFunc computes further events from the events the finality buffer released, such as
+// MEV candidates. It must be deterministic, so a replayed block derives the same event IDs,
+// and derive nothing from derived events, which a replay releases alongside their sources.
+type DeriveFunc func(released []events.Event) []events.Event
+
+// Relay moves events from the listener through the finality buffer to the publisher, recording
+// each in the outbox first and skipping those the SeenSet says were already published.
+// Released events are published in order; the first failure stops the queue until the next
+// Retry, so events are neither lost nor reordered while the publisher is down. Events derived
+// from a release are recorded and queued right after it. A Relay is not safe for concurrent use.
+type Relay struct {
+	outbox  *Outbox
+	seen    *SeenSet
+	buffer  *finality.Buffer
+	derive  DeriveFunc
+	publish PublishFunc
+	ready   []events.Event
+}
+
+// NewRelay builds a relay; derive may be nil.
+func NewRelay(outbox *Outbox, seen *SeenSet, buffer *finality.Buffer, derive DeriveFunc, publish PublishFunc) *Relay {
+	return &Relay{outbox: outbox, seen: seen, buffer: buffer, derive: derive, publish: publish}
+}
+
+// Replay feeds the events left in the outbox by a previous run back into the finality buffer
+// and publishes those that are confirmed.
+func (r *Relay) Replay(ctx context.Context) error {
+	pending := r.outbox.Pending()
+	if len(pending) == 0 {
+		return nil
+	}
+	logger.Info("Replaying outbox", "events", len(pending), "checkpoint", r.outbox.Checkpoint())
+	for _, event := range pending {
+		// Published just before the previous run stopped, but not yet acknowledged.
+		if r.seen.Seen(event) {
+			if err := r.outbox.Ack(event); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.release(r.buffer.Add(event)); err != nil {
+			return err
+		}
+	}
+	return r.drain(ctx)
+}
+
+// Add records event in the outbox, buffers it and publishes whatever the buffer releases.
+// Only an outbox write failure is returned; publish failures are retried by Retry.
+func (r *Relay) Add(ctx context.Context, event events.Event) error {
+	if r.seen.Seen(event) {
+		logger.Debug("Skipping already published event", "event_id", event.GetEventID())
+		return nil
+	}
+	added, err := r.outbox.Append(event)
+	if err != nil {
+		return err
+	}
+	if !added {
+		logger.Debug("Event already pending in outbox", "event_id", event.GetEventID())
+		return nil
+	}
+	if err := r.release(r.buffer.Add(event)); err != nil {
+		return err
+	}
+	return r.drain(ctx)
+}
+
+// Retry publishes the events still queued after a publish failure.
+func (r *Relay) Retry(ctx context.Context) error {
+	return r.drain(ctx)
+}
+
+// DrainReport lists what a shutdown drain left undone.
+type DrainReport struct {
+	// Unconfirmed events are still waiting for confirmations.
+	Unconfirmed int
+	// Unpublished events were released but not published before the deadline.
+	Unpublished int
+	// Durable is whether both survive in the outbox for the next run.
+	Durable bool
+	// Lost counts the unconfirmed and unpublished events a memory-only outbox drops.
+	Lost int
+}
+
+// Drain publishes the queued events, retrying every retryInterval, until none are left or ctx
+// ends. Events still waiting for confirmations are never published: a durable outbox keeps
+// them for the next run to replay, and a memory-only one loses them.
+func (r *Relay) Drain(ctx context.Context, retryInterval time.Duration) (DrainReport, error) {
+	for {
+		if err := r.drain(ctx); err != nil {
+			return DrainReport{}, err
+		}
+		if len(r.ready) == 0 || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+	}
+	report := DrainReport{
+		Unconfirmed: r.buffer.PendingCount(),
+		Unpublished: len(r.ready),
+		Durable:     r.outbox.Durable(),
+	}
+	if !report.Durable {
+		report.Lost = report.Unconfirmed + report.Unpublished
+	}
+	return report, nil
+}
+
+// Queued returns how many released events are waiting to be published.
+func (r *Relay) Queued() int {
+	return len(r.ready)
+}
+
+// release queues the events the buffer released, followed by the events derived from them.
+// Derived events are recorded in the outbox before anything of the release is published, so
+// a restart that replays the release derives nothing new.
+func (r *Relay) release(released []events.Event) error {
+	r.ready = append(r.ready, released...)
+	if r.derive == nil || len(released) == 0 {
+		return nil
+	}
+	for _, derived := range r.derive(released) {
+		if r.seen.Seen(derived) {
+			continue
+		}
+		added, err := r.outbox.Append(derived)
+		if err != nil {
+			return err
+		}
+		if added {
+			r.ready = append(r.ready, derived)
+		}
+	}
+	return nil
+}
+
+// drain publishes queued events in order until one fails. An event the publisher can never
+// encode is dropped so it does not block the queue forever.
+func (r *Relay) drain(ctx context.Context) error {
+	for len(r.ready) > 0 {
+		event := r.ready[0]
+		if err := r.publish(ctx, event); err != nil {
+			var dataErr *apperr.DataError
+			if !errors.As(err, &dataErr) {
+				logger.Warn("Publish failed, will retry", "event_id", event.GetEventID(), "queued", len(r.ready), "error", err)
+				return nil
+			}
+			logger.Error("Dropping event that cannot be published", "event_id", event.GetEventID(), "error", err)
+		} else if err := r.seen.Add(event); err != nil {
+			return err
+		}
+		if err := r.outbox.Ack(event); err != nil {
+			return err
+		}
+		r.ready = r.ready[1:]
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package outbox
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+	"ingester/internal/finality"
+)
+
+func newSeenSet(t *testing.T) *SeenSet {
+	t.Helper()
+	seen, err := OpenSeenSet(nil, 100, 1000)
+	if err != nil {
+		t.Fatalf("OpenSeenSet failed: %v", err)
+	}
+	return seen
+}
+
+// recorder publishes into published, failing while down is set.
+type recorder struct {
+	published []string
+	down      bool
+	poison    string
+}
+
+func (r *recorder) publish(ctx context.Context, event events.Event) error {
+	if event.GetEventID() == r.poison {
+		return &apperr.DataError{Message: "cannot encode"}
+	}
+	if r.down {
+		return &apperr.PublishError{Message: "sidecar unavailable"}
+	}
+	r.published = append(r.published, event.GetEventID())
+	return nil
+}
+
+func TestRelay_RetriesInOrderAfterPublishFailure(t *testing.T) {
+	outbox, _ := Open(nil)
+	publisher := &recorder{down: true}
+	relay := NewRelay(outbox, newSeenSet(t), finality.NewBuffer(0), nil, publisher.publish)
+
+	relay.Add(context.Background(), makeEvent(10, "a"))
+	relay.Add(context.Background(), makeEvent(11, "b"))
+	if relay.Queued() != 2 || outbox.Len() != 2 {
+		t.Fatalf("Expected both events queued and pending, got queued=%d pending=%d", relay.Queued(), outbox.Len())
+	}
+
+	publisher.down = false
+	if err := relay.Retry(context.Background()); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if len(publisher.published) != 2 || publisher.published[0] != "a" || publisher.published[1] != "b" {
+		t.Errorf("Expected a then b, got %v", publisher.published)
+	}
+	if outbox.Len() != 0 || outbox.Checkpoint() != 11 {
+		t.Errorf("Expected an empty outbox at checkpoint 11, got len=%d checkpoint=%d", outbox.Len(), outbox.Checkpoint())
+	}
+}
+
+func TestRelay_DropsEventsThatCannotBeEncoded(t *testing.T) {
+	outbox, _ := Open(nil)
+	publisher := &recorder{poison: "a"}
+	relay := NewRelay(outbox, newSeenSet(t), finality.NewBuffer(0), nil, publisher.publish)
+
+	relay.Add(context.Background(), makeEvent(10, "a"))
+	relay.Add(context.Background(), makeEvent(11, "b"))
+
+	if len(publisher.published) != 1 || publisher.published[0] != "b" || outbox.Len() != 0 {
+		t.Errorf("Expected only b published and nothing pending, got %v (pending=%d)", publisher.published, outbox.Len())
+	}
+}
+
+func TestRelay_ReplaysBufferedEventsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	first := NewRelay(openOutbox(t, path), newSeenSet(t), finality.NewBuffer(2), nil, (&recorder{down: true}).publish)
+	first.Add(context.Background(), makeEvent(10, "a"))
+	first.Add(context.Background(), makeEvent(12, "b")) // releases a, whose publish fails
+
+	publisher := &recorder{}
+	second := NewRelay(openOutbox(t, path), newSeenSet(t), finality.NewBuffer(2), nil, publisher.publish)
+	if err := second.Replay(context.Background()); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if len(publisher.published) != 1 || publisher.published[0] != "a" {
+		t.Errorf("Expected a republished, got %v", publisher.published)
+	}
+
+	// b is still buffered; the listener re-reading it after the restart must not duplicate it.
+	second.Add(context.Background(), makeEvent(12, "b"))
+	second.Add(context.Background(), makeEvent(14, "c"))
+	if len(publisher.published) != 2 || publisher.published[1] != "b" {
+		t.Errorf("Expected b published once, got %v", publisher.published)
+	}
+}
+
+func TestRelay_QueuesDerivedEventsAfterTheirRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	// One derived event per release, named after the release's first event.
+	derive := func(released []events.Event) []events.Event {
+		if strings.HasPrefix(released[0].GetEventID(), "derived-") {
+			return nil
+		}
+		return []events.Event{makeEvent(released[0].GetBlockNumber(), "derived-"+released[0].GetEventID())}
+	}
+	first := NewRelay(openOutbox(t, path), newSeenSet(t), finality.NewBuffer(2), derive, (&recorder{down: true}).publish)
+	first.Add(context.Background(), makeEvent(10, "a"))
+	first.Add(context.Background(), makeEvent(12, "b")) // releases a, whose publish fails
+
+	publisher := &recorder{}
+	second := NewRelay(openOutbox(t, path), newSeenSet(t), finality.NewBuffer(2), derive, publisher.publish)
+	if err := second.Replay(context.Background()); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	// Re-deriving from the replayed release finds derived-a already pending.
+	if len(publisher.published) != 2 || publisher.published[0] != "a" || publisher.published[1] != "derived-a" {
+		t.Errorf("Expected a then derived-a once, got %v", publisher.published)
+	}
+}
+
+func TestRelay_SkipsAlreadyPublishedEvents(t *testing.T) {
+	outbox, _ := Open(nil)
+	seen := newSeenSet(t)
+	publisher := &recorder{}
+	relay := NewRelay(outbox, seen, finality.NewBuffer(0), nil, publisher.publish)
+
+	relay.Add(context.Background(), makeEvent(10, "a"))
+	relay.Add(context.Background(), makeEvent(10, "a"))
+
+	if len(publisher.published) != 1 || outbox.Len() != 0 {
+		t.Errorf("Expected a published once, got %v (pending=%d)", publisher.published, outbox.Len())
+	}
+	if stats := seen.Stats(); stats.Suppressed != 1 {
+		t.Errorf("Expected 1 suppressed duplicate, got %+v", stats)
+	}
+}
+
+func TestRelay_DrainKeepsUnconfirmedEventsInDurableOutbox(t *testing.T) {
+	outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.log"))
+	publisher := &recorder{}
+	relay := NewRelay(outbox, newSeenSet(t), finality.NewBuffer(5), nil, publisher.publish)
+	relay.Add(context.Background(), makeEvent(10, "a"))
+
+	report, err := relay.Drain(context.Background(), time.Millisecond)
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if len(publisher.published) != 0 || report != (DrainReport{Unconfirmed: 1, Durable: true}) || outbox.Len() != 1 {
+		t.Errorf("Expected a kept for the next run, got %v, %+v (pending=%d)", publisher.published, report, outbox.Len())
+	}
+}
+
+func TestRelay_DrainDoesNotPublishUnconfirmedEventsWithoutDurableOutbox(t *testing.T) {
+	outbox, _ := Open(nil)
+	publisher := &recorder{}
+	relay := NewRelay(outbox, newSeenSet(t), finality.NewBuffer(5), nil, publisher.publish)
+	relay.Add(context.Background(), makeEvent(10, "a"))
+	relay.Add(context.Background(), makeEvent(11, "b"))
+
+	report, err := relay.Drain(context.Background(), time.Millisecond)
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if len(publisher.published) != 0 || report != (DrainReport{Unconfirmed: 2, Lost: 2}) {
+		t.Errorf("Expected nothing published and both lost, got %v, %+v", publisher.published, report)
+	}
+}
+
+func TestRelay_DrainStopsAtDeadline(t *testing.T) {
+	outbox, _ := Open(nil)
+	relay := NewRelay(outbox, newSeenSet(t), finality.NewBuffer(0), nil, (&recorder{down: true}).publish)
+	relay.Add(context.Background(), makeEvent(10, "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err := relay.Drain(ctx, time.Millisecond)
+
+	if err != nil || report.Unpublished != 1 {
+		t.Errorf("Expected a left unpublished at the deadline, got %+v (err=%v)", report, err)
+	}
+}
@@ -0,0 +1,236 @@
+package outbox
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"ingester/internal/cache"
+	apperr "ingester/internal/errors"
+	"ingester/internal/events"
+)
+
+const (
+	seenKeyPrefix     = "seen:"
+	previousFilterKey = `"filter:previous"`
+)
+
+// SeenSet remembers which events were published so re-read blocks (the startup backfill,
+// resubscriptions, resuming from the outbox checkpoint) are not published again.
+//
+// IDs of events within windowBlocks of the highest published block are kept exactly. Every
+// windowBlocks blocks, older IDs move into a Bloom filter; two filters of filterCapacity IDs
+// each are kept, the older one dropped when the newer fills up. Events older than both
+// filters are treated as unseen, so the set stays bounded at the cost of possible duplicates
+// far behind the head, and a filter false positive (about one in a million) skips an event.
+//
+// Only a full filter is persisted, once, when it is rotated out. IDs in the filter still
+// filling up keep their exact records in storage and are added back to it on load, so a
+// prune never rewrites a filter into the log.
+type SeenSet struct {
+	storage        cache.Storage
+	windowBlocks   uint64
+	filterCapacity int
+
+	mu        sync.Mutex
+	exact     map[string]uint64 // event ID -> block
+	highest   uint64
+	nextPrune uint64
+	current   *bloomFilter
+	previous  *bloomFilter
+	// filtered lists the IDs in current whose exact records are still stored.
+	filtered []string
+
+	suppressed         atomic.Uint64
+	suppressedByFilter atomic.Uint64
+}
+
+// SeenStats describes a SeenSet's size and the duplicates it suppressed.
+type SeenStats struct {
+	Tracked            int    `json:"tracked"`
+	Filtered           int    `json:"filtered"`
+	Suppressed         uint64 `json:"suppressed"`
+	SuppressedByFilter uint64 `json:"suppressedByFilter"`
+}
+
+var publishedSeen atomic.Pointer[SeenSet]
+
+func init() {
+	expvar.Publish("dedup", expvar.Func(func() any {
+		if seen := publishedSeen.Load(); seen != nil {
+			return seen.Stats()
+		}
+		return SeenStats{}
+	}))
+}
+
+// OpenSeenSet loads the set from storage; a nil storage keeps it in memory only. The newest
+// set opened is the one published as the "dedup" expvar.
+func OpenSeenSet(storage cache.Storage, windowBlocks uint64, filterCapacity int) (*SeenSet, error) {
+	s := &SeenSet{
+		storage:        storage,
+		windowBlocks:   max(windowBlocks, 1),
+		filterCapacity: filterCapacity,
+		exact:          make(map[string]uint64),
+		current:        newBloomFilter(filterCapacity),
+		previous:       newBloomFilter(filterCapacity),
+	}
+	if storage != nil {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	}
+	publishedSeen.Store(s)
+	return s, nil
+}
+
+func (s *SeenSet) load() error {
+	stored, err := s.storage.Load()
+	if err != nil {
+		return err
+	}
+	for key, value := range stored {
+		switch {
+		case key == previousFilterKey:
+			var filter bloomFilter
+			if err := json.Unmarshal(value, &filter); err != nil {
+				return &apperr.DataError{Message: fmt.Sprintf("decode seen-set filter %s", key), Cause: err}
+			}
+			s.previous = &filter
+		default:
+			var eventID string
+			var block uint64
+			if json.Unmarshal([]byte(key), &eventID) != nil || !strings.HasPrefix(eventID, seenKeyPrefix) ||
+				json.Unmarshal(value, &block) != nil {
+				logger.Warn("Skipping unknown seen-set record", "key", key)
+				continue
+			}
+			s.exact[strings.TrimPrefix(eventID, seenKeyPrefix)] = block
+			s.highest = max(s.highest, block)
+		}
+	}
+	// Rebuild the filter that was still filling up from the records older than the window.
+	s.nextPrune = s.highest + s.windowBlocks
+	return s.prune()
+}
+
+// Seen reports whether event was published before; each positive counts as a suppressed
+// duplicate.
+func (s *SeenSet) Seen(event events.Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eventID := event.GetEventID()
+	if _, exists := s.exact[eventID]; exists {
+		s.suppressed.Add(1)
+		return true
+	}
+	// The exact set is complete within the window, so the filters are only asked about
+	// older events.
+	if blockOf(event)+s.windowBlocks >= s.highest {
+		return false
+	}
+	if s.current.contains(eventID) || s.previous.contains(eventID) {
+		s.suppressed.Add(1)
+		s.suppressedByFilter.Add(1)
+		return true
+	}
+	return false
+}
+
+// Add records event as published.
+func (s *SeenSet) Add(event events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eventID := event.GetEventID()
+	block := blockOf(event)
+	if s.storage != nil {
+		key, err := json.Marshal(seenKeyPrefix + eventID)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(block)
+		if err != nil {
+			return err
+		}
+		if err := s.storage.Put(key, value); err != nil {
+			return &apperr.DataError{Message: fmt.Sprintf("persist seen event %s", eventID), Cause: err}
+		}
+	}
+	s.exact[eventID] = block
+	s.highest = max(s.highest, block)
+
+	if s.highest >= s.nextPrune {
+		s.nextPrune = s.highest + s.windowBlocks
+		return s.prune()
+	}
+	return nil
+}
+
+// prune moves IDs older than the window from the exact set into the current filter. Their
+// stored records stay until the filter is rotated out and persisted.
+func (s *SeenSet) prune() error {
+	for eventID, block := range s.exact {
+		if block+s.windowBlocks >= s.highest {
+			continue
+		}
+		if s.current.Count >= s.filterCapacity {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		s.current.add(eventID, block)
+		delete(s.exact, eventID)
+		if s.storage != nil {
+			s.filtered = append(s.filtered, eventID)
+		}
+	}
+	return nil
+}
+
+// rotate makes the full current filter the previous one, dropping the older previous filter.
+// The filter is saved before the exact records it replaces are deleted, so a crash in between
+// only leaves IDs in both.
+func (s *SeenSet) rotate() error {
+	logger.Info("Rotating seen-set filter", "fromBlock", s.current.FromBlock, "toBlock", s.current.ToBlock, "count", s.current.Count)
+	if s.storage != nil {
+		value, err := json.Marshal(s.current)
+		if err != nil {
+			return err
+		}
+		if err := s.storage.Put([]byte(previousFilterKey), value); err != nil {
+			return &apperr.DataError{Message: "persist seen-set filter", Cause: err}
+		}
+		for _, eventID := range s.filtered {
+			key, err := json.Marshal(seenKeyPrefix + eventID)
+			if err != nil {
+				return err
+			}
+			if err := s.storage.Delete(key); err != nil {
+				return &apperr.DataError{Message: fmt.Sprintf("remove seen event %s", eventID), Cause: err}
+			}
+		}
+		s.filtered = nil
+	}
+	s.previous, s.current = s.current, newBloomFilter(s.filterCapacity)
+	return nil
+}
+
+func (s *SeenSet) Stats() SeenStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SeenStats{
+		Tracked:            len(s.exact),
+		Filtered:           s.current.Count + s.previous.Count,
+		Suppressed:         s.suppressed.Load(),
+		SuppressedByFilter: s.suppressedByFilter.Load(),
+	}
+}
+
+func blockOf(event events.Event) uint64 {
+	return uint64(max(event.GetBlockNumber(), 0))
+}
@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"ingester/internal/cache"
+)
+
+func openSeenSet(t *testing.T, path string, windowBlocks uint64, filterCapacity int) *SeenSet {
+	t.Helper()
+	storage, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	seen, err := OpenSeenSet(storage, windowBlocks, filterCapacity)
+	if err != nil {
+		t.Fatalf("OpenSeenSet failed: %v", err)
+	}
+	return seen
+}
+
+func TestSeenSet_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.log")
+	first := openSeenSet(t, path, 10, 100)
+	for block := int64(1); block <= 40; block++ {
+		if err := first.Add(makeEvent(block, fmt.Sprintf("tx%d:0", block))); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	second := openSeenSet(t, path, 10, 100)
+	if !second.Seen(makeEvent(38, "tx38:0")) {
+		t.Error("Expected a recent event to be remembered exactly")
+	}
+	if !second.Seen(makeEvent(3, "tx3:0")) {
+		t.Error("Expected an old event to be remembered by the filter")
+	}
+	if second.Seen(makeEvent(38, "tx38:1")) || second.Seen(makeEvent(41, "tx41:0")) {
+		t.Error("Unpublished events should not be seen")
+	}
+
+	stats := second.Stats()
+	if stats.Tracked > 20 || stats.Tracked+stats.Filtered != 40 {
+		t.Errorf("Expected old IDs moved to the filter, got %+v", stats)
+	}
+	if stats.Suppressed != 2 || stats.SuppressedByFilter != 1 {
+		t.Errorf("Expected 2 suppressed (1 by filter), got %+v", stats)
+	}
+}
+
+// countingStorage counts the filter records written through it.
+type countingStorage struct {
+	cache.Storage
+	filterPuts int
+}
+
+func (s *countingStorage) Put(key, entry []byte) error {
+	if string(key) == previousFilterKey {
+		s.filterPuts++
+	}
+	return s.Storage.Put(key, entry)
+}
+
+func TestSeenSet_PersistsOnlyRotatedFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.log")
+	fileStorage, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	storage := &countingStorage{Storage: fileStorage}
+	seen, err := OpenSeenSet(storage, 1, 10)
+	if err != nil {
+		t.Fatalf("OpenSeenSet failed: %v", err)
+	}
+	for block := int64(1); block <= 10; block++ {
+		if err := seen.Add(makeEvent(block, fmt.Sprintf("tx%d:0", block))); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if storage.filterPuts != 0 {
+		t.Errorf("Expected no filter writes before the first rotation, got %d", storage.filterPuts)
+	}
+	for block := int64(11); block <= 20; block++ {
+		if err := seen.Add(makeEvent(block, fmt.Sprintf("tx%d:0", block))); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if storage.filterPuts != 1 {
+		t.Errorf("Expected one filter write for one rotation, got %d", storage.filterPuts)
+	}
+	stored, err := storage.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(stored) > 12 {
+		t.Errorf("Expected the rotated filter's exact records to be deleted, %d records left", len(stored))
+	}
+	fileStorage.Close()
+
+	reopened := openSeenSet(t, path, 1, 10)
+	for _, block := range []int64{2, 15, 20} {
+		if !reopened.Seen(makeEvent(block, fmt.Sprintf("tx%d:0", block))) {
+			t.Errorf("Expected block %d's event to be remembered after a restart", block)
+		}
+	}
+}
+
+func TestSeenSet_ForgetsBeyondTwoFilters(t *testing.T) {
+	seen, err := OpenSeenSet(nil, 1, 5)
+	if err != nil {
+		t.Fatalf("OpenSeenSet failed: %v", err)
+	}
+	for block := int64(1); block <= 30; block++ {
+		seen.Add(makeEvent(block, fmt.Sprintf("tx%d:0", block)))
+	}
+
+	if seen.Seen(makeEvent(1, "tx1:0")) {
+		t.Error("Expected the oldest IDs to be forgotten once both filters filled up")
+	}
+	if stats := seen.Stats(); stats.Filtered > 10 {
+		t.Errorf("Expected at most two filters of 5, got %+v", stats)
+	}
+}
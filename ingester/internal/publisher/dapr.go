@@ -15,6 +15,7 @@ import (
 
 	"ingester/internal/avro"
 	"ingester/internal/config"
+	apperr "ingester/internal/errors"
 	"ingester/internal/events"
 )
 
@@ -37,29 +38,37 @@ func CreateCodecMap() (CodecMap, error) {
 	return codecs, nil
 }
 
-func TopicMapperFromEnv() TopicMapper {
+func TopicMapperFromConfig(topics config.TopicConfig) TopicMapper {
 	return func(eventType events.EventType) (string, error) {
 		switch eventType {
 		case events.EventTypeSwap:
-			return config.GetTopicTradingEvents(), nil
+			return topics.TradingEvents, nil
 		case events.EventTypeMint, events.EventTypeBurn, events.EventTypeTransfer:
-			return config.GetTopicLiquidityEvents(), nil
+			return topics.LiquidityEvents, nil
+		case events.EventTypeMevCandidate:
+			if topics.MevEvents == "" {
+				return "", fmt.Errorf("no topic configured for event type: %s", eventType)
+			}
+			return topics.MevEvents, nil
 		default:
 			return "", fmt.Errorf("no mapping for event type: %s", eventType)
 		}
 	}
 }
 
-func DaprPublishURL() URLBuilder {
+func DaprPublishURL(dapr config.DaprConfig) URLBuilder {
 	return func(topic string) string {
 		return fmt.Sprintf("http://%s:%s/v1.0/publish/%s/%s",
-			config.GetDaprHost(),
-			config.GetDaprHTTPPort(),
-			config.GetPubSubName(),
+			dapr.Host,
+			dapr.HTTPPort,
+			dapr.PubSubName,
 			topic)
 	}
 }
 
+// Publish sends event to its topic. A nil error means Dapr accepted it. An event that cannot
+// be encoded fails with a *errors.DataError and will never succeed; a failed or rejected
+// request fails with a *errors.PublishError and may be retried.
 func Publish(
 	ctx context.Context,
 	event events.Event,
@@ -67,7 +76,7 @@ func Publish(
 	topicMapper TopicMapper,
 	urlBuilder URLBuilder,
 	httpDoer HTTPDoer,
-) {
+) error {
 	eventID := event.GetEventID()
 	eventType := event.GetEventType()
 	pair := event.GetPairAddress()
@@ -75,14 +84,14 @@ func Publish(
 	cloudEventJSON, topic, err := createCloudEvent(event, codecs, topicMapper)
 	if err != nil {
 		logger.Error("Failed to prepare payload", "event_id", eventID, "event_type", eventType, "pair", pair, "error", err)
-		return
+		return &apperr.DataError{Message: fmt.Sprintf("prepare payload for event %s", eventID), Cause: err}
 	}
 
 	url := urlBuilder(topic)
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(cloudEventJSON))
 	if err != nil {
 		logger.Error("Failed to create HTTP request", "event_id", eventID, "event_type", eventType, "pair", pair, "url", url, "error", err)
-		return
+		return &apperr.PublishError{Message: fmt.Sprintf("create request for event %s", eventID), Cause: err}
 	}
 
 	request.Header.Set("Content-Type", "application/cloudevents+json")
@@ -91,16 +100,17 @@ func Publish(
 	response, err := httpDoer(request)
 	if err != nil {
 		logger.Warn("Publish failed", "event_id", eventID, "event_type", eventType, "pair", pair, "error", err)
-		return
+		return &apperr.PublishError{Message: fmt.Sprintf("publish event %s", eventID), Cause: err}
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode >= 300 {
 		logger.Warn("Publish failed", "event_id", eventID, "event_type", eventType, "pair", pair, "status", response.StatusCode)
-		return
+		return &apperr.PublishError{Message: fmt.Sprintf("publish event %s: status %d", eventID, response.StatusCode)}
 	}
 
 	logger.Info("Event published", "event_id", eventID, "event_type", eventType, "pair", pair)
+	return nil
 }
 
 func createCloudEvent(event events.Event, codecs CodecMap, topicMapper TopicMapper) ([]byte, string, error) {
@@ -124,7 +134,8 @@ func createCloudEvent(event events.Event, codecs CodecMap, topicMapper TopicMapp
 	cloudEvent := map[string]interface{}{
 		"specversion":     "1.0",
 		"id":              event.GetEventID(),
-		"source":          "ingester/uniswap-v2",
+		"source":          fmt.Sprintf("ingester/eip155:%d/uniswap-v2", event.GetChainID()),
+		"chainid":         event.GetChainID(),
 		"type":            eventType.CloudEventType(),
 		"datacontenttype": "application/avro-binary",
 		"subject":         event.GetPairAddress(),
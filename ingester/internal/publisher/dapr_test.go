@@ -3,11 +3,14 @@ package publisher
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 
+	"ingester/internal/config"
+	apperr "ingester/internal/errors"
 	"ingester/internal/events"
 )
 
@@ -48,8 +51,8 @@ func TestCreateCodecMap(t *testing.T) {
 		t.Fatalf("CreateCodecMap() failed: %v", err)
 	}
 
-	if len(codecs) != 4 {
-		t.Errorf("Expected 4 codecs, got %d", len(codecs))
+	if len(codecs) != 5 {
+		t.Errorf("Expected 5 codecs, got %d", len(codecs))
 	}
 
 	// Verify all event types have codecs
@@ -60,6 +63,18 @@ func TestCreateCodecMap(t *testing.T) {
 	}
 }
 
+func TestTopicMapperFromConfig_MevTopicIsOptional(t *testing.T) {
+	mapper := TopicMapperFromConfig(config.TopicConfig{TradingEvents: "trading", LiquidityEvents: "liquidity"})
+	if _, err := mapper(events.EventTypeMevCandidate); err == nil {
+		t.Error("Expected an error for MEV candidates without a topic")
+	}
+
+	mapper = TopicMapperFromConfig(config.TopicConfig{TradingEvents: "trading", LiquidityEvents: "liquidity", MevEvents: "mev"})
+	if topic, err := mapper(events.EventTypeMevCandidate); err != nil || topic != "mev" {
+		t.Errorf("Expected topic mev, got %q (%v)", topic, err)
+	}
+}
+
 func TestPublish_SwapEvent(t *testing.T) {
 	codecs, _ := CreateCodecMap()
 
@@ -77,6 +92,7 @@ func TestPublish_SwapEvent(t *testing.T) {
 
 	event := events.SwapEvent{
 		BaseEvent: events.BaseEvent{
+			ChainID:         42161,
 			EventType:       events.EventTypeSwap,
 			EventID:         "tx-123-0",
 			PairAddress:     "0xpair",
@@ -97,7 +113,9 @@ func TestPublish_SwapEvent(t *testing.T) {
 		GasPrice:   "50000000000",
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	if err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
 
 	if capturedReq == nil {
 		t.Fatal("Expected HTTP request to be made")
@@ -131,8 +149,11 @@ func TestPublish_SwapEvent(t *testing.T) {
 	if cloudEvent["type"] != "com.dex.events.swap" {
 		t.Errorf("Expected type com.dex.events.swap, got %v", cloudEvent["type"])
 	}
-	if cloudEvent["source"] != "ingester/uniswap-v2" {
-		t.Errorf("Expected source ingester/uniswap-v2, got %v", cloudEvent["source"])
+	if cloudEvent["source"] != "ingester/eip155:42161/uniswap-v2" {
+		t.Errorf("Expected source ingester/eip155:42161/uniswap-v2, got %v", cloudEvent["source"])
+	}
+	if cloudEvent["chainid"] != float64(42161) {
+		t.Errorf("Expected chainid extension 42161, got %v", cloudEvent["chainid"])
 	}
 	if cloudEvent["id"] != "tx-123-0" {
 		t.Errorf("Expected id tx-123-0, got %v", cloudEvent["id"])
@@ -166,7 +187,9 @@ func TestPublish_MintEvent(t *testing.T) {
 		Amount1: "4250",
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	if err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
 
 	if !requestMade {
 		t.Error("Expected HTTP request to be made")
@@ -193,7 +216,9 @@ func TestPublish_BurnEvent(t *testing.T) {
 		Amount1:   "2125",
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	if err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
 
 	if !requestMade {
 		t.Error("Expected HTTP request to be made")
@@ -219,7 +244,9 @@ func TestPublish_TransferEvent(t *testing.T) {
 		Value: "777",
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	if err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
 
 	if !requestMade {
 		t.Error("Expected HTTP request to be made")
@@ -238,7 +265,12 @@ func TestPublish_HTTPError(t *testing.T) {
 		},
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+
+	var publishErr *apperr.PublishError
+	if !errors.As(err, &publishErr) {
+		t.Errorf("Expected PublishError, got %v", err)
+	}
 }
 
 func TestPublish_NetworkError(t *testing.T) {
@@ -253,7 +285,12 @@ func TestPublish_NetworkError(t *testing.T) {
 		},
 	}
 
-	Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+	err := Publish(context.Background(), event, codecs, mockTopicMapper, mockURLBuilder, mockDoer)
+
+	var publishErr *apperr.PublishError
+	if !errors.As(err, &publishErr) {
+		t.Errorf("Expected PublishError, got %v", err)
+	}
 }
 
 func TestCreateCloudEvent(t *testing.T) {
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ingester/internal/contract"
+)
+
+// Length caps for on-chain text. Longer values are truncated rather than dropped: a long name
+// is still more useful downstream than none.
+const (
+	maxSymbolRunes = 32
+	maxNameRunes   = 64
+)
+
+// Metadata describes an ERC-20 token. Symbol and Name are empty when the token does not
+// expose them in a readable form.
+type Metadata struct {
+	Symbol   string `json:"symbol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// decodeText decodes a name() or symbol() result. Tokens that predate the ERC-20 standard
+// (MKR, SAI) return bytes32 instead of string; those come back as exactly one word.
+func decodeText(data []byte, maxRunes int) string {
+	if len(data) == 32 {
+		return sanitize(string(bytes.TrimRight(data, "\x00")), maxRunes)
+	}
+
+	symbolABI, err := contract.GetABI(contract.ERC20Symbol)
+	if err != nil {
+		return ""
+	}
+	values, err := symbolABI.Methods["symbol"].Outputs.Unpack(data)
+	if err != nil || len(values) != 1 {
+		return ""
+	}
+	text, _ := values[0].(string)
+	return sanitize(text, maxRunes)
+}
+
+// sanitize makes on-chain text safe for events: invalid UTF-8 and control characters are
+// dropped, whitespace runs collapse to one space, and the result is cut to maxRunes.
+func sanitize(text string, maxRunes int) string {
+	text = strings.ToValidUTF8(text, "")
+
+	var cleaned strings.Builder
+	cleaned.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			cleaned.WriteRune(' ')
+		case unicode.IsPrint(r):
+			cleaned.WriteRune(r)
+		}
+	}
+	text = strings.Join(strings.Fields(cleaned.String()), " ")
+
+	if utf8.RuneCountInString(text) > maxRunes {
+		text = strings.TrimSpace(string([]rune(text)[:maxRunes]))
+	}
+	return text
+}
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"ingester/internal/contract"
+)
+
+func packString(t *testing.T, value string) []byte {
+	t.Helper()
+	symbolABI, err := contract.GetABI(contract.ERC20Symbol)
+	if err != nil {
+		t.Fatalf("load symbol ABI: %v", err)
+	}
+	packed, err := symbolABI.Methods["symbol"].Outputs.Pack(value)
+	if err != nil {
+		t.Fatalf("pack %q: %v", value, err)
+	}
+	return packed
+}
+
+func bytes32(value string) []byte {
+	word := make([]byte, 32)
+	copy(word, value)
+	return word
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"string", packString(t, "USDC"), "USDC"},
+		{"bytes32", bytes32("MKR"), "MKR"},
+		{"bytes32 name with spaces", bytes32("Maker"), "Maker"},
+		{"invalid UTF-8 dropped", packString(t, "W\xffETH"), "WETH"},
+		{"control characters dropped", packString(t, "US\x00D\x07C"), "USDC"},
+		{"whitespace collapsed", packString(t, "  Wrapped \n\t Ether "), "Wrapped Ether"},
+		{"long symbol truncated", packString(t, strings.Repeat("A", 40)), strings.Repeat("A", maxSymbolRunes)},
+		{"garbage", []byte{1, 2, 3}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeText(tt.data, maxSymbolRunes); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSanitize_TruncatesByRune(t *testing.T) {
+	if got := sanitize("ÄÖÜ€", 3); got != "ÄÖÜ" {
+		t.Errorf("Expected rune-wise truncation, got %q", got)
+	}
+}
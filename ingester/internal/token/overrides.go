@@ -0,0 +1,85 @@
+package token
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+
+	apperr "ingester/internal/errors"
+)
+
+// Override replaces what a token reports on-chain. Empty fields and a nil Decimals keep the
+// on-chain value.
+type Override struct {
+	Symbol   string
+	Name     string
+	Decimals *uint8
+}
+
+type overridesFile struct {
+	Chains map[string][]overrideEntry `yaml:"chains"`
+}
+
+type overrideEntry struct {
+	Token    string `yaml:"token"`
+	Symbol   string `yaml:"symbol"`
+	Name     string `yaml:"name"`
+	Decimals *uint8 `yaml:"decimals"`
+}
+
+// LoadOverrides reads chainID's entries from a JSON or YAML file keyed by chain ID, the same
+// shape as the feed registry. An empty path means no overrides. Every invalid entry is
+// reported as a *errors.ConfigError.
+func LoadOverrides(chainID uint64, path string) (map[common.Address]Override, error) {
+	overrides := make(map[common.Address]Override)
+	if path == "" {
+		return overrides, nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 -- operator-supplied metadata path
+	if err != nil {
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("read token metadata %s", path), Cause: err}
+	}
+	var file overridesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, &apperr.ConfigError{Message: fmt.Sprintf("parse token metadata %s", path), Cause: err}
+	}
+
+	chain := strconv.FormatUint(chainID, 10)
+	var errs []error
+	for _, entry := range file.Chains[chain] {
+		if !common.IsHexAddress(entry.Token) {
+			errs = append(errs, &apperr.ConfigError{Message: fmt.Sprintf("token metadata chain %s: invalid token address %q", chain, entry.Token)})
+			continue
+		}
+		overrides[common.HexToAddress(entry.Token)] = Override{
+			Symbol:   sanitize(entry.Symbol, maxSymbolRunes),
+			Name:     sanitize(entry.Name, maxNameRunes),
+			Decimals: entry.Decimals,
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (o Override) apply(metadata Metadata) Metadata {
+	if o.Symbol != "" {
+		metadata.Symbol = o.Symbol
+	}
+	if o.Name != "" {
+		metadata.Name = o.Name
+	}
+	if o.Decimals != nil {
+		metadata.Decimals = *o.Decimals
+	}
+	return metadata
+}
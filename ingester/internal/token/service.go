@@ -0,0 +1,134 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/cache"
+	"ingester/internal/contract"
+	apperr "ingester/internal/errors"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// Service resolves token metadata: overrides first, then metadata persisted by an earlier
+// run, then RPC. Resolved metadata is kept in memory for the life of the process.
+type Service struct {
+	caller    contract.ContractCaller
+	overrides map[common.Address]Override
+	stored    *cache.Cache[common.Address, Metadata]
+	resolved  *cache.Cache[common.Address, Metadata]
+}
+
+// NewService reads tokens through caller. stored holds complete on-chain reads; pass a
+// persistent cache to keep them across restarts. overrides and stored may be nil.
+func NewService(caller contract.ContractCaller, overrides map[common.Address]Override, stored *cache.Cache[common.Address, Metadata]) *Service {
+	if stored == nil {
+		stored = cache.NewCache[common.Address, Metadata](0)
+	}
+	// Without Storage the cache cannot fail to build.
+	resolved, _ := cache.NewCacheWith[common.Address, Metadata](0, cache.Options{Name: "tokens"})
+	return &Service{
+		caller:    caller,
+		overrides: overrides,
+		stored:    stored,
+		resolved:  resolved,
+	}
+}
+
+// New builds the production service from chainID's entries in overridesFile (may be empty),
+// persisting on-chain reads to storage when it is not nil.
+func New(caller contract.ContractCaller, chainID uint64, overridesFile string, storage cache.Storage) (*Service, error) {
+	overrides, err := LoadOverrides(chainID, overridesFile)
+	if err != nil {
+		return nil, err
+	}
+	var stored *cache.Cache[common.Address, Metadata]
+	if storage != nil {
+		if stored, err = cache.NewPersistentCache[common.Address, Metadata](0, storage); err != nil {
+			return nil, err
+		}
+	}
+	return NewService(caller, overrides, stored), nil
+}
+
+// Resolve returns token's metadata. Only decimals are required: a token whose name or symbol
+// cannot be read resolves with those fields empty, while a failed decimals() read is returned
+// as a *errors.ConnectionError and retried on the next call.
+func (s *Service) Resolve(ctx context.Context, token common.Address) (Metadata, error) {
+	var fetchErr error
+	metadata, found := s.resolved.GetOrFetch(ctx, token, func(ctx context.Context, token common.Address) (Metadata, bool) {
+		metadata, err := s.load(ctx, token)
+		fetchErr = err
+		return metadata, err == nil
+	})
+	if !found {
+		if fetchErr == nil {
+			// Another caller's fetch failed, or ctx ended while waiting for it.
+			fetchErr = &apperr.ConnectionError{Message: fmt.Sprintf("token metadata fetch failed for %s", token.Hex()), Cause: ctx.Err()}
+		}
+		return Metadata{}, fetchErr
+	}
+	return metadata, nil
+}
+
+func (s *Service) load(ctx context.Context, token common.Address) (Metadata, error) {
+	override, hasOverride := s.overrides[token]
+	if metadata, _, stored := s.stored.Get(token); stored {
+		return override.apply(metadata), nil
+	}
+
+	symbol, symbolErr := s.readText(ctx, token, contract.ERC20Symbol, "symbol", maxSymbolRunes)
+	name, nameErr := s.readText(ctx, token, contract.ERC20Name, "name", maxNameRunes)
+	metadata := Metadata{Symbol: symbol, Name: name}
+	complete := symbolErr == nil && nameErr == nil
+	if hasOverride && override.Decimals != nil {
+		metadata.Decimals = *override.Decimals
+		complete = false
+	} else {
+		decimalsABI, err := contract.GetABI(contract.ERC20Decimals)
+		if err != nil {
+			return Metadata{}, &apperr.ConfigError{Message: "failed to get ERC20 decimals ABI", Cause: err}
+		}
+		if err := contract.CallContract(ctx, s.caller, token, decimalsABI, "decimals", &metadata.Decimals); err != nil {
+			return Metadata{}, &apperr.ConnectionError{Message: fmt.Sprintf("rpc token decimals fetch failed for %s", token.Hex()), Cause: err}
+		}
+	}
+
+	// Only persist what was read on-chain in full: a failed call may be transient, and an
+	// override may later be removed.
+	if complete {
+		s.stored.Set(token, metadata, true)
+	}
+	return override.apply(metadata), nil
+}
+
+// readText calls a name()/symbol()-style method without decoding it as string first, so
+// bytes32 results can be handled. Unreadable text is "" without an error; err is set only
+// when the call itself failed.
+func (s *Service) readText(ctx context.Context, token common.Address, abiName contract.ABIName, method string, maxRunes int) (string, error) {
+	methodABI, err := contract.GetABI(abiName)
+	if err != nil {
+		logger.Error("Failed to get token ABI", "abi", abiName, "error", err)
+		return "", err
+	}
+	data, err := methodABI.Pack(method)
+	if err != nil {
+		return "", err
+	}
+	result, err := s.caller.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		logger.Debug("Token metadata fetch failed", "token", token.Hex(), "method", method, "error", err)
+		return "", err
+	}
+	text := decodeText(result, maxRunes)
+	if text == "" {
+		logger.Debug("Token returned no readable text", "token", token.Hex(), "method", method)
+	}
+	return text, nil
+}
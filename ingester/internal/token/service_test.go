@@ -0,0 +1,184 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"ingester/internal/cache"
+	"ingester/internal/contract"
+	apperr "ingester/internal/errors"
+)
+
+var (
+	testMKR  = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	testUSDC = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+)
+
+// tokenCaller answers name/symbol/decimals per token from raw results and counts calls.
+// Tokens without an entry for a method make that call fail.
+func tokenCaller(t *testing.T, results map[common.Address]map[string][]byte, calls *int) contract.ContractCaller {
+	t.Helper()
+	methods := map[string]string{}
+	for _, name := range []contract.ABIName{contract.ERC20Symbol, contract.ERC20Name, contract.ERC20Decimals} {
+		parsed, err := contract.GetABI(name)
+		if err != nil {
+			t.Fatalf("load %s ABI: %v", name, err)
+		}
+		for methodName, method := range parsed.Methods {
+			methods[string(method.ID)] = methodName
+		}
+	}
+	return contract.ContractCallerFunc(func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		*calls++
+		method := methods[string(msg.Data[:4])]
+		result, exists := results[*msg.To][method]
+		if !exists {
+			return nil, fmt.Errorf("execution reverted: %s", method)
+		}
+		return result, nil
+	})
+}
+
+// persistentService opens a service whose on-chain reads persist to path.
+func persistentService(t *testing.T, caller contract.ContractCaller, path string) *Service {
+	t.Helper()
+	storage, err := cache.OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("OpenFileStorage failed: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	service, err := New(caller, 1, "", storage)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return service
+}
+
+func decimalsResult(decimals uint8) []byte {
+	return common.LeftPadBytes([]byte{decimals}, 32)
+}
+
+func TestService_ResolvesBytes32AndStringTokens(t *testing.T) {
+	var calls int
+	caller := tokenCaller(t, map[common.Address]map[string][]byte{
+		testMKR:  {"symbol": bytes32("MKR"), "name": bytes32("Maker"), "decimals": decimalsResult(18)},
+		testUSDC: {"symbol": packString(t, "USDC"), "name": packString(t, "USD Coin"), "decimals": decimalsResult(6)},
+	}, &calls)
+	service := NewService(caller, nil, nil)
+
+	mkr, err := service.Resolve(context.Background(), testMKR)
+	if err != nil || mkr != (Metadata{Symbol: "MKR", Name: "Maker", Decimals: 18}) {
+		t.Errorf("Unexpected MKR metadata %+v (err=%v)", mkr, err)
+	}
+	usdc, err := service.Resolve(context.Background(), testUSDC)
+	if err != nil || usdc != (Metadata{Symbol: "USDC", Name: "USD Coin", Decimals: 6}) {
+		t.Errorf("Unexpected USDC metadata %+v (err=%v)", usdc, err)
+	}
+
+	before := calls
+	if _, err := service.Resolve(context.Background(), testMKR); err != nil || calls != before {
+		t.Errorf("Expected a cached result without RPC calls, got %d calls (err=%v)", calls-before, err)
+	}
+}
+
+func TestService_MissingDecimalsIsConnectionError(t *testing.T) {
+	var calls int
+	caller := tokenCaller(t, map[common.Address]map[string][]byte{
+		testMKR: {"symbol": bytes32("MKR")},
+	}, &calls)
+
+	_, err := NewService(caller, nil, nil).Resolve(context.Background(), testMKR)
+
+	var connErr *apperr.ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("Expected ConnectionError, got %v", err)
+	}
+}
+
+func TestService_OverridesWin(t *testing.T) {
+	var calls int
+	caller := tokenCaller(t, map[common.Address]map[string][]byte{
+		testMKR: {"symbol": bytes32("MKR")},
+	}, &calls)
+	decimals := uint8(18)
+	overrides := map[common.Address]Override{testMKR: {Name: "Maker", Decimals: &decimals}}
+
+	metadata, err := NewService(caller, overrides, nil).Resolve(context.Background(), testMKR)
+
+	if err != nil || metadata != (Metadata{Symbol: "MKR", Name: "Maker", Decimals: 18}) {
+		t.Errorf("Expected on-chain symbol with overridden name and decimals, got %+v (err=%v)", metadata, err)
+	}
+}
+
+func TestService_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.cache")
+	results := map[common.Address]map[string][]byte{
+		testUSDC: {"symbol": packString(t, "USDC"), "name": packString(t, "USD Coin"), "decimals": decimalsResult(6)},
+	}
+
+	var calls int
+	if _, err := persistentService(t, tokenCaller(t, results, &calls), path).Resolve(context.Background(), testUSDC); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	calls = 0
+	metadata, err := persistentService(t, tokenCaller(t, results, &calls), path).Resolve(context.Background(), testUSDC)
+	if err != nil || metadata.Symbol != "USDC" || metadata.Decimals != 6 || calls != 0 {
+		t.Errorf("Expected stored USDC metadata without RPC calls, got %+v after %d calls (err=%v)", metadata, calls, err)
+	}
+}
+
+func TestService_DoesNotPersistFailedReads(t *testing.T) {
+	stored := cache.NewCache[common.Address, Metadata](0)
+	var calls int
+	caller := tokenCaller(t, map[common.Address]map[string][]byte{
+		testUSDC: {"symbol": packString(t, "USDC"), "decimals": decimalsResult(6)},
+	}, &calls)
+
+	if _, err := NewService(caller, nil, stored).Resolve(context.Background(), testUSDC); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if _, _, exists := stored.Get(testUSDC); exists {
+		t.Error("Metadata with a failed name() call should not be persisted")
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.yaml")
+	content := `
+chains:
+  "1":
+    - token: "0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2"
+      symbol: MKR
+      name: Maker
+      decimals: 18
+  "137":
+    - token: "not-an-address"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write overrides: %v", err)
+	}
+
+	overrides, err := LoadOverrides(1, path)
+	if err != nil {
+		t.Fatalf("LoadOverrides failed: %v", err)
+	}
+	if override := overrides[testMKR]; override.Symbol != "MKR" || override.Name != "Maker" || override.Decimals == nil || *override.Decimals != 18 {
+		t.Errorf("Unexpected MKR override %+v", override)
+	}
+
+	_, err = LoadOverrides(137, path)
+	var configErr *apperr.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Errorf("Expected ConfigError for an invalid address, got %v", err)
+	}
+}